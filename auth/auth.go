@@ -3,8 +3,10 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/oauth2"
@@ -22,6 +24,13 @@ type (
 	}
 )
 
+// tokenInfoURL is Google's endpoint to validate access tokens, it doesn't use
+// any API quota.
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// ErrTokenInvalid when the identity provider rejects a token.
+var ErrTokenInvalid = errors.New("token is invalid")
+
 // NewAuther creates a oauth2 handler.
 func NewAuther(db *sqlx.DB, config *oauth2.Config) *Auther {
 	return &Auther{
@@ -101,3 +110,32 @@ func (a *Auther) getToken(ctx context.Context, tokenID int) (*oauth2.Token, erro
 	}
 	return &tok, nil
 }
+
+// CheckToken checks a token can still be used, refreshing it if it has expired
+// and asking the identity provider whether it has been revoked.
+func (a *Auther) CheckToken(ctx context.Context, tokenID int) error {
+	tok, err := a.getToken(ctx, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	tok, err = a.config.TokenSource(ctx, tok).Token()
+	if err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	u := tokenInfoURL + "?" + url.Values{"access_token": {tok.AccessToken}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to do request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: token info status code %d", ErrTokenInvalid, res.StatusCode)
+	}
+	return nil
+}
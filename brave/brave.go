@@ -1,10 +1,14 @@
 package brave
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
+
+	"github.com/ystv/showtime/metrics"
 )
 
 type (
@@ -12,10 +16,24 @@ type (
 	Braver struct {
 		baseURL *url.URL
 		c       *http.Client
+		timeout time.Duration
+		retries int
+		breaker *breaker
 	}
 	// Config to configure Brave.
 	Config struct {
 		Endpoint string
+		// Timeout of each request, defaults to 10s.
+		Timeout time.Duration
+		// Retries of idempotent requests when Brave is unavailable, defaults
+		// to 2. Negative disables retrying.
+		Retries int
+		// BreakerThreshold is how many requests in a row can find Brave
+		// unavailable before requests fail fast, defaults to 5.
+		BreakerThreshold int
+		// BreakerCooldown is how long requests fail fast for before one is
+		// let through to check Brave again, defaults to 30s.
+		BreakerCooldown time.Duration
 	}
 )
 
@@ -24,16 +42,62 @@ var (
 	ErrInvalidBaseURL = errors.New("failed to parse base URL")
 	// ErrRequestFailed to make a HTTP request.
 	ErrRequestFailed = errors.New("failed to make request")
+	// ErrNotFound when an object doesn't exist in Brave.
+	ErrNotFound = errors.New("not found")
+	// ErrBadRequest when Brave rejects a request.
+	ErrBadRequest = errors.New("bad request")
+	// ErrBraveUnavailable when Brave can't be reached, errors or the circuit
+	// breaker is open after it has been unavailable.
+	ErrBraveUnavailable = errors.New("brave is unavailable")
 )
 
 // New creates a new Brave client.
 func New(c Config) (*Braver, error) {
 	u, err := url.Parse(c.Endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrInvalidBaseURL, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBaseURL, err)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Retries == 0 {
+		c.Retries = 2
+	} else if c.Retries < 0 {
+		c.Retries = 0
+	}
+	if c.BreakerThreshold == 0 {
+		c.BreakerThreshold = 5
+	}
+	if c.BreakerCooldown == 0 {
+		c.BreakerCooldown = 30 * time.Second
 	}
 	return &Braver{
 		baseURL: u,
-		c:       &http.Client{},
+		c:       &http.Client{Transport: metrics.NewTransport(metrics.APIBrave, nil)},
+		timeout: c.Timeout,
+		retries: c.Retries,
+		breaker: &breaker{
+			threshold: c.BreakerThreshold,
+			cooldown:  c.BreakerCooldown,
+		},
 	}, nil
 }
+
+// Ping checks the Brave API is reachable.
+func (b *Braver) Ping(ctx context.Context) error {
+	return b.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/all",
+		idempotent: true,
+	})
+}
+
+// getJSON gets a Brave API path, decoding the response into v.
+func (b *Braver) getJSON(ctx context.Context, path string, v interface{}) error {
+	return b.do(ctx, request{
+		method:     http.MethodGet,
+		path:       path,
+		out:        v,
+		idempotent: true,
+	})
+}
@@ -0,0 +1,624 @@
+// Package bravesim is an in-memory Brave, for running ShowTime! without one.
+//
+// It implements the parts of Brave's REST API and websocket that ShowTime!
+// uses. Inputs, mixers, outputs and overlays are only records, no media is
+// pulled, mixed, drawn on or pushed, and state changes happen straight away.
+package bravesim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/brave"
+)
+
+type (
+	// Sim is a simulated Brave.
+	Sim struct {
+		mu       sync.Mutex
+		nextID   map[string]int
+		inputs   map[int]*brave.Input
+		mixers   map[int]*brave.Mixer
+		outputs  map[int]*brave.Output
+		overlays map[int]*brave.Overlay
+		sockets  map[*websocket.Conn]bool
+		mux      *echo.Echo
+	}
+	// editObject is the body of requests creating or updating objects, it
+	// covers every type.
+	editObject struct {
+		Type    string      `json:"type"`
+		State   brave.State `json:"state"`
+		URI     string      `json:"uri"`
+		Loop    bool        `json:"loop"`
+		Volume  interface{} `json:"volume"`
+		Width   int         `json:"width"`
+		Height  int         `json:"height"`
+		Source  string      `json:"source"`
+		Host    string      `json:"host"`
+		Port    int         `json:"port"`
+		Pattern string      `json:"pattern"`
+		// Overlays.
+		Text             *string `json:"text"`
+		Visible          *bool   `json:"visible"`
+		FontSize         int     `json:"font_size"`
+		VAlignment       string  `json:"valignment"`
+		HAlignment       string  `json:"halignment"`
+		ShadedBackground bool    `json:"shaded_background"`
+		XPos             int     `json:"xpos"`
+		YPos             int     `json:"ypos"`
+	}
+)
+
+// New creates a simulated Brave with nothing in it.
+func New() *Sim {
+	s := &Sim{
+		nextID:   map[string]int{},
+		inputs:   map[int]*brave.Input{},
+		mixers:   map[int]*brave.Mixer{},
+		outputs:  map[int]*brave.Output{},
+		overlays: map[int]*brave.Overlay{},
+		sockets:  map[*websocket.Conn]bool{},
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.GET("/api/all", s.getAll)
+	e.GET("/api/inputs", s.listInputs)
+	e.PUT("/api/inputs", s.newInput)
+	e.POST("/api/inputs/:id", s.updateInput)
+	e.DELETE("/api/inputs/:id", s.deleteInput)
+	e.GET("/api/mixers", s.listMixers)
+	e.PUT("/api/mixers", s.newMixer)
+	e.POST("/api/mixers/:id/cut_to_source", s.cutToSource)
+	e.POST("/api/mixers/:id/overlay_source", s.overlaySource)
+	e.POST("/api/mixers/:id/remove_source", s.removeFromMix)
+	e.DELETE("/api/mixers/:id", s.deleteMixer)
+	e.GET("/api/outputs", s.listOutputs)
+	e.PUT("/api/outputs", s.newOutput)
+	e.DELETE("/api/outputs/:id", s.deleteOutput)
+	e.GET("/api/overlays", s.listOverlays)
+	e.PUT("/api/overlays", s.newOverlay)
+	e.POST("/api/overlays/:id", s.updateOverlay)
+	e.DELETE("/api/overlays/:id", s.deleteOverlay)
+	e.GET("/socket", s.socket)
+	s.mux = e
+	return s
+}
+
+// Start serves the simulator in the background, returning its URL. An address
+// with port 0, i.e. "127.0.0.1:0", picks a free port.
+func (s *Sim) Start(addr string) (string, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	go func() {
+		_ = http.Serve(l, s)
+	}()
+	return "http://" + l.Addr().String(), nil
+}
+
+// ServeHTTP serves Brave's API.
+func (s *Sim) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Sim) getAll(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"inputs":   s.sortedInputs(),
+		"mixers":   s.sortedMixers(),
+		"outputs":  s.sortedOutputs(),
+		"overlays": s.sortedOverlays(),
+	})
+}
+
+func (s *Sim) listInputs(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.JSON(http.StatusOK, s.sortedInputs())
+}
+
+func (s *Sim) newInput(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	switch p.Type {
+	case "uri", "image":
+	default:
+		return badRequest(c, fmt.Sprintf("unsupported input type %q", p.Type))
+	}
+	if p.URI == "" {
+		return badRequest(c, "missing uri")
+	}
+	volume, err := parseVolume(p.Volume)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	if p.State == "" {
+		p.State = brave.StatePlaying
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID("input")
+	i := &brave.Input{
+		ID:           id,
+		UID:          fmt.Sprintf("input%d", id),
+		Type:         p.Type,
+		State:        p.State,
+		DesiredState: p.State,
+		URI:          p.URI,
+		Loop:         p.Loop,
+		Volume:       volume,
+		HasAudio:     p.Type == "uri",
+		HasVideo:     true,
+	}
+	s.inputs[id] = i
+	s.broadcast("update", "input", i)
+	return c.JSON(http.StatusOK, map[string]interface{}{"id": i.ID, "uid": i.UID})
+}
+
+func (s *Sim) updateInput(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.inputs[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such input ID")
+	}
+	if p.State != "" {
+		switch p.State {
+		case brave.StateNull, brave.StateReady, brave.StatePaused, brave.StatePlaying:
+		default:
+			return badRequest(c, fmt.Sprintf("invalid state %q", p.State))
+		}
+		i.State = p.State
+		i.DesiredState = p.State
+	}
+	if p.Volume != nil {
+		i.Volume, err = parseVolume(p.Volume)
+		if err != nil {
+			return badRequest(c, err.Error())
+		}
+	}
+	s.broadcast("update", "input", i)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) deleteInput(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.inputs[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such input ID")
+	}
+	delete(s.inputs, i.ID)
+	// Brave removes a deleted input from any mixer it was in.
+	for _, m := range s.mixers {
+		if removeSource(m, i.UID) {
+			s.broadcast("update", "mixer", m)
+		}
+	}
+	s.broadcast("delete", "input", i)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) listMixers(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.JSON(http.StatusOK, s.sortedMixers())
+}
+
+func (s *Sim) newMixer(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	if p.Width <= 0 || p.Height <= 0 {
+		return badRequest(c, "invalid width or height")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newID("mixer")
+	m := &brave.Mixer{
+		ID:      id,
+		UID:     fmt.Sprintf("mixer%d", id),
+		State:   brave.StatePlaying,
+		Width:   p.Width,
+		Height:  p.Height,
+		Sources: []brave.MixerSource{},
+	}
+	s.mixers[id] = m
+	s.broadcast("update", "mixer", m)
+	return c.JSON(http.StatusOK, map[string]interface{}{"id": m.ID, "uid": m.UID})
+}
+
+func (s *Sim) cutToSource(c echo.Context) error {
+	return s.changeSource(c, func(m *brave.Mixer, uid string) {
+		found := false
+		for i := range m.Sources {
+			m.Sources[i].InMix = m.Sources[i].UID == uid
+			found = found || m.Sources[i].UID == uid
+		}
+		if !found {
+			m.Sources = append(m.Sources, brave.MixerSource{UID: uid, InMix: true})
+		}
+	})
+}
+
+// overlaySource puts a source in the mix, on top of the sources already in
+// it.
+func (s *Sim) overlaySource(c echo.Context) error {
+	return s.changeSource(c, func(m *brave.Mixer, uid string) {
+		removeSource(m, uid)
+		m.Sources = append(m.Sources, brave.MixerSource{UID: uid, InMix: true})
+	})
+}
+
+// removeFromMix takes a source out of the mix, leaving it connected.
+func (s *Sim) removeFromMix(c echo.Context) error {
+	return s.changeSource(c, func(m *brave.Mixer, uid string) {
+		for i := range m.Sources {
+			if m.Sources[i].UID == uid {
+				m.Sources[i].InMix = false
+			}
+		}
+	})
+}
+
+// changeSource applies a change of the request's source to its mixer.
+func (s *Sim) changeSource(c echo.Context, change func(m *brave.Mixer, uid string)) error {
+	p := struct {
+		UID string `json:"uid"`
+	}{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mixers[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such mixer ID")
+	}
+	if !s.exists(p.UID) {
+		return badRequest(c, fmt.Sprintf("no such source %q", p.UID))
+	}
+	change(m, p.UID)
+	s.broadcast("update", "mixer", m)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) deleteMixer(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mixers[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such mixer ID")
+	}
+	delete(s.mixers, m.ID)
+	for _, other := range s.mixers {
+		if removeSource(other, m.UID) {
+			s.broadcast("update", "mixer", other)
+		}
+	}
+	s.broadcast("delete", "mixer", m)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) listOutputs(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.JSON(http.StatusOK, s.sortedOutputs())
+}
+
+func (s *Sim) newOutput(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	uri := p.URI
+	switch p.Type {
+	case "rtmp":
+		if uri == "" {
+			return badRequest(c, "missing uri")
+		}
+	case "tcp":
+		uri = "tcp://" + net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	default:
+		return badRequest(c, fmt.Sprintf("unsupported output type %q", p.Type))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.Source != "" && !s.exists(p.Source) {
+		return badRequest(c, fmt.Sprintf("no such source %q", p.Source))
+	}
+	id := s.newID("output")
+	o := &brave.Output{
+		ID:     id,
+		UID:    fmt.Sprintf("output%d", id),
+		Type:   p.Type,
+		State:  brave.StatePlaying,
+		Src:    p.Source,
+		Dst:    uri,
+		Width:  p.Width,
+		Height: p.Height,
+	}
+	s.outputs[id] = o
+	s.broadcast("update", "output", o)
+	return c.JSON(http.StatusOK, map[string]interface{}{"id": o.ID, "uid": o.UID})
+}
+
+func (s *Sim) deleteOutput(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.outputs[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such output ID")
+	}
+	delete(s.outputs, o.ID)
+	s.broadcast("delete", "output", o)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) listOverlays(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.JSON(http.StatusOK, s.sortedOverlays())
+}
+
+func (s *Sim) newOverlay(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	switch p.Type {
+	case "text", "clock":
+	case "image":
+		if p.URI == "" {
+			return badRequest(c, "missing uri")
+		}
+	default:
+		return badRequest(c, fmt.Sprintf("unsupported overlay type %q", p.Type))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.exists(p.Source) {
+		return badRequest(c, fmt.Sprintf("no such source %q", p.Source))
+	}
+	id := s.newID("overlay")
+	o := &brave.Overlay{
+		ID:               id,
+		UID:              fmt.Sprintf("overlay%d", id),
+		Type:             p.Type,
+		Src:              p.Source,
+		FontSize:         p.FontSize,
+		VAlignment:       p.VAlignment,
+		HAlignment:       p.HAlignment,
+		ShadedBackground: p.ShadedBackground,
+		URI:              p.URI,
+		Width:            p.Width,
+		Height:           p.Height,
+		XPos:             p.XPos,
+		YPos:             p.YPos,
+	}
+	if p.Text != nil {
+		o.Text = *p.Text
+	}
+	if p.Visible != nil {
+		o.Visible = *p.Visible
+	}
+	s.overlays[id] = o
+	s.broadcast("update", "overlay", o)
+	return c.JSON(http.StatusOK, map[string]interface{}{"id": o.ID, "uid": o.UID})
+}
+
+func (s *Sim) updateOverlay(c echo.Context) error {
+	p := editObject{}
+	err := c.Bind(&p)
+	if err != nil {
+		return badRequest(c, "invalid body")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overlays[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such overlay ID")
+	}
+	if p.Text != nil {
+		o.Text = *p.Text
+	}
+	if p.Visible != nil {
+		o.Visible = *p.Visible
+	}
+	s.broadcast("update", "overlay", o)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+func (s *Sim) deleteOverlay(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overlays[atoi(c.Param("id"))]
+	if !ok {
+		return badRequest(c, "no such overlay ID")
+	}
+	delete(s.overlays, o.ID)
+	s.broadcast("delete", "overlay", o)
+	return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+}
+
+// socket pushes updates to a websocket client until it disconnects.
+func (s *Sim) socket(c echo.Context) error {
+	up := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := up.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.sockets[conn] = true
+	s.mu.Unlock()
+
+	// Nothing is expected from clients, reading handles pings and notices
+	// the client going.
+	for {
+		_, _, err = conn.ReadMessage()
+		if err != nil {
+			break
+		}
+	}
+	s.mu.Lock()
+	delete(s.sockets, conn)
+	s.mu.Unlock()
+	return conn.Close()
+}
+
+// broadcast sends an update to every websocket client, s.mu must be held so
+// writes to a client don't overlap.
+func (s *Sim) broadcast(msgType, blockType string, object interface{}) {
+	data := map[string]interface{}{}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+	if json.Unmarshal(b, &data) != nil {
+		return
+	}
+	data["block_type"] = blockType
+	msg := map[string]interface{}{
+		"msg_type": msgType,
+		"data":     data,
+	}
+	for conn := range s.sockets {
+		err = conn.WriteJSON(msg)
+		if err != nil {
+			conn.Close()
+			delete(s.sockets, conn)
+		}
+	}
+}
+
+// newID returns the next ID of a type of object, s.mu must be held. Like
+// Brave, IDs count up from 1 for each type.
+func (s *Sim) newID(object string) int {
+	s.nextID[object]++
+	return s.nextID[object]
+}
+
+// exists checks an input or mixer exists by its UID, s.mu must be held.
+func (s *Sim) exists(uid string) bool {
+	for _, i := range s.inputs {
+		if i.UID == uid {
+			return true
+		}
+	}
+	for _, m := range s.mixers {
+		if m.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sim) sortedInputs() []brave.Input {
+	inputs := make([]brave.Input, 0, len(s.inputs))
+	for _, i := range s.inputs {
+		inputs = append(inputs, *i)
+	}
+	sort.Slice(inputs, func(a, b int) bool { return inputs[a].ID < inputs[b].ID })
+	return inputs
+}
+
+func (s *Sim) sortedMixers() []brave.Mixer {
+	mixers := make([]brave.Mixer, 0, len(s.mixers))
+	for _, m := range s.mixers {
+		mixers = append(mixers, *m)
+	}
+	sort.Slice(mixers, func(a, b int) bool { return mixers[a].ID < mixers[b].ID })
+	return mixers
+}
+
+func (s *Sim) sortedOutputs() []brave.Output {
+	outputs := make([]brave.Output, 0, len(s.outputs))
+	for _, o := range s.outputs {
+		outputs = append(outputs, *o)
+	}
+	sort.Slice(outputs, func(a, b int) bool { return outputs[a].ID < outputs[b].ID })
+	return outputs
+}
+
+func (s *Sim) sortedOverlays() []brave.Overlay {
+	overlays := make([]brave.Overlay, 0, len(s.overlays))
+	for _, o := range s.overlays {
+		overlays = append(overlays, *o)
+	}
+	sort.Slice(overlays, func(a, b int) bool { return overlays[a].ID < overlays[b].ID })
+	return overlays
+}
+
+// removeSource removes a source from a mixer, returning whether it was there.
+func removeSource(m *brave.Mixer, uid string) bool {
+	for i, src := range m.Sources {
+		if src.UID == uid {
+			m.Sources = append(m.Sources[:i], m.Sources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// parseVolume parses a volume from 0 to 1, which ShowTime! sends as a string.
+func parseVolume(v interface{}) (float64, error) {
+	var volume float64
+	switch v := v.(type) {
+	case nil:
+		return 1, nil
+	case float64:
+		volume = v
+	case string:
+		var err error
+		volume, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid volume %q", v)
+		}
+	default:
+		return 0, fmt.Errorf("invalid volume %v", v)
+	}
+	if volume < 0 || volume > 1 {
+		return 0, fmt.Errorf("volume %v out of range", volume)
+	}
+	return volume, nil
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// badRequest responds like Brave does to a rejected request.
+func badRequest(c echo.Context, msg string) error {
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+}
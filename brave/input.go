@@ -1,114 +1,99 @@
 package brave
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
+	"strconv"
+	"time"
 )
 
 // Input are sources for mixers.
 type Input struct {
-	ID int
+	ID           int    `json:"id"`
+	UID          string `json:"uid"`
+	Type         string `json:"type"`
+	State        State  `json:"state"`
+	DesiredState State  `json:"desired_state"`
+	URI          string `json:"uri"`
+	Loop         bool   `json:"loop"`
+	// Volume is from 0 to 1.
+	Volume   float64       `json:"volume"`
+	Position time.Duration `json:"position"`
+	// Duration is 0 for live sources.
+	Duration         time.Duration `json:"duration"`
+	BufferingPercent int           `json:"buffering_percent"`
+	HasAudio         bool          `json:"has_audio"`
+	HasVideo         bool          `json:"has_video"`
+	Width            int           `json:"width"`
+	Height           int           `json:"height"`
 }
 
-// PlayInput triggers Brave to start playing the input.
-func (b *Braver) PlayInput(ctx context.Context, inputID int) error {
-	data := struct {
-		State string `json:"state"`
-	}{
-		State: "PLAYING",
-	}
-
-	body, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/inputs/%d", inputID)})
-	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewBuffer(body))
+// ListInputs lists all Brave inputs.
+func (b *Braver) ListInputs(ctx context.Context) ([]Input, error) {
+	inputs := []Input{}
+	err := b.getJSON(ctx, "/api/inputs", &inputs)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrRequestFailed, err)
+		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
+	return inputs, nil
+}
 
-	res, err := b.c.Do(req)
+// GetInput gets the current state of an input.
+func (b *Braver) GetInput(ctx context.Context, inputID int) (Input, error) {
+	inputs, err := b.ListInputs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
+		return Input{}, err
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+	for _, i := range inputs {
+		if i.ID == inputID {
+			return i, nil
 		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
-	}
-
-	resp := &struct {
-		Status string `json:"status"`
-	}{}
-	err = json.NewDecoder(res.Body).Decode(resp)
-	if err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
 	}
+	return Input{}, fmt.Errorf("input %d: %w", inputID, ErrNotFound)
+}
 
-	return nil
+// PlayInput triggers Brave to start playing the input.
+func (b *Braver) PlayInput(ctx context.Context, inputID int) error {
+	return b.setInputState(ctx, inputID, StatePlaying)
 }
 
 // PauseInput triggers Brave to pause the input.
 func (b *Braver) PauseInput(ctx context.Context, inputID int) error {
-	data := struct {
-		State string `json:"state"`
-	}{
-		State: "PAUSED",
-	}
-
-	body, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/inputs/%d", inputID)})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrRequestFailed, err)
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
-	}
+	return b.setInputState(ctx, inputID, StatePaused)
+}
 
-	resp := &struct {
-		Status string `json:"status"`
-	}{}
-	err = json.NewDecoder(res.Body).Decode(resp)
-	if err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
+// SetInputVolume sets an input's volume, from 0 to 1.
+func (b *Braver) SetInputVolume(ctx context.Context, inputID int, volume float64) error {
+	return b.do(ctx, request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/api/inputs/%d", inputID),
+		in: struct {
+			Volume string `json:"volume"`
+		}{
+			Volume: strconv.FormatFloat(volume, 'f', 2, 64),
+		},
+		idempotent: true,
+	})
+}
 
-	return nil
+func (b *Braver) setInputState(ctx context.Context, inputID int, state State) error {
+	return b.do(ctx, request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/api/inputs/%d", inputID),
+		in: struct {
+			State State `json:"state"`
+		}{
+			State: state,
+		},
+		idempotent: true,
+	})
 }
 
-// NewURIInput creates a new URI input in Brave.
+// NewURIInput creates a new URI input in Brave, with a volume from 0 to 1.
 //
 // General-purpose input.
-func (b *Braver) NewURIInput(ctx context.Context, uri string, loop bool) (Input, error) {
+func (b *Braver) NewURIInput(ctx context.Context, uri string, loop bool, volume float64) (Input, error) {
 	data := struct {
 		Type   string `json:"type"`
 		State  string `json:"state"`
@@ -119,46 +104,28 @@ func (b *Braver) NewURIInput(ctx context.Context, uri string, loop bool) (Input,
 		Type:   "uri",
 		State:  "NULL",
 		URI:    uri,
-		Volume: "1.0",
+		Volume: strconv.FormatFloat(volume, 'f', 2, 64),
 		Loop:   loop,
 	}
-	body, err := json.Marshal(data)
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/inputs",
+		in:     data,
+		out:    &resp,
+	})
 	if err != nil {
-		return Input{}, fmt.Errorf("failed to marshal json: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: "/api/inputs"})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		return Input{}, ErrRequestFailed
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return Input{}, fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return Input{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Input{}, fmt.Errorf("bad request: %s", string(resBytes))
-	}
-
-	resp := &struct {
-		ID  int    `json:"id"`
-		UID string `json:"uid"`
-	}{}
-	err = json.NewDecoder(res.Body).Decode(resp)
-	if err != nil {
-		return Input{}, fmt.Errorf("failed to decode response: %w", err)
+		return Input{}, err
 	}
 
 	return Input{
-		ID: resp.ID,
+		ID:     resp.ID,
+		UID:    resp.UID,
+		Type:   "uri",
+		State:  StateNull,
+		URI:    uri,
+		Loop:   loop,
+		Volume: volume,
 	}, nil
 }
 
@@ -173,68 +140,43 @@ func (b *Braver) NewImageInput(ctx context.Context, uri string) (Input, error) {
 		State: "PLAYING",
 		URI:   uri,
 	}
-	body, err := json.Marshal(data)
-	if err != nil {
-		return Input{}, fmt.Errorf("failed to marshal json: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: "/api/inputs"})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		return Input{}, ErrRequestFailed
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return Input{}, fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return Input{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Input{}, fmt.Errorf("bad request: %s", string(resBytes))
-	}
-
-	resp := &struct {
-		ID  int    `json:"id"`
-		UID string `json:"uid"`
-	}{}
-	err = json.NewDecoder(res.Body).Decode(resp)
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/inputs",
+		in:     data,
+		out:    &resp,
+	})
 	if err != nil {
-		return Input{}, fmt.Errorf("failed to decode response: %w", err)
+		return Input{}, err
 	}
 
 	return Input{
-		ID: resp.ID,
+		ID:    resp.ID,
+		UID:   resp.UID,
+		Type:  "image",
+		State: StatePlaying,
+		URI:   uri,
 	}, nil
 }
 
 // DeleteInput delete an input in Brave.
 func (b *Braver) DeleteInput(ctx context.Context, inputID int) error {
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/inputs/%d", inputID)})
-	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
-	if err != nil {
-		return ErrRequestFailed
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
+	return b.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("/api/inputs/%d", inputID),
+		idempotent: true,
+	})
+}
 
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
-	}
+// Ended checks whether an input has played to the end of its media, live
+// sources never end.
+func (i Input) Ended() bool {
+	return !i.Loop && i.Duration > 0 && i.Position >= i.Duration
+}
 
-	return nil
+// Failed checks whether an input has stopped when it should be playing,
+// i.e. a stream it was pulling has gone.
+func (i Input) Failed() bool {
+	return i.DesiredState == StatePlaying && i.State == StateNull
 }
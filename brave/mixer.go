@@ -1,21 +1,26 @@
 package brave
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
 )
 
 type (
 	// Mixer provides media mixing.
 	Mixer struct {
-		ID     int
-		width  int
-		height int
+		ID      int           `json:"id"`
+		UID     string        `json:"uid"`
+		State   State         `json:"state"`
+		Width   int           `json:"width"`
+		Height  int           `json:"height"`
+		Sources []MixerSource `json:"sources"`
+	}
+	// MixerSource is an input or mixer connected to a mixer.
+	MixerSource struct {
+		UID string `json:"uid"`
+		// InMix when the source is part of the mixer's program.
+		InMix bool `json:"in_mix"`
 	}
 	// NewMixerParams are fields configuring the mixer.
 	NewMixerParams struct {
@@ -31,114 +36,99 @@ func (b *Braver) NewMixer(ctx context.Context, p NewMixerParams) (Mixer, error)
 		Width   int    `json:"width"`
 		Height  int    `json:"height"`
 	}{
-		Pattern: "0",
+		// Black, which shows when nothing is in the program.
+		Pattern: "2",
 		Width:   p.Width,
 		Height:  p.Height,
 	}
-
-	body, err := json.Marshal(data)
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/mixers",
+		in:     data,
+		out:    &resp,
+	})
 	if err != nil {
-		return Mixer{}, fmt.Errorf("failed to marshal json: %w", err)
+		return Mixer{}, err
 	}
+	return Mixer{
+		ID:     resp.ID,
+		UID:    resp.UID,
+		Width:  p.Width,
+		Height: p.Height,
+	}, nil
+}
 
-	u := b.baseURL.ResolveReference(&url.URL{Path: "/api/mixers"})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(body))
+// ListMixers lists all Brave mixers.
+func (b *Braver) ListMixers(ctx context.Context) ([]Mixer, error) {
+	mixers := []Mixer{}
+	err := b.getJSON(ctx, "/api/mixers", &mixers)
 	if err != nil {
-		return Mixer{}, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
+	return mixers, nil
+}
 
-	res, err := b.c.Do(req)
+// GetMixer gets the current state of a mixer.
+func (b *Braver) GetMixer(ctx context.Context, mixerID int) (Mixer, error) {
+	mixers, err := b.ListMixers(ctx)
 	if err != nil {
-		return Mixer{}, fmt.Errorf("failed to do request: %w", err)
+		return Mixer{}, err
 	}
-	defer res.Body.Close()
-
-	switch res.StatusCode {
-	case http.StatusOK:
-		resp := &struct {
-			ID  int    `json:"id"`
-			UID string `json:"uid"`
-		}{}
-		err = json.NewDecoder(res.Body).Decode(resp)
-		if err != nil {
-			return Mixer{}, fmt.Errorf("failed to decode response: %w", err)
+	for _, m := range mixers {
+		if m.ID == mixerID {
+			return m, nil
 		}
-		return Mixer{
-			ID:     resp.ID,
-			width:  p.Width,
-			height: p.Height,
-		}, nil
-	case http.StatusBadRequest:
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return Mixer{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return Mixer{}, fmt.Errorf("mixer %d: %w", mixerID, ErrNotFound)
+}
+
+// Program returns the UIDs of the sources in the mixer's program.
+func (m Mixer) Program() []string {
+	var uids []string
+	for _, src := range m.Sources {
+		if src.InMix {
+			uids = append(uids, src.UID)
 		}
-		return Mixer{}, fmt.Errorf("bad request: %s", string(resBytes))
-	default:
-		return Mixer{}, fmt.Errorf("unexpected HTTP response status code: %d", res.StatusCode)
 	}
+	return uids
 }
 
 // CutMixerToInput sets a mixer's program output to a given input.
 func (b *Braver) CutMixerToInput(ctx context.Context, mixerID int, inputID int) error {
-	data := struct {
-		UID string `json:"uid"`
-	}{
-		UID: fmt.Sprintf("input%d", inputID),
-	}
-
-	body, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/mixers/%d/cut_to_source", mixerID)})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrRequestFailed, err)
-	}
-	req.Header.Add("Accept", "application/json")
+	return b.changeMixerSource(ctx, mixerID, "cut_to_source", inputID)
+}
 
-	res, err := b.c.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
+// OverlayInputOnMixer adds an input to a mixer's program on top of the
+// sources already in it.
+func (b *Braver) OverlayInputOnMixer(ctx context.Context, mixerID int, inputID int) error {
+	return b.changeMixerSource(ctx, mixerID, "overlay_source", inputID)
+}
 
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
-	}
+// RemoveInputFromMixer takes an input out of a mixer's program, the mixer's
+// background shows when nothing is left in it.
+func (b *Braver) RemoveInputFromMixer(ctx context.Context, mixerID int, inputID int) error {
+	return b.changeMixerSource(ctx, mixerID, "remove_source", inputID)
+}
 
-	return nil
+func (b *Braver) changeMixerSource(ctx context.Context, mixerID int, action string, inputID int) error {
+	return b.do(ctx, request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/api/mixers/%d/%s", mixerID, action),
+		in: struct {
+			UID string `json:"uid"`
+		}{
+			UID: fmt.Sprintf("input%d", inputID),
+		},
+		idempotent: true,
+	})
 }
 
 // DeleteMixer delete an mixer in Brave.
 func (b *Braver) DeleteMixer(ctx context.Context, mixerID int) error {
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/mixers/%d", mixerID)})
-	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
-	if err != nil {
-		return ErrRequestFailed
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
-	}
-
-	return nil
+	return b.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("/api/mixers/%d", mixerID),
+		idempotent: true,
+	})
 }
@@ -1,22 +1,24 @@
 package brave
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"strconv"
 )
 
 // Output provides output from a mixer.
 type Output struct {
-	ID  int
-	Src string
-	Dst string
+	ID    int    `json:"id"`
+	UID   string `json:"uid"`
+	Type  string `json:"type"`
+	State State  `json:"state"`
+	// Src is the UID of the mixer or input being output.
+	Src    string `json:"source"`
+	Dst    string `json:"uri"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 // NewRTMPOutput creates an RTMP output of a mixer.
@@ -31,53 +33,29 @@ func (b *Braver) NewRTMPOutput(ctx context.Context, m Mixer, outURI string) (Out
 	}{
 		Type:   "rtmp",
 		URI:    outURI,
-		Width:  m.width,
-		Height: m.height,
+		Width:  m.Width,
+		Height: m.Height,
 		Source: source,
 	}
-
-	body, err := json.Marshal(data)
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/outputs",
+		in:     data,
+		out:    &resp,
+	})
 	if err != nil {
-		return Output{}, fmt.Errorf("failed to marshal brave /outputs request body: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: "/api/outputs"})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		return Output{}, fmt.Errorf("%w: %w", ErrRequestFailed, err)
-	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return Output{}, fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
-
-	switch res.StatusCode {
-	case http.StatusOK:
-		resp := &struct {
-			ID  int    `json:"id"`
-			UID string `json:"uid"`
-		}{}
-		err = json.NewDecoder(res.Body).Decode(resp)
-		if err != nil {
-			return Output{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Output{
-			ID:  resp.ID,
-			Src: source,
-			Dst: outURI,
-		}, nil
-	case http.StatusBadRequest:
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return Output{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Output{}, fmt.Errorf("bad request: %s", string(resBytes))
-	default:
-		return Output{}, fmt.Errorf("unexpected HTTP response status code: %d", res.StatusCode)
+		return Output{}, err
 	}
+	return Output{
+		ID:     resp.ID,
+		UID:    resp.UID,
+		Type:   "rtmp",
+		Src:    source,
+		Dst:    outURI,
+		Width:  m.Width,
+		Height: m.Height,
+	}, nil
 }
 
 // NewTCPOutput creates a TCP output of a mixer.
@@ -94,82 +72,65 @@ func (b *Braver) NewTCPOutput(ctx context.Context, m Mixer, port int) (Output, e
 		Type:   "tcp",
 		Host:   "0.0.0.0",
 		Port:   port,
-		Width:  m.width,
-		Height: m.height,
+		Width:  m.Width,
+		Height: m.Height,
 		Source: source,
 	}
-
-	body, err := json.Marshal(data)
-	if err != nil {
-		return Output{}, fmt.Errorf("failed to marshal brave /outputs request body: %w", err)
-	}
-
-	u := b.baseURL.ResolveReference(&url.URL{Path: "/api/outputs"})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(body))
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/outputs",
+		in:     data,
+		out:    &resp,
+	})
 	if err != nil {
-		return Output{}, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+		return Output{}, err
 	}
-	req.Header.Add("Accept", "application/json")
-
-	res, err := b.c.Do(req)
-	if err != nil {
-		return Output{}, fmt.Errorf("failed to do request: %w", err)
-	}
-	defer res.Body.Close()
+	return Output{
+		ID:     resp.ID,
+		UID:    resp.UID,
+		Type:   "tcp",
+		Src:    source,
+		Dst:    b.TCPOutputURI(port),
+		Width:  m.Width,
+		Height: m.Height,
+	}, nil
+}
 
-	switch res.StatusCode {
-	case http.StatusOK:
-		resp := &struct {
-			ID  int    `json:"id"`
-			UID string `json:"uid"`
-		}{}
-		err = json.NewDecoder(res.Body).Decode(resp)
-		if err != nil {
-			return Output{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Output{
-			ID:  resp.ID,
-			Src: source,
-			Dst: fmt.Sprintf("tcp://%s", net.JoinHostPort(b.baseURL.Host, strconv.Itoa(port))),
-		}, nil
-	case http.StatusBadRequest:
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return Output{}, fmt.Errorf("failed to decode response: %w", err)
-		}
-		return Output{}, fmt.Errorf("bad request: %s", string(resBytes))
-	default:
-		return Output{}, fmt.Errorf("unexpected HTTP response status code: %d", res.StatusCode)
-	}
+// TCPOutputURI is where a TCP output on a port can be read from.
+func (b *Braver) TCPOutputURI(port int) string {
+	return fmt.Sprintf("tcp://%s", net.JoinHostPort(b.baseURL.Hostname(), strconv.Itoa(port)))
 }
 
 // ListOutputs lists all Brave outputs.
 func (b *Braver) ListOutputs(ctx context.Context) ([]Output, error) {
-	return nil, nil
-}
-
-// DeleteOutput delete an output in Brave.
-func (b *Braver) DeleteOutput(ctx context.Context, outputID int) error {
-	u := b.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("/api/outputs/%d", outputID)})
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	outputs := []Output{}
+	err := b.getJSON(ctx, "/api/outputs", &outputs)
 	if err != nil {
-		return ErrRequestFailed
+		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
+	return outputs, nil
+}
 
-	res, err := b.c.Do(req)
+// GetOutput gets the current state of an output.
+func (b *Braver) GetOutput(ctx context.Context, outputID int) (Output, error) {
+	outputs, err := b.ListOutputs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to do request: %w", err)
+		return Output{}, err
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		resBytes, err := io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+	for _, o := range outputs {
+		if o.ID == outputID {
+			return o, nil
 		}
-		return fmt.Errorf("bad request: %s", string(resBytes))
 	}
+	return Output{}, fmt.Errorf("output %d: %w", outputID, ErrNotFound)
+}
 
-	return nil
+// DeleteOutput delete an output in Brave.
+func (b *Braver) DeleteOutput(ctx context.Context, outputID int) error {
+	return b.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("/api/outputs/%d", outputID),
+		idempotent: true,
+	})
 }
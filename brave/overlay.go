@@ -0,0 +1,97 @@
+package brave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Overlay is text, a clock or an image drawn over a mixer.
+	Overlay struct {
+		ID   int    `json:"id"`
+		UID  string `json:"uid"`
+		Type string `json:"type"`
+		// Src is the UID of the mixer the overlay is drawn on.
+		Src     string `json:"source"`
+		Visible bool   `json:"visible"`
+		// Text, FontSize and the alignments are of text and clock overlays,
+		// a clock's text is shown alongside the time.
+		Text             string `json:"text,omitempty"`
+		FontSize         int    `json:"font_size,omitempty"`
+		VAlignment       string `json:"valignment,omitempty"`
+		HAlignment       string `json:"halignment,omitempty"`
+		ShadedBackground bool   `json:"shaded_background,omitempty"`
+		// URI, the size and position are of image overlays.
+		URI    string `json:"uri,omitempty"`
+		Width  int    `json:"width,omitempty"`
+		Height int    `json:"height,omitempty"`
+		XPos   int    `json:"xpos,omitempty"`
+		YPos   int    `json:"ypos,omitempty"`
+	}
+	// UpdateOverlay changes a text overlay, nil fields are left as they are.
+	UpdateOverlay struct {
+		Text    *string `json:"text,omitempty"`
+		Visible *bool   `json:"visible,omitempty"`
+	}
+)
+
+// Overlay types.
+const (
+	OverlayText  = "text"
+	OverlayClock = "clock"
+	OverlayImage = "image"
+)
+
+// NewOverlay creates an overlay on a mixer, the overlay's ID, UID and source
+// are ignored.
+func (b *Braver) NewOverlay(ctx context.Context, m Mixer, o Overlay) (Overlay, error) {
+	o.Src = fmt.Sprintf("mixer%d", m.ID)
+	resp := created{}
+	err := b.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/overlays",
+		in: struct {
+			Overlay
+			// Shadow the overlay's, Brave assigns them.
+			ID  int    `json:"id,omitempty"`
+			UID string `json:"uid,omitempty"`
+		}{Overlay: o},
+		out: &resp,
+	})
+	if err != nil {
+		return Overlay{}, err
+	}
+	o.ID = resp.ID
+	o.UID = resp.UID
+	return o, nil
+}
+
+// UpdateOverlay changes an overlay's text or visibility.
+func (b *Braver) UpdateOverlay(ctx context.Context, overlayID int, u UpdateOverlay) error {
+	return b.do(ctx, request{
+		method:     http.MethodPost,
+		path:       fmt.Sprintf("/api/overlays/%d", overlayID),
+		in:         u,
+		idempotent: true,
+	})
+}
+
+// ListOverlays lists all Brave overlays.
+func (b *Braver) ListOverlays(ctx context.Context) ([]Overlay, error) {
+	overlays := []Overlay{}
+	err := b.getJSON(ctx, "/api/overlays", &overlays)
+	if err != nil {
+		return nil, err
+	}
+	return overlays, nil
+}
+
+// DeleteOverlay delete an overlay in Brave.
+func (b *Braver) DeleteOverlay(ctx context.Context, overlayID int) error {
+	return b.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("/api/overlays/%d", overlayID),
+		idempotent: true,
+	})
+}
@@ -0,0 +1,204 @@
+package brave
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// request is a call to the Brave API.
+	request struct {
+		method string
+		path   string
+		// in is encoded as the JSON body when it isn't nil.
+		in interface{}
+		// out is decoded from the JSON response when it isn't nil.
+		out interface{}
+		// idempotent requests can be retried, they have the same effect
+		// however many times they are made.
+		idempotent bool
+	}
+	// created is Brave's response to creating an object.
+	created struct {
+		ID  int    `json:"id"`
+		UID string `json:"uid"`
+	}
+	// breaker stops requests being made whilst Brave is unavailable, so
+	// callers fail fast instead of each waiting for a timeout.
+	breaker struct {
+		mu        sync.Mutex
+		threshold int
+		cooldown  time.Duration
+		failures  int
+		openUntil time.Time
+	}
+)
+
+// retryBackoff is the base delay between retries, doubling each attempt.
+const retryBackoff = 250 * time.Millisecond
+
+// do makes a request to Brave, retrying idempotent requests with jittered
+// backoff whilst Brave is unavailable.
+func (b *Braver) do(ctx context.Context, r request) error {
+	var body []byte
+	if r.in != nil {
+		var err error
+		body, err = json.Marshal(r.in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal json: %w", err)
+		}
+	}
+
+	attempts := 1
+	if r.idempotent {
+		attempts += b.retries
+	}
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			// Full jitter so retrying clients don't all hit Brave at once.
+			backoff := retryBackoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%s %s: %w", r.method, r.path, ctx.Err())
+			case <-time.After(time.Duration(rand.Int63n(int64(backoff)))):
+			}
+		}
+		attemptErr := b.attempt(ctx, r, body)
+		if attempt > 0 && errors.Is(attemptErr, errBreakerOpen) {
+			// This request's failures opened the breaker, they say more.
+			break
+		}
+		err = attemptErr
+		if !errors.Is(err, ErrBraveUnavailable) || errors.Is(err, errBreakerOpen) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// errBreakerOpen when a request isn't made since the circuit breaker is open.
+var errBreakerOpen = fmt.Errorf("%w: circuit breaker open", ErrBraveUnavailable)
+
+// attempt makes a single request to Brave within the client's timeout.
+func (b *Braver) attempt(ctx context.Context, r request, body []byte) error {
+	if !b.breaker.allow() {
+		return errBreakerOpen
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	u := b.baseURL.ResolveReference(&url.URL{Path: r.path})
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	res, err := b.c.Do(req)
+	if err != nil {
+		if ctx.Err() != nil && errors.Is(ctx.Err(), context.Canceled) {
+			// The caller gave up, it says nothing about Brave.
+			return err
+		}
+		b.breaker.failure()
+		return fmt.Errorf("%w: %v", ErrBraveUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		b.breaker.failure()
+		return fmt.Errorf("%w: %s", ErrBraveUnavailable, responseError(res))
+	}
+	b.breaker.success()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, responseError(res))
+	case res.StatusCode == http.StatusBadRequest:
+		msg := responseError(res)
+		// Brave rejects unknown IDs as a bad request, i.e. "no such input ID".
+		if strings.Contains(msg, "no such") {
+			return fmt.Errorf("%w: %s", ErrNotFound, msg)
+		}
+		return fmt.Errorf("%w: %s", ErrBadRequest, msg)
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected HTTP response status code: %d", res.StatusCode)
+	}
+
+	if r.out == nil {
+		return nil
+	}
+	err = json.NewDecoder(res.Body).Decode(r.out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// responseError reads the error message of a response, Brave responds with
+// {"error": "..."}.
+func responseError(res *http.Response) string {
+	b, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if err != nil {
+		return fmt.Sprintf("status %d", res.StatusCode)
+	}
+	e := struct {
+		Error string `json:"error"`
+	}{}
+	if json.Unmarshal(b, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	if len(b) == 0 {
+		return fmt.Sprintf("status %d", res.StatusCode)
+	}
+	return string(b)
+}
+
+// allow checks whether a request can be made. Once the breaker has been open
+// for the cooldown a single request is let through, which closes the breaker
+// if it succeeds.
+func (br *breaker) allow() bool {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if br.failures < br.threshold {
+		return true
+	}
+	if time.Now().Before(br.openUntil) {
+		return false
+	}
+	br.openUntil = time.Now().Add(br.cooldown)
+	return true
+}
+
+func (br *breaker) success() {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.failures = 0
+}
+
+func (br *breaker) failure() {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.failures++
+	if br.failures >= br.threshold {
+		br.openUntil = time.Now().Add(br.cooldown)
+	}
+}
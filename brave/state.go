@@ -0,0 +1,35 @@
+package brave
+
+import "context"
+
+type (
+	// State is the GStreamer state of an input, mixer or output.
+	State string
+	// All is everything that currently exists in Brave.
+	All struct {
+		Inputs   []Input   `json:"inputs"`
+		Mixers   []Mixer   `json:"mixers"`
+		Outputs  []Output  `json:"outputs"`
+		Overlays []Overlay `json:"overlays"`
+	}
+)
+
+// States an object moves through, NULL being stopped and PLAYING being fully
+// running.
+const (
+	StateNull    State = "NULL"
+	StateReady   State = "READY"
+	StatePaused  State = "PAUSED"
+	StatePlaying State = "PLAYING"
+)
+
+// GetAll gets the current state of every input, mixer, output and overlay in
+// one request, so they are consistent with each other.
+func (b *Braver) GetAll(ctx context.Context) (All, error) {
+	all := All{}
+	err := b.getJSON(ctx, "/api/all", &all)
+	if err != nil {
+		return All{}, err
+	}
+	return all, nil
+}
@@ -0,0 +1,223 @@
+package brave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type (
+	// Update is a change to an input, mixer or output pushed by Brave.
+	Update struct {
+		// Object is "input", "mixer" or "output".
+		Object string
+		ID     int
+		// Deleted when the object has been removed, its state is empty.
+		Deleted bool
+		// The state of the object, whichever matches Object.
+		Input  Input
+		Mixer  Mixer
+		Output Output
+	}
+	// message is sent by Brave over its websocket.
+	message struct {
+		MsgType string          `json:"msg_type"`
+		Data    json.RawMessage `json:"data"`
+	}
+	// messageBlock identifies the object a message is about.
+	messageBlock struct {
+		BlockType string `json:"block_type"`
+		ID        int    `json:"id"`
+	}
+)
+
+const (
+	// reconnectBackoff is the delay before reconnecting, doubling each failed
+	// attempt up to maxReconnectBackoff.
+	reconnectBackoff    = time.Second
+	maxReconnectBackoff = 30 * time.Second
+	// pongWait is how long the connection can be silent before it is assumed
+	// to be dead, it is pinged more often than that.
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait / 2
+)
+
+// Subscribe listens to Brave's websocket, publishing updates to inputs,
+// mixers and outputs until the context is done, when the channel is closed.
+//
+// The connection is remade whenever it drops. Updates could have been missed
+// whilst disconnected, so the state of every object is published each time it
+// connects.
+func (b *Braver) Subscribe(ctx context.Context) <-chan Update {
+	updates := make(chan Update, 64)
+	go func() {
+		defer close(updates)
+		backoff := reconnectBackoff
+		for {
+			connected, err := b.subscribe(ctx, updates)
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				backoff = reconnectBackoff
+			}
+			log.Printf("brave websocket disconnected, reconnecting in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff/2)))):
+			}
+			backoff *= 2
+			if backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+		}
+	}()
+	return updates
+}
+
+// subscribe publishes updates from a single websocket connection until it
+// fails, returning whether it connected.
+func (b *Braver) subscribe(ctx context.Context, updates chan<- Update) (bool, error) {
+	u := *b.baseURL
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u = *u.ResolveReference(&url.URL{Path: "/socket"})
+
+	dialCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	conn, res, err := websocket.DefaultDialer.DialContext(dialCtx, u.String(), nil)
+	cancel()
+	if err != nil {
+		return false, fmt.Errorf("failed to dial: %w", err)
+	}
+	defer conn.Close()
+	if res.Body != nil {
+		res.Body.Close()
+	}
+
+	// Closing the connection unblocks reading when the context is done, and
+	// pings catch a connection that has silently gone.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		t := time.NewTicker(pingPeriod)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-t.C:
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(b.timeout))
+				if err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	all, err := b.GetAll(ctx)
+	if err != nil {
+		return true, fmt.Errorf("failed to get current state: %w", err)
+	}
+	for _, i := range all.Inputs {
+		if !publish(ctx, updates, Update{Object: "input", ID: i.ID, Input: i}) {
+			return true, ctx.Err()
+		}
+	}
+	for _, m := range all.Mixers {
+		if !publish(ctx, updates, Update{Object: "mixer", ID: m.ID, Mixer: m}) {
+			return true, ctx.Err()
+		}
+	}
+	for _, o := range all.Outputs {
+		if !publish(ctx, updates, Update{Object: "output", ID: o.ID, Output: o}) {
+			return true, ctx.Err()
+		}
+	}
+
+	for {
+		msg := message{}
+		err = conn.ReadJSON(&msg)
+		if err != nil {
+			return true, fmt.Errorf("failed to read message: %w", err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		update, ok, err := decodeMessage(msg)
+		if err != nil {
+			log.Printf("failed to decode brave %s message: %v", msg.MsgType, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !publish(ctx, updates, update) {
+			return true, ctx.Err()
+		}
+	}
+}
+
+// decodeMessage decodes an update from a message, returning false when the
+// message isn't an update to an input, mixer or output.
+func decodeMessage(msg message) (Update, bool, error) {
+	if msg.MsgType != "update" && msg.MsgType != "delete" {
+		return Update{}, false, nil
+	}
+	block := messageBlock{}
+	err := json.Unmarshal(msg.Data, &block)
+	if err != nil {
+		return Update{}, false, err
+	}
+
+	u := Update{
+		Object:  block.BlockType,
+		ID:      block.ID,
+		Deleted: msg.MsgType == "delete",
+	}
+	var state interface{}
+	switch block.BlockType {
+	case "input":
+		state = &u.Input
+	case "mixer":
+		state = &u.Mixer
+	case "output":
+		state = &u.Output
+	default:
+		// i.e. overlays, which only ShowTime! changes.
+		return Update{}, false, nil
+	}
+	if !u.Deleted {
+		err = json.Unmarshal(msg.Data, state)
+		if err != nil {
+			return Update{}, false, err
+		}
+	}
+	return u, true, nil
+}
+
+func publish(ctx context.Context, updates chan<- Update, u Update) bool {
+	select {
+	case updates <- u:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
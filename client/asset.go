@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// ChannelAssets are the images a channel's continuity card is made from, the
+// URIs are empty when they haven't been uploaded.
+type ChannelAssets struct {
+	BackgroundURI string `json:"backgroundURI"`
+	LogoURI       string `json:"logoURI"`
+	SlateURI      string `json:"slateURI"`
+}
+
+// Channel asset types.
+const (
+	AssetBackground = "background"
+	AssetLogo       = "logo"
+	AssetSlate      = "slate"
+)
+
+// GetChannelAssets retrieves which assets a channel has.
+func (c *Client) GetChannelAssets(ctx context.Context, channelID int) (ChannelAssets, error) {
+	a := ChannelAssets{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/assets", channelID), nil, &a)
+	return a, err
+}
+
+// SetChannelAsset uploads or replaces a channel's asset from a JPEG, PNG or GIF
+// image. It fails with ErrAssetNotImage when it isn't one.
+func (c *Client) SetChannelAsset(ctx context.Context, channelID int, asset, fileName string, r io.Reader) error {
+	// Assets are small enough to buffer.
+	b := bytes.Buffer{}
+	mw := multipart.NewWriter(&b)
+	fw, err := mw.CreateFormFile("file", fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+	_, err = io.Copy(fw, r)
+	if err != nil {
+		return fmt.Errorf("failed to read asset: %w", err)
+	}
+	err = mw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+
+	res, err := c.sendBody(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/assets/%s", channelID, asset), mw.FormDataContentType(), &b)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(res, nil)
+}
+
+// DeleteChannelAsset removes a channel's asset.
+func (c *Client) DeleteChannelAsset(ctx context.Context, channelID int, asset string) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/channels/%d/assets/%s", channelID, asset), nil, nil)
+}
@@ -0,0 +1,504 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// Channel is an MCR channel.
+	Channel struct {
+		ID                int    `json:"channelID"`
+		Status            string `json:"status"`
+		URLName           string `json:"urlName"`
+		OutputURL         string `json:"outputURL"`
+		Width             int    `json:"width"`
+		Height            int    `json:"height"`
+		Title             string `json:"title"`
+		MixerID           int    `json:"mixerID"`
+		ProgramInputID    int    `json:"programInputID"`
+		ContinuityInputID int    `json:"continuityInputID"`
+		// FillerOrder is ordered or shuffled.
+		FillerOrder   string `json:"fillerOrder"`
+		FillerInputID int    `json:"fillerInputID"`
+		// Transition is cut, crossfade or dip.
+		Transition         string        `json:"transition"`
+		TransitionDuration time.Duration `json:"transitionDuration"`
+		// AudioOnly channels show their continuity card over whatever is
+		// on.
+		AudioOnly   bool       `json:"audioOnly"`
+		FillerAudio AudioLevel `json:"fillerAudio"`
+		// Failover is continuity or slate.
+		Failover string `json:"failover"`
+		// FailoverPlayoutID is the live playout the channel has failed over
+		// from, 0 when it hasn't.
+		FailoverPlayoutID int `json:"failoverPlayoutID"`
+		SlateInputID      int `json:"slateInputID"`
+	}
+	// Playout is an individual media stream scheduled on a channel.
+	Playout struct {
+		ID             int       `json:"playoutID"`
+		ChannelID      int       `json:"channelID"`
+		BraveInputID   int       `json:"braveInputID"`
+		SrcType        string    `json:"srcType"`
+		SrcURI         string    `json:"srcURI"`
+		Status         string    `json:"status"`
+		Title          string    `json:"title"`
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+		Visibility     string    `json:"visibility"`
+		EndMode        string    `json:"endMode"`
+		MediaID        int       `json:"mediaID"`
+		// StartTransition and EndTransition are empty when the playout
+		// uses its channel's transition.
+		StartTransition         string        `json:"startTransition"`
+		StartTransitionDuration time.Duration `json:"startTransitionDuration"`
+		EndTransition           string        `json:"endTransition"`
+		EndTransitionDuration   time.Duration `json:"endTransitionDuration"`
+		Audio                   AudioLevel    `json:"audio"`
+	}
+	// EditPlayout creates a playout of a URI or media on a channel.
+	EditPlayout struct {
+		// MediaID is used instead of SrcURI when it isn't 0, ScheduledEnd
+		// defaults to the end of the media.
+		MediaID        int       `json:"mediaID,omitempty"`
+		SrcURI         string    `json:"srcURI,omitempty"`
+		Title          string    `json:"title"`
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+		Visibility     string    `json:"visibility"`
+		// EndMode is hard or soft, defaults to hard.
+		EndMode string `json:"endMode,omitempty"`
+		// AllowConflict schedules the playout even when it overlaps another
+		// on the channel.
+		AllowConflict bool `json:"allowConflict,omitempty"`
+		// StartTransition and EndTransition are cut, crossfade or dip, they
+		// default to the channel's transition.
+		StartTransition         string        `json:"startTransition,omitempty"`
+		StartTransitionDuration time.Duration `json:"startTransitionDuration,omitempty"`
+		EndTransition           string        `json:"endTransition,omitempty"`
+		EndTransitionDuration   time.Duration `json:"endTransitionDuration,omitempty"`
+		// Audio defaults to a volume of 1.
+		Audio *AudioLevel `json:"audio,omitempty"`
+	}
+	// EditTransition sets how a channel changes source.
+	EditTransition struct {
+		// Transition is cut, crossfade or dip.
+		Transition string `json:"transition"`
+		// Duration is how long a crossfade or dip takes, defaults to 1s.
+		Duration time.Duration `json:"duration,omitempty"`
+	}
+	// EditFailover sets what a channel fails over to.
+	EditFailover struct {
+		// Failover is continuity or slate.
+		Failover string `json:"failover"`
+	}
+	// AudioLevel is how loud a source is played.
+	AudioLevel struct {
+		// Volume is from 0 to 1.
+		Volume float64 `json:"volume"`
+		// Gain is in dB from -20 to 0, applied on top of the volume.
+		Gain  float64 `json:"gain"`
+		Muted bool    `json:"muted"`
+	}
+	// ChannelOutput is a destination a channel's program is sent to whilst
+	// it is on-air.
+	ChannelOutput struct {
+		ID        int `json:"channelOutputID"`
+		ChannelID int `json:"channelID"`
+		// Type is local, rtmp or tcp.
+		Type          string `json:"type"`
+		Name          string `json:"name"`
+		Port          int    `json:"port,omitempty"`
+		BraveOutputID int    `json:"braveOutputID"`
+		// Destination is where the output goes, with any stream key masked.
+		Destination string `json:"destination"`
+		// Status is Brave's state of the output, off-air, missing or unknown.
+		Status string `json:"status"`
+	}
+	// EditChannelOutput adds an RTMP or TCP output to a channel.
+	EditChannelOutput struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		// URL is required for an RTMP output.
+		URL string `json:"url,omitempty"`
+		// Port is required for a TCP output.
+		Port int `json:"port,omitempty"`
+	}
+	// ChannelGraphics are what is drawn over a channel's program whilst it
+	// is on-air, besides its lower thirds. Positions are top-left,
+	// top-right, bottom-left or bottom-right.
+	ChannelGraphics struct {
+		BugVisible    bool   `json:"bugVisible"`
+		BugPosition   string `json:"bugPosition"`
+		ClockVisible  bool   `json:"clockVisible"`
+		ClockPosition string `json:"clockPosition"`
+		// NextUp shows a strap NextUpLead before each playout starts, with
+		// {title} in NextUpText replaced with the playout's title.
+		NextUp     bool          `json:"nextUp"`
+		NextUpLead time.Duration `json:"nextUpLead"`
+		NextUpText string        `json:"nextUpText"`
+	}
+	// LowerThird is a strap of text shown along the bottom of a channel,
+	// during a playout or on demand.
+	LowerThird struct {
+		ID           int           `json:"lowerThirdID"`
+		ChannelID    int           `json:"channelID"`
+		PlayoutID    int           `json:"playoutID,omitempty"`
+		PlayoutTitle string        `json:"playoutTitle,omitempty"`
+		Text         string        `json:"text"`
+		Offset       time.Duration `json:"offset"`
+		Duration     time.Duration `json:"duration"`
+		ShownAt      *time.Time    `json:"shownAt,omitempty"`
+		Hidden       bool          `json:"hidden"`
+		Showing      bool          `json:"showing"`
+	}
+	// EditLowerThird adds a lower third to a channel.
+	EditLowerThird struct {
+		// PlayoutID is the playout to show it during, 0 to only show it on
+		// demand.
+		PlayoutID int `json:"playoutID,omitempty"`
+		// Text is required without a playout.
+		Text string `json:"text,omitempty"`
+		// Offset is how long after the playout's scheduled start it is
+		// shown.
+		Offset time.Duration `json:"offset,omitempty"`
+		// Duration is how long it is shown for, 0 until it is hidden or the
+		// playout ends.
+		Duration time.Duration `json:"duration,omitempty"`
+	}
+	// CardTemplate is how a channel's continuity card is laid out. When it
+	// is set, empty fields other than the text use the default template's.
+	CardTemplate struct {
+		FontPath         string `json:"fontPath,omitempty"`
+		HeadingFontSize  int    `json:"headingFontSize,omitempty"`
+		BodyFontSize     int    `json:"bodyFontSize,omitempty"`
+		TextColour       string `json:"textColour,omitempty"`
+		BackgroundColour string `json:"backgroundColour,omitempty"`
+		// LogoPosition is none, top-left, top-right, bottom-left or
+		// bottom-right.
+		LogoPosition string `json:"logoPosition,omitempty"`
+		Heading      string `json:"heading"`
+		Upcoming     string `json:"upcoming"`
+		Empty        string `json:"empty"`
+		Message      string `json:"message"`
+		Today        string `json:"today"`
+		MaxEntries   int    `json:"maxEntries,omitempty"`
+		// TitleOverflow is truncate or wrap.
+		TitleOverflow string `json:"titleOverflow,omitempty"`
+		DateFormat    string `json:"dateFormat,omitempty"`
+		TimeFormat    string `json:"timeFormat,omitempty"`
+		Timezone      string `json:"timezone,omitempty"`
+	}
+	// Filler is media played on a channel between its scheduled playouts.
+	Filler struct {
+		ID        int           `json:"fillerID"`
+		ChannelID int           `json:"channelID"`
+		Position  int           `json:"position"`
+		MediaID   int           `json:"mediaID"`
+		Title     string        `json:"title"`
+		Duration  time.Duration `json:"duration"`
+	}
+	// EditFillers replaces a channel's filler playlist.
+	EditFillers struct {
+		// Order is ordered or shuffled, defaults to ordered.
+		Order    string `json:"order,omitempty"`
+		MediaIDs []int  `json:"mediaIDs"`
+	}
+	// ChannelEventType is the type of a channel event.
+	ChannelEventType string
+	// ChannelEvent is something that happened to a channel.
+	ChannelEvent struct {
+		ID   int              `json:"channelEventID"`
+		Type ChannelEventType `json:"type"`
+		Time time.Time        `json:"time"`
+		// Data is the payload, its fields depend on the type.
+		Data json.RawMessage `json:"data"`
+	}
+	// ScheduleConflict is a playout scheduled at the same time as another on
+	// the same channel.
+	ScheduleConflict struct {
+		PlayoutID      int       `json:"playoutID"`
+		Title          string    `json:"title"`
+		OtherPlayoutID int       `json:"otherPlayoutID"`
+		OtherTitle     string    `json:"otherTitle"`
+		OverlapStart   time.Time `json:"overlapStart"`
+		OverlapEnd     time.Time `json:"overlapEnd"`
+		// Resolutions are schedules the playout could be moved to so it
+		// doesn't overlap the other.
+		Resolutions []ScheduleResolution `json:"resolutions"`
+	}
+	// ScheduleResolution is a suggested schedule for a conflicting playout.
+	ScheduleResolution struct {
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+	}
+	// AsRunEntry is a record of something that happened on a channel, when
+	// it actually happened.
+	AsRunEntry struct {
+		ID        int       `json:"asRunID"`
+		ChannelID int       `json:"channelID"`
+		Time      time.Time `json:"time"`
+		// Type is cut, on-air, off-air, playout-start or playout-end.
+		Type string `json:"type"`
+		// Program is what a cut went to, playout, continuity or filler.
+		Program      string     `json:"program,omitempty"`
+		InputID      int        `json:"inputID,omitempty"`
+		PlayoutID    int        `json:"playoutID,omitempty"`
+		PlayoutTitle string     `json:"playoutTitle,omitempty"`
+		Scheduled    *time.Time `json:"scheduled,omitempty"`
+		// Deviation is how late the entry was compared to Scheduled.
+		Deviation time.Duration `json:"deviation"`
+		Source    string        `json:"source"`
+		Detail    string        `json:"detail,omitempty"`
+	}
+	// ReconcileReport is what reconciling Brave with the channels changed.
+	ReconcileReport struct {
+		Changes []ReconcileChange `json:"changes"`
+		Errors  []string          `json:"errors"`
+	}
+	// ReconcileChange is a change made to a Brave object or a channel's
+	// stored ID, action is created, deleted, cut, played or cleared.
+	ReconcileChange struct {
+		Action    string `json:"action"`
+		Object    string `json:"object"`
+		BraveID   int    `json:"braveID"`
+		ChannelID int    `json:"channelID,omitempty"`
+		PlayoutID int    `json:"playoutID,omitempty"`
+		Reason    string `json:"reason"`
+	}
+)
+
+// Channel event types.
+const (
+	ChannelEventCardRefreshed ChannelEventType = "card-refreshed"
+	ChannelEventCardFailed    ChannelEventType = "card-failed"
+	ChannelEventFailover      ChannelEventType = "failover"
+	ChannelEventFailback      ChannelEventType = "failback"
+)
+
+// ListChannels lists all MCR channels.
+func (c *Client) ListChannels(ctx context.Context) ([]Channel, error) {
+	ch := []Channel{}
+	err := c.do(ctx, http.MethodGet, "/channels", nil, &ch)
+	return ch, err
+}
+
+// GetChannel retrieves a single MCR channel.
+func (c *Client) GetChannel(ctx context.Context, channelID int) (Channel, error) {
+	ch := Channel{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d", channelID), nil, &ch)
+	return ch, err
+}
+
+// ListChannelPlayouts lists the playouts of a channel in schedule order.
+func (c *Client) ListChannelPlayouts(ctx context.Context, channelID int) ([]Playout, error) {
+	po := []Playout{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/playouts", channelID), nil, &po)
+	return po, err
+}
+
+// NewChannelPlayout schedules a playout on a channel, returning its ID.
+func (c *Client) NewChannelPlayout(ctx context.Context, channelID int, po EditPlayout) (int, error) {
+	playoutID := 0
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/playouts", channelID), po, &playoutID)
+	return playoutID, err
+}
+
+// DeleteChannelPlayout removes a playout that isn't live from a channel.
+func (c *Client) DeleteChannelPlayout(ctx context.Context, channelID, playoutID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/channels/%d/playouts/%d", channelID, playoutID), nil, nil)
+}
+
+// GetCardTemplate retrieves a channel's continuity card template.
+func (c *Client) GetCardTemplate(ctx context.Context, channelID int) (CardTemplate, error) {
+	t := CardTemplate{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/card-template", channelID), nil, &t)
+	return t, err
+}
+
+// SetCardTemplate replaces a channel's continuity card template.
+func (c *Client) SetCardTemplate(ctx context.Context, channelID int, t CardTemplate) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/card-template", channelID), t, nil)
+}
+
+// PreviewCardTemplate draws a channel's continuity card with a template,
+// returning a PNG image.
+func (c *Client) PreviewCardTemplate(ctx context.Context, channelID int, t CardTemplate) ([]byte, error) {
+	res, err := c.send(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/card-template/preview", channelID), t)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, newError(res)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return b, nil
+}
+
+// ListChannelOutputs lists a channel's outputs with their status.
+func (c *Client) ListChannelOutputs(ctx context.Context, channelID int) ([]ChannelOutput, error) {
+	o := []ChannelOutput{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/outputs", channelID), nil, &o)
+	return o, err
+}
+
+// NewChannelOutput adds an output to a channel, returning its ID.
+func (c *Client) NewChannelOutput(ctx context.Context, channelID int, o EditChannelOutput) (int, error) {
+	outputID := 0
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/outputs", channelID), o, &outputID)
+	return outputID, err
+}
+
+// DeleteChannelOutput removes an output from a channel.
+func (c *Client) DeleteChannelOutput(ctx context.Context, channelID, outputID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/channels/%d/outputs/%d", channelID, outputID), nil, nil)
+}
+
+// GetChannelGraphics retrieves a channel's graphics.
+func (c *Client) GetChannelGraphics(ctx context.Context, channelID int) (ChannelGraphics, error) {
+	g := ChannelGraphics{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/graphics", channelID), nil, &g)
+	return g, err
+}
+
+// SetChannelGraphics replaces a channel's graphics.
+func (c *Client) SetChannelGraphics(ctx context.Context, channelID int, g ChannelGraphics) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/graphics", channelID), g, nil)
+}
+
+// ListLowerThirds lists a channel's lower thirds.
+func (c *Client) ListLowerThirds(ctx context.Context, channelID int) ([]LowerThird, error) {
+	l := []LowerThird{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/lower-thirds", channelID), nil, &l)
+	return l, err
+}
+
+// NewLowerThird adds a lower third to a channel, returning its ID.
+func (c *Client) NewLowerThird(ctx context.Context, channelID int, l EditLowerThird) (int, error) {
+	lowerThirdID := 0
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/lower-thirds", channelID), l, &lowerThirdID)
+	return lowerThirdID, err
+}
+
+// DeleteLowerThird removes a lower third from a channel.
+func (c *Client) DeleteLowerThird(ctx context.Context, channelID, lowerThirdID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/channels/%d/lower-thirds/%d", channelID, lowerThirdID), nil, nil)
+}
+
+// ShowLowerThird shows a lower third on an on-air channel now.
+func (c *Client) ShowLowerThird(ctx context.Context, channelID, lowerThirdID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/lower-thirds/%d/show", channelID, lowerThirdID), nil, nil)
+}
+
+// HideLowerThird takes a lower third off a channel's strap.
+func (c *Client) HideLowerThird(ctx context.Context, channelID, lowerThirdID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/lower-thirds/%d/hide", channelID, lowerThirdID), nil, nil)
+}
+
+// ListChannelFillers lists a channel's filler playlist in order.
+func (c *Client) ListChannelFillers(ctx context.Context, channelID int) ([]Filler, error) {
+	f := []Filler{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/fillers", channelID), nil, &f)
+	return f, err
+}
+
+// SetChannelFillers replaces a channel's filler playlist.
+func (c *Client) SetChannelFillers(ctx context.Context, channelID int, f EditFillers) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/fillers", channelID), f, nil)
+}
+
+// SetPlayoutAudio sets a playout's audio level, straight away when it is
+// playing.
+func (c *Client) SetPlayoutAudio(ctx context.Context, channelID, playoutID int, a AudioLevel) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/playouts/%d/audio", channelID, playoutID), a, nil)
+}
+
+// SetFillerAudio sets the audio level of a channel's fillers.
+func (c *Client) SetFillerAudio(ctx context.Context, channelID int, a AudioLevel) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/fillers/audio", channelID), a, nil)
+}
+
+// SetChannelTransition sets how a channel changes source.
+func (c *Client) SetChannelTransition(ctx context.Context, channelID int, t EditTransition) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/transition", channelID), t, nil)
+}
+
+// SetChannelFailover sets what a channel fails over to when a live playout's
+// source fails.
+func (c *Client) SetChannelFailover(ctx context.Context, channelID int, f EditFailover) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/channels/%d/failover", channelID), f, nil)
+}
+
+// ListChannelEvents lists the events of a channel, oldest first.
+func (c *Client) ListChannelEvents(ctx context.Context, channelID int) ([]ChannelEvent, error) {
+	evts := []ChannelEvent{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/events", channelID), nil, &evts)
+	return evts, err
+}
+
+// ListChannelConflicts lists the overlapping playouts on a channel.
+func (c *Client) ListChannelConflicts(ctx context.Context, channelID int) ([]ScheduleConflict, error) {
+	conflicts := []ScheduleConflict{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/channels/%d/conflicts", channelID), nil, &conflicts)
+	return conflicts, err
+}
+
+// ListChannelAsRun lists a channel's as-run log between two times, oldest
+// first. A zero time leaves that end open.
+func (c *Client) ListChannelAsRun(ctx context.Context, channelID int, from, to time.Time) ([]AsRunEntry, error) {
+	entries := []AsRunEntry{}
+	err := c.do(ctx, http.MethodGet, asRunPath(channelID, "", from, to), nil, &entries)
+	return entries, err
+}
+
+// ExportChannelAsRun retrieves a channel's as-run log between two times as
+// CSV.
+func (c *Client) ExportChannelAsRun(ctx context.Context, channelID int, from, to time.Time) ([]byte, error) {
+	return c.getDocument(ctx, asRunPath(channelID, "/csv", from, to))
+}
+
+func asRunPath(channelID int, suffix string, from, to time.Time) string {
+	q := url.Values{}
+	if !from.IsZero() {
+		q.Set("from", from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		q.Set("to", to.Format(time.RFC3339))
+	}
+	p := fmt.Sprintf("/channels/%d/as-run%s", channelID, suffix)
+	if len(q) != 0 {
+		p += "?" + q.Encode()
+	}
+	return p
+}
+
+// SetChannelOnAir starts the channel's broadcast.
+func (c *Client) SetChannelOnAir(ctx context.Context, channelID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/on-air", channelID), nil, nil)
+}
+
+// SetChannelOffAir ends the channel's broadcast.
+func (c *Client) SetChannelOffAir(ctx context.Context, channelID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/channels/%d/off-air", channelID), nil, nil)
+}
+
+// ReconcileChannels rebuilds what channels need in Brave and deletes what
+// they don't, returning what changed.
+func (c *Client) ReconcileChannels(ctx context.Context) (ReconcileReport, error) {
+	r := ReconcileReport{}
+	err := c.do(ctx, http.MethodPost, "/channels/reconcile", nil, &r)
+	return r, err
+}
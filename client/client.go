@@ -0,0 +1,130 @@
+// Package client is a ShowTime! API client.
+//
+// The API is described in handlers/openapi.yaml, which is also served by
+// ShowTime! at /api/openapi.yaml.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// Client is a ShowTime! API client.
+	Client struct {
+		baseURL *url.URL
+		token   string
+		c       *http.Client
+	}
+	// Config to configure the client.
+	Config struct {
+		// Endpoint is the address ShowTime! is served on, without the /api path.
+		Endpoint string
+		// Token is a web-auth generated access token, it isn't required when
+		// ShowTime! is running in debug mode.
+		Token string
+		// HTTPClient is used to make requests, http.DefaultClient when empty.
+		HTTPClient *http.Client
+	}
+)
+
+var (
+	// ErrInvalidBaseURL when an invalid endpoint is given.
+	ErrInvalidBaseURL = errors.New("failed to parse base URL")
+	// ErrRequestFailed to make a HTTP request.
+	ErrRequestFailed = errors.New("failed to make request")
+)
+
+// New creates a new ShowTime! client.
+func New(c Config) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBaseURL, err)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL: u,
+		token:   c.Token,
+		c:       httpClient,
+	}, nil
+}
+
+// do makes a request to the API, encoding the body and decoding the response
+// into out when they are not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	res, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(res, out)
+}
+
+// decodeResponse decodes a response into out when it is not nil, closing
+// the response body.
+func decodeResponse(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return newError(res)
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	err := json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send makes a request to the API, encoding the body when it is not nil. The
+// caller must close the response body.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	if body == nil {
+		return c.sendBody(ctx, method, path, "", nil)
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json: %w", err)
+	}
+	return c.sendBody(ctx, method, path, "application/json", bytes.NewReader(b))
+}
+
+// sendBody makes a request to the API with a body of the content type. The
+// caller must close the response body.
+func (c *Client) sendBody(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	u := *c.baseURL
+	// Paths can have a query.
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path, u.RawQuery = path[:i], path[i+1:]
+	}
+	u.Path += "/api" + path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	return res, nil
+}
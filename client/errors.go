@@ -0,0 +1,166 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Errors returned by the API, use errors.Is to check for them. They mirror the
+// errors of the livestream, mcr, youtube and brave packages.
+var (
+	ErrTitleEmpty            = errors.New("title is empty")
+	ErrTitleTooLong          = errors.New("title is too long, max 100 characters")
+	ErrDescriptionTooLong    = errors.New("description is too long, max 5000 characters")
+	ErrVisibilityInvalid     = errors.New("invalid visibility option")
+	ErrStartAfterEnd         = errors.New("scheduled start cannot be after the scheduled end")
+	ErrStartInPast           = errors.New("start time cannot be in the past")
+	ErrStreamKeyNotFound     = errors.New("stream key not found")
+	ErrUnkownIntegrationType = errors.New("unknown integration type")
+	ErrLinkNotFound          = errors.New("link not found")
+	ErrLinkParamsMissing     = errors.New("link parameters missing for integration type")
+	ErrLinkNotUpdatable      = errors.New("link cannot be updated for integration type")
+	ErrChannelIDInvalid      = errors.New("channel id is invalid")
+	ErrSrcURIEmpty           = errors.New("source uri is empty")
+	ErrVisibilityEmpty       = errors.New("visibility is empty")
+	ErrURLNameEmpty          = errors.New("url name is empty")
+	ErrChannelOnAir          = errors.New("channel is on-air")
+	ErrChannelOffAir         = errors.New("channel is off-air")
+	ErrChannelArchived       = errors.New("channel is archived")
+	ErrChannelNotArchived    = errors.New("channel is not archived")
+	ErrPlayoutNotFound       = errors.New("playout not found")
+	ErrSourceOnAir           = errors.New("cannot remove source that is on air")
+	ErrSrcTypeInvalid        = errors.New("source type is invalid")
+	ErrEndModeInvalid        = errors.New("end mode is invalid")
+	ErrMediaNotFound         = errors.New("media not found")
+	ErrMediaNotPlayable      = errors.New("media is not playable")
+	ErrMediaInUse            = errors.New("media is in use")
+	ErrFillerOrderInvalid    = errors.New("filler order is invalid")
+	ErrCardTemplateInvalid   = errors.New("card template is invalid")
+	ErrAssetTypeInvalid      = errors.New("asset type is invalid")
+	ErrAssetNotImage         = errors.New("asset is not an image")
+	ErrAssetTooLarge         = errors.New("asset is too large")
+	ErrScheduleInvalid       = errors.New("scheduled start must be before scheduled end")
+	ErrPlayoutConflict       = errors.New("playout overlaps another on the channel")
+	ErrOutputNotFound        = errors.New("output not found")
+	ErrOutputTypeInvalid     = errors.New("output type is invalid")
+	ErrOutputNameEmpty       = errors.New("output name is empty")
+	ErrOutputURLInvalid      = errors.New("output url is invalid")
+	ErrOutputPortInvalid     = errors.New("output port is invalid")
+	ErrOutputPortInUse       = errors.New("output port is in use")
+	ErrOutputLocal           = errors.New("local output cannot be removed")
+	ErrGraphicsInvalid       = errors.New("graphics are invalid")
+	ErrLowerThirdNotFound    = errors.New("lower third not found")
+	ErrLowerThirdTextEmpty   = errors.New("lower third text is empty")
+	ErrTransitionInvalid     = errors.New("transition is invalid")
+	ErrAudioLevelInvalid     = errors.New("audio level is invalid")
+	ErrFailoverInvalid       = errors.New("failover is invalid")
+	ErrNoYouTuberFound       = errors.New("youtuber not found")
+	ErrBroadcastNotFound     = errors.New("broadcast not found")
+	ErrBraveUnavailable      = errors.New("brave is unavailable")
+
+	// ErrNotFound when the resource doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized when the token is missing or invalid.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// errorCodes maps the codes in API error responses to errors, it must stay in
+// sync with handlers/errors.go.
+var errorCodes = map[string]error{
+	"title-empty":              ErrTitleEmpty,
+	"title-too-long":           ErrTitleTooLong,
+	"description-too-long":     ErrDescriptionTooLong,
+	"visibility-invalid":       ErrVisibilityInvalid,
+	"start-after-end":          ErrStartAfterEnd,
+	"start-in-past":            ErrStartInPast,
+	"stream-key-not-found":     ErrStreamKeyNotFound,
+	"unknown-integration-type": ErrUnkownIntegrationType,
+	"link-not-found":           ErrLinkNotFound,
+	"link-params-missing":      ErrLinkParamsMissing,
+	"link-not-updatable":       ErrLinkNotUpdatable,
+	"channel-id-invalid":       ErrChannelIDInvalid,
+	"src-uri-empty":            ErrSrcURIEmpty,
+	"visibility-empty":         ErrVisibilityEmpty,
+	"url-name-empty":           ErrURLNameEmpty,
+	"channel-on-air":           ErrChannelOnAir,
+	"channel-off-air":          ErrChannelOffAir,
+	"channel-archived":         ErrChannelArchived,
+	"channel-not-archived":     ErrChannelNotArchived,
+	"playout-not-found":        ErrPlayoutNotFound,
+	"source-on-air":            ErrSourceOnAir,
+	"src-type-invalid":         ErrSrcTypeInvalid,
+	"end-mode-invalid":         ErrEndModeInvalid,
+	"media-not-found":          ErrMediaNotFound,
+	"media-not-playable":       ErrMediaNotPlayable,
+	"media-in-use":             ErrMediaInUse,
+	"filler-order-invalid":     ErrFillerOrderInvalid,
+	"card-template-invalid":    ErrCardTemplateInvalid,
+	"asset-type-invalid":       ErrAssetTypeInvalid,
+	"asset-not-image":          ErrAssetNotImage,
+	"asset-too-large":          ErrAssetTooLarge,
+	"schedule-invalid":         ErrScheduleInvalid,
+	"playout-conflict":         ErrPlayoutConflict,
+	"output-not-found":         ErrOutputNotFound,
+	"output-type-invalid":      ErrOutputTypeInvalid,
+	"output-name-empty":        ErrOutputNameEmpty,
+	"output-url-invalid":       ErrOutputURLInvalid,
+	"output-port-invalid":      ErrOutputPortInvalid,
+	"output-port-in-use":       ErrOutputPortInUse,
+	"output-local":             ErrOutputLocal,
+	"graphics-invalid":         ErrGraphicsInvalid,
+	"lower-third-not-found":    ErrLowerThirdNotFound,
+	"lower-third-text-empty":   ErrLowerThirdTextEmpty,
+	"transition-invalid":       ErrTransitionInvalid,
+	"audio-level-invalid":      ErrAudioLevelInvalid,
+	"failover-invalid":         ErrFailoverInvalid,
+	"youtuber-not-found":       ErrNoYouTuberFound,
+	"broadcast-not-found":      ErrBroadcastNotFound,
+	"brave-unavailable":        ErrBraveUnavailable,
+}
+
+// Error is an unsuccessful response from the API.
+type Error struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"error"`
+	Detail     string `json:"detail"`
+}
+
+func newError(res *http.Response) error {
+	apiErr := &Error{StatusCode: res.StatusCode}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read error response: %w", err)
+	}
+	if json.Unmarshal(b, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = string(b)
+	}
+	return apiErr
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("showtime: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the known error the response represents, or nil.
+func (e *Error) Unwrap() error {
+	return errorCodes[e.Code]
+}
+
+// Is lets ErrNotFound and ErrUnauthorized match on the status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// GuideChannel is the public part of a channel.
+	GuideChannel struct {
+		ID      int    `json:"channelID"`
+		Status  string `json:"status"`
+		URLName string `json:"urlName"`
+		Title   string `json:"title"`
+	}
+	// Programme is a public playout in a channel's guide.
+	Programme struct {
+		PlayoutID   int       `json:"playoutID"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		Start       time.Time `json:"start"`
+		End         time.Time `json:"end"`
+		Status      string    `json:"status"`
+		// Live is whether the programme is airing on the channel now.
+		Live bool `json:"live"`
+	}
+	// NowNext is what's airing on a channel and what's on after it, either
+	// can be nil.
+	NowNext struct {
+		Channel GuideChannel `json:"channel"`
+		Now     *Programme   `json:"now"`
+		Next    *Programme   `json:"next"`
+	}
+)
+
+// GetNowNext retrieves what's airing on a channel and what's on next.
+func (c *Client) GetNowNext(ctx context.Context, channelID int) (NowNext, error) {
+	nn := NowNext{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/guide/%d/now-next", channelID), nil, &nn)
+	return nn, err
+}
+
+// GetGuideXMLTV retrieves a channel's programme guide as an XMLTV document.
+func (c *Client) GetGuideXMLTV(ctx context.Context, channelID int) ([]byte, error) {
+	return c.getDocument(ctx, fmt.Sprintf("/guide/%d/xmltv", channelID))
+}
+
+// GetGuideICal retrieves a channel's programme guide as an iCalendar.
+func (c *Client) GetGuideICal(ctx context.Context, channelID int) ([]byte, error) {
+	return c.getDocument(ctx, fmt.Sprintf("/guide/%d/ical", channelID))
+}
+
+// getDocument retrieves a response that isn't JSON.
+func (c *Client) getDocument(ctx context.Context, path string) ([]byte, error) {
+	res, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, newError(res)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// IntegrationType is a type of intergration with a platform.
+	IntegrationType string
+	// Link is a relationship between a livestream and an integration.
+	Link struct {
+		ID              int             `json:"linkID"`
+		LivestreamID    int             `json:"livestreamID"`
+		IntegrationType IntegrationType `json:"integrationType"`
+		IntegrationID   string          `json:"integrationID"`
+	}
+	// LinkDetails is a link including information retrieved from its
+	// integration.
+	LinkDetails struct {
+		Link
+		MCR        *MCRLinkDetails        `json:"mcr,omitempty"`
+		YouTube    *YouTubeLinkDetails    `json:"youtube,omitempty"`
+		RTMPOutput *RTMPOutputLinkDetails `json:"rtmp,omitempty"`
+	}
+	// MCRLinkDetails describes the playout and the channel it is on.
+	MCRLinkDetails struct {
+		PlayoutID     int    `json:"playoutID"`
+		PlayoutStatus string `json:"playoutStatus"`
+		ChannelID     int    `json:"channelID"`
+		ChannelTitle  string `json:"channelTitle"`
+		ChannelStatus string `json:"channelStatus"`
+		OutputURL     string `json:"outputURL"`
+	}
+	// YouTubeLinkDetails describes the linked YouTube broadcast.
+	YouTubeLinkDetails struct {
+		BroadcastID    string `json:"broadcastID"`
+		AccountID      int    `json:"accountID"`
+		WatchURL       string `json:"watchURL"`
+		Title          string `json:"title"`
+		ScheduledStart string `json:"scheduledStart"`
+		ScheduledEnd   string `json:"scheduledEnd"`
+		Visibility     string `json:"visibility"`
+	}
+	// RTMPOutputLinkDetails describes the RTMP destination, the stream key is
+	// masked.
+	RTMPOutputLinkDetails struct {
+		RTMPOutputID int    `json:"rtmpOutputID"`
+		OutputURL    string `json:"outputURL"`
+	}
+
+	// EditLink creates or updates a link to an integration. Only the
+	// parameters matching the integration type are used.
+	EditLink struct {
+		IntegrationType IntegrationType     `json:"integrationType"`
+		MCR             *EditMCRLink        `json:"mcr,omitempty"`
+		YTNew           *EditYTNewLink      `json:"ytNew,omitempty"`
+		YTExisting      *EditYTExistingLink `json:"ytExisting,omitempty"`
+		RTMPOutput      *EditRTMPOutputLink `json:"rtmp,omitempty"`
+	}
+	// EditMCRLink are parameters for a link to an MCR channel.
+	EditMCRLink struct {
+		ChannelID int `json:"channelID"`
+	}
+	// EditYTNewLink are parameters for a link to a new YouTube broadcast.
+	EditYTNewLink struct {
+		AccountID int `json:"accountID"`
+	}
+	// EditYTExistingLink are parameters for a link to an existing YouTube
+	// broadcast.
+	EditYTExistingLink struct {
+		AccountID   int    `json:"accountID"`
+		BroadcastID string `json:"broadcastID"`
+	}
+	// EditRTMPOutputLink are parameters for a link to a custom RTMP endpoint.
+	EditRTMPOutputLink struct {
+		OutputURL string `json:"outputURL"`
+	}
+)
+
+// Integration types.
+const (
+	LinkMCR        IntegrationType = "mcr"
+	LinkYTNew      IntegrationType = "yt-new"
+	LinkYTExisting IntegrationType = "yt-existing"
+	LinkRTMPOutput IntegrationType = "rtmp"
+)
+
+// ListLinks lists the links of a livestream.
+func (c *Client) ListLinks(ctx context.Context, livestreamID int) ([]LinkDetails, error) {
+	links := []LinkDetails{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/livestreams/%d/links", livestreamID), nil, &links)
+	return links, err
+}
+
+// NewLink links a livestream to an integration.
+func (c *Client) NewLink(ctx context.Context, livestreamID int, p EditLink) (LinkDetails, error) {
+	link := LinkDetails{}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/livestreams/%d/links", livestreamID), p, &link)
+	return link, err
+}
+
+// GetLink retrieves a single link.
+func (c *Client) GetLink(ctx context.Context, livestreamID, linkID int) (LinkDetails, error) {
+	link := LinkDetails{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/livestreams/%d/links/%d", livestreamID, linkID), nil, &link)
+	return link, err
+}
+
+// UpdateLink updates the integration-specific parameters of a link.
+func (c *Client) UpdateLink(ctx context.Context, livestreamID, linkID int, p EditLink) (LinkDetails, error) {
+	link := LinkDetails{}
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/livestreams/%d/links/%d", livestreamID, linkID), p, &link)
+	return link, err
+}
+
+// DeleteLink unlinks an integration from a livestream.
+func (c *Client) DeleteLink(ctx context.Context, livestreamID, linkID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/livestreams/%d/links/%d", livestreamID, linkID), nil, nil)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type (
+	// Livestream is the metadata of a stream and the links to external
+	// platforms.
+	Livestream struct {
+		ID             int       `json:"livestreamID"`
+		StreamKey      string    `json:"streamKey"`
+		Status         string    `json:"status"`
+		Title          string    `json:"title"`
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+		Visibility     string    `json:"visbility"`
+	}
+	// EditLivestream are parameters required to create or update a livestream.
+	EditLivestream struct {
+		Title          string    `json:"title"`
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+		Visibility     string    `json:"visbility"`
+		Thumbnail      string    `json:"thumbnail,omitempty"`
+		// AllowConflict moves the livestream's playouts even when they
+		// overlap others on their channels.
+		AllowConflict bool `json:"allowConflict,omitempty"`
+	}
+	// EventType is the type of a livestream event.
+	EventType string
+	// Event is something that happened to a livestream.
+	Event struct {
+		ID   int       `json:"livestreamEventID"`
+		Type EventType `json:"type"`
+		Time time.Time `json:"time"`
+		// Data is the payload, its fields depend on the type.
+		Data json.RawMessage `json:"data"`
+	}
+)
+
+// Livestream event types.
+const (
+	EventStarted           EventType = "started"
+	EventEnded             EventType = "ended"
+	EventLinked            EventType = "linked"
+	EventUnlinked          EventType = "unlinked"
+	EventStreamReceived    EventType = "stream-received"
+	EventStreamLost        EventType = "stream-lost"
+	EventForwardingStopped EventType = "forwarding-stopped"
+	EventError             EventType = "error"
+)
+
+// NewLivestream creates a livestream, returning its ID.
+func (c *Client) NewLivestream(ctx context.Context, strm EditLivestream) (int, error) {
+	strmID := 0
+	err := c.do(ctx, http.MethodPost, "/livestreams", strm, &strmID)
+	return strmID, err
+}
+
+// ListLivestreams lists all livestreams, only the summary fields are filled.
+func (c *Client) ListLivestreams(ctx context.Context) ([]Livestream, error) {
+	strms := []Livestream{}
+	err := c.do(ctx, http.MethodGet, "/livestreams", nil, &strms)
+	return strms, err
+}
+
+// GetLivestream retrieves a single livestream.
+func (c *Client) GetLivestream(ctx context.Context, livestreamID int) (Livestream, error) {
+	strm := Livestream{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/livestreams/%d", livestreamID), nil, &strm)
+	return strm, err
+}
+
+// UpdateLivestream updates a livestream and its linked integrations.
+func (c *Client) UpdateLivestream(ctx context.Context, livestreamID int, strm EditLivestream) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/livestreams/%d", livestreamID), strm, nil)
+}
+
+// StartLivestream triggers a start on all the livestream's linked
+// integrations.
+func (c *Client) StartLivestream(ctx context.Context, livestreamID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/livestreams/%d/start", livestreamID), nil, nil)
+}
+
+// EndLivestream triggers an end on all the livestream's linked integrations.
+func (c *Client) EndLivestream(ctx context.Context, livestreamID int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/livestreams/%d/end", livestreamID), nil, nil)
+}
+
+// ListLivestreamEvents lists the events of a livestream, oldest first.
+func (c *Client) ListLivestreamEvents(ctx context.Context, livestreamID int) ([]Event, error) {
+	evts := []Event{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/livestreams/%d/events", livestreamID), nil, &evts)
+	return evts, err
+}
+
+// RefreshStreamKey rotates the stream key of a livestream, returning the new
+// key.
+func (c *Client) RefreshStreamKey(ctx context.Context, livestreamID int) (string, error) {
+	res := struct {
+		StreamKey string `json:"streamKey"`
+	}{}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/livestreams/%d/refresh-key", livestreamID), nil, &res)
+	return res.StreamKey, err
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// Media is a pre-recorded file in the media library.
+type Media struct {
+	ID           int           `json:"mediaID"`
+	Title        string        `json:"title"`
+	FileName     string        `json:"fileName"`
+	SizeBytes    int64         `json:"sizeBytes"`
+	Duration     time.Duration `json:"duration"`
+	VideoCodec   string        `json:"videoCodec"`
+	AudioCodec   string        `json:"audioCodec"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	URI          string        `json:"uri"`
+	ThumbnailURI string        `json:"thumbnailURI"`
+}
+
+// ListMedia lists the media library, newest first.
+func (c *Client) ListMedia(ctx context.Context) ([]Media, error) {
+	m := []Media{}
+	err := c.do(ctx, http.MethodGet, "/media", nil, &m)
+	return m, err
+}
+
+// GetMedia retrieves media from the library.
+func (c *Client) GetMedia(ctx context.Context, mediaID int) (Media, error) {
+	m := Media{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/media/%d", mediaID), nil, &m)
+	return m, err
+}
+
+// UploadMedia adds a file to the media library, the title defaults to the file
+// name when it is empty. It fails with ErrMediaNotPlayable when the file has no
+// audio or video.
+func (c *Client) UploadMedia(ctx context.Context, title, fileName string, r io.Reader) (Media, error) {
+	// The file is streamed rather than buffered, they can be large.
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := mw.WriteField("title", title)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		fw, err := mw.CreateFormFile("file", fileName)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		_, err = io.Copy(fw, r)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	res, err := c.sendBody(ctx, http.MethodPost, "/media", mw.FormDataContentType(), pr)
+	// Unblocks the writer if the request failed before reading it all.
+	pr.Close()
+	if err != nil {
+		return Media{}, err
+	}
+	m := Media{}
+	err = decodeResponse(res, &m)
+	return m, err
+}
+
+// DeleteMedia removes media from the library, it fails with ErrMediaInUse
+// whilst a playout that hasn't ended or a channel's fillers use it.
+func (c *Client) DeleteMedia(ctx context.Context, mediaID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/media/%d", mediaID), nil, nil)
+}
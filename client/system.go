@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// BuildSetting is a key-value pair describing a Go build.
+	BuildSetting struct {
+		Key   string `json:"Key"`
+		Value string `json:"Value"`
+	}
+	// HealthStatus is the status of a health check.
+	HealthStatus string
+	// HealthCheck is the result of checking one of ShowTime!'s dependencies.
+	HealthCheck struct {
+		Name     string       `json:"name"`
+		Status   HealthStatus `json:"status"`
+		Detail   string       `json:"detail"`
+		Error    string       `json:"error"`
+		Duration float64      `json:"durationSeconds"`
+	}
+	// HealthReport is the result of checking ShowTime!'s dependencies.
+	HealthReport struct {
+		Status HealthStatus  `json:"status"`
+		Checks []HealthCheck `json:"checks"`
+	}
+)
+
+// Health check statuses.
+const (
+	HealthOK   HealthStatus = "ok"
+	HealthFail HealthStatus = "fail"
+)
+
+// ErrNotReady when at least one of ShowTime!'s dependencies failed its check.
+var ErrNotReady = errors.New("showtime is not ready")
+
+// Health checks ShowTime! is up.
+//
+// Deprecated: use Live or Ready.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// Live checks ShowTime! is serving requests.
+func (c *Client) Live(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health/live", nil, nil)
+}
+
+// Ready checks ShowTime!'s dependencies. When any of them failed the report is
+// returned along with ErrNotReady.
+func (c *Client) Ready(ctx context.Context) (HealthReport, error) {
+	res, err := c.send(ctx, http.MethodGet, "/health/ready", nil)
+	if err != nil {
+		return HealthReport{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusServiceUnavailable {
+		return HealthReport{}, newError(res)
+	}
+	r := HealthReport{}
+	err = json.NewDecoder(res.Body).Decode(&r)
+	if err != nil {
+		return HealthReport{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if res.StatusCode == http.StatusServiceUnavailable {
+		return r, ErrNotReady
+	}
+	return r, nil
+}
+
+// Version returns the build settings of the ShowTime! binary, empty when they
+// are unavailable.
+func (c *Client) Version(ctx context.Context) ([]BuildSetting, error) {
+	settings := []BuildSetting{}
+	err := c.do(ctx, http.MethodGet, "/version", nil, &settings)
+	return settings, err
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Broadcast is a YouTube livestream.
+type Broadcast struct {
+	ID             string `json:"id"`
+	AccountID      int    `json:"accountID"`
+	IngestAddress  string `json:"ingestAddress"`
+	IngestKey      string `json:"ingestKey"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	ScheduledStart string `json:"scheduledStart"`
+	ScheduledEnd   string `json:"scheduledEnd"`
+	Visibility     string `json:"visibility"`
+}
+
+// ListYouTubeBroadcasts lists the upcoming broadcasts of all integrated
+// YouTube accounts.
+func (c *Client) ListYouTubeBroadcasts(ctx context.Context) ([]Broadcast, error) {
+	b := []Broadcast{}
+	err := c.do(ctx, http.MethodGet, "/youtube/broadcasts", nil, &b)
+	return b, err
+}
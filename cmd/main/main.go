@@ -6,16 +6,23 @@ import (
 	"io/fs"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"github.com/ystv/showtime/auth"
 	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/brave/bravesim"
 	"github.com/ystv/showtime/db"
 	"github.com/ystv/showtime/handlers"
+	"github.com/ystv/showtime/health"
 	"github.com/ystv/showtime/livestream"
 	"github.com/ystv/showtime/mcr"
+	"github.com/ystv/showtime/metrics"
 	"github.com/ystv/showtime/youtube"
 )
 
@@ -27,9 +34,14 @@ type Config struct {
 	livestream livestream.Config
 	mcr        *mcr.Config
 	brave      brave.Config
+	health     health.Config
 	handlers   *handlers.Config
 	auth       *auth.Config
 	db         *db.Config
+	// shutdownTimeout is how long to wait for in-flight work when stopping.
+	shutdownTimeout time.Duration
+	// reconcileInterval is how often Brave is reconciled with the database.
+	reconcileInterval time.Duration
 }
 
 func main() {
@@ -49,16 +61,37 @@ func main() {
 
 	autoInit, _ := strconv.ParseBool(os.Getenv("ST_DB_AUTO_INIT"))
 
+	shutdownTimeout, err := time.ParseDuration(os.Getenv("ST_SHUTDOWN_TIMEOUT"))
+	if err != nil {
+		shutdownTimeout = 30 * time.Second
+	}
+	// Zero uses the client's default.
+	braveTimeout, _ := time.ParseDuration(os.Getenv("ST_BRAVE_TIMEOUT"))
+	// Zero uses the MCR's defaults.
+	mcrPreRoll, _ := time.ParseDuration(os.Getenv("ST_MCR_PREROLL"))
+	mcrSoftEndLimit, _ := time.ParseDuration(os.Getenv("ST_MCR_SOFT_END_LIMIT"))
+	mcrFillerGap, _ := time.ParseDuration(os.Getenv("ST_MCR_FILLER_GAP"))
+	mcrFailoverTimeout, _ := time.ParseDuration(os.Getenv("ST_MCR_FAILOVER_TIMEOUT"))
+	reconcileInterval, err := time.ParseDuration(os.Getenv("ST_RECONCILE_INTERVAL"))
+	if err != nil {
+		reconcileInterval = time.Minute
+	}
+
 	conf := Config{
 		livestream: livestream.Config{
 			IngestAddress: os.Getenv("ST_INGEST_ADDR"),
 		},
 		mcr: &mcr.Config{
-			BaseServeURL:  os.Getenv("ST_BASE_SERVE_ADDR"),
-			OutputAddress: os.Getenv("ST_OUTPUT_ADDR"),
+			BaseServeURL:    os.Getenv("ST_BASE_SERVE_ADDR"),
+			OutputAddress:   os.Getenv("ST_OUTPUT_ADDR"),
+			PreRoll:         mcrPreRoll,
+			SoftEndLimit:    mcrSoftEndLimit,
+			FillerGap:       mcrFillerGap,
+			FailoverTimeout: mcrFailoverTimeout,
 		},
 		brave: brave.Config{
 			Endpoint: os.Getenv("ST_BRAVE_ADDR"),
+			Timeout:  braveTimeout,
 		},
 		handlers: &handlers.Config{
 			Debug:           debug,
@@ -79,6 +112,8 @@ func main() {
 			Password: os.Getenv("ST_DB_PASSWORD"),
 			AutoInit: autoInit,
 		},
+		shutdownTimeout:   shutdownTimeout,
+		reconcileInterval: reconcileInterval,
 	}
 
 	db, err := db.New(conf.db)
@@ -100,6 +135,13 @@ func main() {
 	}
 	auth := auth.NewAuther(db, ytConfig)
 
+	if conf.brave.Endpoint == "sim" {
+		conf.brave.Endpoint, err = bravesim.New().Start("127.0.0.1:0")
+		if err != nil {
+			log.Fatalf("failed to start brave simulator: %+v", err)
+		}
+		log.Printf("using simulated brave at %s", conf.brave.Endpoint)
+	}
 	brave, err := brave.New(conf.brave)
 	if err != nil {
 		log.Fatalf("failed to create brave client: %+v", err)
@@ -113,6 +155,10 @@ func main() {
 		log.Fatalf("failed to create youtube client: %+v", err)
 	}
 	ls := livestream.New(conf.livestream, db, mcr, yt)
+	health := health.New(conf.health, db, brave, yt)
+
+	metrics.RegisterStatusGauge("livestreams", "Livestreams by status.", ls.CountByStatus)
+	metrics.RegisterStatusGauge("channels", "MCR channels by status, on-air channels are broadcasting.", mcr.CountChannelsByStatus)
 
 	templatesFS, err := fs.Sub(content, "public/templates")
 	if err != nil {
@@ -123,7 +169,77 @@ func main() {
 		log.Fatalf("failed to create templater: %v", err)
 	}
 
-	h := handlers.New(conf.handlers, auth, ls, mcr, yt, templates)
+	h := handlers.New(conf.handlers, auth, health, ls, mcr, yt, templates)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workers := sync.WaitGroup{}
+	workers.Add(4)
+	// Brave may have restarted whilst ShowTime! wasn't running, so reconcile
+	// straight away and keep checking in case it restarts again.
+	go func() {
+		defer workers.Done()
+		mcr.RunReconciler(workersCtx, conf.reconcileInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		mcr.WatchBrave(brave.Subscribe(workersCtx))
+	}()
+	go func() {
+		defer workers.Done()
+		mcr.RunScheduler(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		mcr.RunCardRenderer(workersCtx)
+	}()
+
+	go func() {
+		err := h.Start()
+		if err != nil {
+			log.Fatalf("failed to start server: %+v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("shutting down, waiting up to %s", conf.shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), conf.shutdownTimeout)
+	defer cancel()
+
+	err = h.Shutdown(ctx)
+	if err != nil {
+		log.Printf("failed to drain http server: %+v", err)
+	}
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		log.Printf("timed out stopping background workers")
+	}
+	err = ls.Shutdown(ctx)
+	if err != nil {
+		log.Printf("failed to stop forwarders: %+v", err)
+	}
 
-	h.Start()
+	closed := make(chan error, 1)
+	go func() {
+		closed <- db.Close()
+	}()
+	select {
+	case err = <-closed:
+		if err != nil {
+			log.Printf("failed to close database: %+v", err)
+		}
+	case <-ctx.Done():
+		log.Printf("timed out closing database")
+	}
+	log.Println("shut down")
 }
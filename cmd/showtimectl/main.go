@@ -0,0 +1,250 @@
+// showtimectl controls ShowTime! from a terminal.
+//
+// It talks to the ShowTime! API by default, in admin mode it connects
+// directly to the database and integrations using the same environment as
+// ShowTime! itself.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+const usage = `Usage: showtimectl [flags] <command> [args]
+
+Commands:
+  livestreams list                    list livestreams
+  livestreams start <livestreamID>    start a livestream
+  livestreams end <livestreamID>      end a livestream
+  livestreams events <livestreamID>   list a livestream's events, -follow to tail
+  livestreams rotate-key <livestreamID>
+                                      rotate a livestream's stream key
+  channels list                       list channels
+  channels on-air <channelID>         put a channel on-air
+  channels off-air <channelID>        take a channel off-air
+  preflight <livestreamID>            check a livestream is ready to go live
+
+Flags:
+`
+
+// ctl is the state shared by commands.
+type ctl struct {
+	st       showtime
+	out      *printer
+	follow   bool
+	interval time.Duration
+}
+
+func main() {
+	// Load environment
+	_ = godotenv.Load(".env")           // Load .env file for production
+	_ = godotenv.Overload(".env.local") // Load .env.local for developing
+
+	endpoint := flag.String("endpoint", envOr("ST_CTL_ENDPOINT", "http://localhost:8080"), "ShowTime! address, env ST_CTL_ENDPOINT")
+	token := flag.String("token", os.Getenv("ST_CTL_TOKEN"), "web-auth access token, env ST_CTL_TOKEN")
+	admin := flag.Bool("admin", false, "connect directly to the database and integrations instead of the API")
+	format := flag.String("o", "table", "output format, table or json")
+	follow := flag.Bool("follow", false, "keep polling for new events")
+	interval := flag.Duration("interval", 2*time.Second, "poll interval when following events")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	out, err := newPrinter(os.Stdout, *format)
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var st showtime
+	if *admin {
+		// The API records the token's user instead.
+		ctx = mcr.WithAsRunSource(ctx, "showtimectl")
+		st, err = newAdminShowTime(ctx)
+	} else {
+		st, err = newAPIShowTime(*endpoint, *token)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	c := &ctl{
+		st:       st,
+		out:      out,
+		follow:   *follow,
+		interval: *interval,
+	}
+	err = c.run(ctx, flag.Args())
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func (c *ctl) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "livestreams", "livestream", "ls":
+		return c.runLivestreams(ctx, args[1:])
+	case "channels", "channel", "ch":
+		return c.runChannels(ctx, args[1:])
+	case "preflight":
+		id, err := idArg(args[1:], "livestreamID")
+		if err != nil {
+			return err
+		}
+		return c.preflight(ctx, id)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func (c *ctl) runLivestreams(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return c.listLivestreams(ctx)
+	}
+	id, err := idArg(args[1:], "livestreamID")
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "start":
+		err = c.st.StartLivestream(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to start livestream: %w", err)
+		}
+		return c.out.message("livestream %d started", id)
+	case "end":
+		err = c.st.EndLivestream(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to end livestream: %w", err)
+		}
+		return c.out.message("livestream %d ended", id)
+	case "events":
+		return c.livestreamEvents(ctx, id)
+	case "rotate-key":
+		key, err := c.st.RefreshStreamKey(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to rotate stream key: %w", err)
+		}
+		return c.out.streamKey(id, key)
+	}
+	return fmt.Errorf("unknown livestreams command %q", args[0])
+}
+
+func (c *ctl) runChannels(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return c.listChannels(ctx)
+	}
+	id, err := idArg(args[1:], "channelID")
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "on-air":
+		err = c.st.SetChannelOnAir(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to set channel on-air: %w", err)
+		}
+		return c.out.message("channel %d on-air", id)
+	case "off-air":
+		err = c.st.SetChannelOffAir(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to set channel off-air: %w", err)
+		}
+		return c.out.message("channel %d off-air", id)
+	}
+	return fmt.Errorf("unknown channels command %q", args[0])
+}
+
+func (c *ctl) listLivestreams(ctx context.Context) error {
+	strms, err := c.st.ListLivestreams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list livestreams: %w", err)
+	}
+	return c.out.livestreams(strms)
+}
+
+func (c *ctl) listChannels(ctx context.Context) error {
+	chs, err := c.st.ListChannels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+	return c.out.channels(chs)
+}
+
+// livestreamEvents prints a livestream's events, when following it polls for
+// new events until interrupted.
+func (c *ctl) livestreamEvents(ctx context.Context, livestreamID int) error {
+	lastID := 0
+	for {
+		evts, err := c.st.ListLivestreamEvents(ctx, livestreamID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+
+		newEvts := evts[:0]
+		for _, evt := range evts {
+			if evt.ID > lastID {
+				newEvts = append(newEvts, evt)
+				lastID = evt.ID
+			}
+		}
+		if !c.follow {
+			return c.out.events(newEvts)
+		}
+		err = c.out.eventLines(newEvts)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+func idArg(args []string, name string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a single %s argument", name)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return id, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "showtimectl: %v\n", err)
+	os.Exit(1)
+}
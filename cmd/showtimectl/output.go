@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ystv/showtime/client"
+)
+
+// ErrUnknownFormat when the output format isn't table or json.
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// printer writes command results either as aligned tables for people or JSON
+// for scripts.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table":
+		return &printer{w: w}, nil
+	case "json":
+		return &printer{w: w, json: true}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+func (p *printer) encode(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table writes the header and rows with aligned columns.
+func (p *printer) table(header []interface{}, rows [][]interface{}) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]interface{}{header}, rows...) {
+		for i, col := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, col)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func (p *printer) message(format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	if p.json {
+		return p.encode(map[string]string{"message": msg})
+	}
+	_, err := fmt.Fprintln(p.w, msg)
+	return err
+}
+
+func (p *printer) livestreams(strms []client.Livestream) error {
+	if p.json {
+		return p.encode(strms)
+	}
+	rows := make([][]interface{}, 0, len(strms))
+	for _, strm := range strms {
+		rows = append(rows, []interface{}{strm.ID, strm.Status, strm.Title})
+	}
+	return p.table([]interface{}{"ID", "STATUS", "TITLE"}, rows)
+}
+
+func (p *printer) channels(chs []client.Channel) error {
+	if p.json {
+		return p.encode(chs)
+	}
+	rows := make([][]interface{}, 0, len(chs))
+	for _, ch := range chs {
+		rows = append(rows, []interface{}{ch.ID, ch.Status, ch.Title, ch.URLName, fmt.Sprintf("%dx%d", ch.Width, ch.Height), ch.OutputURL})
+	}
+	return p.table([]interface{}{"ID", "STATUS", "TITLE", "URL NAME", "RESOLUTION", "OUTPUT"}, rows)
+}
+
+func (p *printer) events(evts []client.Event) error {
+	if p.json {
+		return p.encode(evts)
+	}
+	rows := make([][]interface{}, 0, len(evts))
+	for _, evt := range evts {
+		rows = append(rows, eventRow(evt))
+	}
+	return p.table([]interface{}{"ID", "TIME", "TYPE", "DATA"}, rows)
+}
+
+// eventLines writes each event on its own line, as the header can't be kept
+// aligned when following. JSON output is newline-delimited.
+func (p *printer) eventLines(evts []client.Event) error {
+	for _, evt := range evts {
+		var err error
+		if p.json {
+			err = json.NewEncoder(p.w).Encode(evt)
+		} else {
+			_, err = fmt.Fprintf(p.w, "%d  %s  %s  %s\n", eventRow(evt)...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eventRow(evt client.Event) []interface{} {
+	data := string(evt.Data)
+	if data == "null" {
+		data = ""
+	}
+	return []interface{}{evt.ID, evt.Time.Local().Format(time.RFC3339), evt.Type, data}
+}
+
+func (p *printer) streamKey(livestreamID int, key string) error {
+	if p.json {
+		return p.encode(map[string]interface{}{
+			"livestreamID": livestreamID,
+			"streamKey":    key,
+		})
+	}
+	_, err := fmt.Fprintf(p.w, "livestream %d stream key: %s\n", livestreamID, key)
+	return err
+}
+
+func (p *printer) checks(checks []check) error {
+	if p.json {
+		return p.encode(checks)
+	}
+	rows := make([][]interface{}, 0, len(checks))
+	for _, c := range checks {
+		rows = append(rows, []interface{}{c.Result, c.Name, c.Detail})
+	}
+	return p.table([]interface{}{"RESULT", "CHECK", "DETAIL"}, rows)
+}
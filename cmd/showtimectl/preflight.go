@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ystv/showtime/client"
+)
+
+type (
+	// checkResult is the outcome of a preflight check.
+	checkResult string
+	// check is a preflight check of a livestream.
+	check struct {
+		Name   string      `json:"name"`
+		Result checkResult `json:"result"`
+		Detail string      `json:"detail"`
+	}
+)
+
+// Preflight check results, a warning won't stop a livestream going live but
+// should be looked at.
+const (
+	checkOK   checkResult = "ok"
+	checkWarn checkResult = "warn"
+	checkFail checkResult = "fail"
+)
+
+// ErrPreflightFailed when at least one preflight check failed.
+var ErrPreflightFailed = errors.New("preflight checks failed")
+
+// preflightStartWindow is how far away the scheduled start can be before it is
+// flagged.
+const preflightStartWindow = time.Hour
+
+// preflight checks a livestream and its integrations are ready to go live.
+func (c *ctl) preflight(ctx context.Context, livestreamID int) error {
+	checks := c.preflightChecks(ctx, livestreamID)
+	err := c.out.checks(checks)
+	if err != nil {
+		return err
+	}
+	for _, chk := range checks {
+		if chk.Result == checkFail {
+			return ErrPreflightFailed
+		}
+	}
+	return nil
+}
+
+func (c *ctl) preflightChecks(ctx context.Context, livestreamID int) []check {
+	checks := []check{}
+	add := func(name string, result checkResult, format string, a ...interface{}) {
+		checks = append(checks, check{Name: name, Result: result, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	report, err := c.st.Ready(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotReady) {
+		add("showtime", checkFail, "%v", err)
+		return checks
+	}
+	add("showtime", checkOK, "reachable")
+
+	strm, err := c.st.GetLivestream(ctx, livestreamID)
+	if err != nil {
+		add("livestream", checkFail, "%v", err)
+		return checks
+	}
+	switch strm.Status {
+	case "stream-ended":
+		add("livestream", checkFail, "%q has already ended", strm.Title)
+	case "stream-started":
+		add("livestream", checkWarn, "%q has already started", strm.Title)
+	default:
+		add("livestream", checkOK, "%q is %s", strm.Title, strm.Status)
+	}
+
+	now := time.Now()
+	switch {
+	case strm.ScheduledEnd.Before(now):
+		add("schedule", checkFail, "scheduled end %s has passed", strm.ScheduledEnd.Local().Format(time.RFC1123))
+	case strm.ScheduledStart.Sub(now) > preflightStartWindow:
+		add("schedule", checkWarn, "scheduled start is in %s", strm.ScheduledStart.Sub(now).Round(time.Minute))
+	default:
+		add("schedule", checkOK, "scheduled %s to %s", strm.ScheduledStart.Local().Format(time.RFC1123), strm.ScheduledEnd.Local().Format(time.RFC1123))
+	}
+
+	links, err := c.st.ListLinks(ctx, livestreamID)
+	switch {
+	case err != nil:
+		add("links", checkFail, "failed to list links: %v", err)
+	case len(links) == 0:
+		add("links", checkFail, "no integrations are linked")
+	default:
+		add("links", checkOK, "%d integrations linked", len(links))
+	}
+	for _, link := range links {
+		name := fmt.Sprintf("link %d (%s)", link.ID, link.IntegrationType)
+		switch {
+		case link.MCR != nil && link.MCR.ChannelStatus != "on-air":
+			add(name, checkFail, "channel %q is %s", link.MCR.ChannelTitle, link.MCR.ChannelStatus)
+		case link.MCR != nil:
+			add(name, checkOK, "playout on %q", link.MCR.ChannelTitle)
+		case link.YouTube != nil:
+			add(name, checkOK, "broadcast %s", link.YouTube.WatchURL)
+		case link.RTMPOutput != nil:
+			add(name, checkOK, "output to %s", link.RTMPOutput.OutputURL)
+		default:
+			add(name, checkFail, "%s %q not found on the integration", link.IntegrationType, link.IntegrationID)
+		}
+	}
+
+	// Dependencies are checked after the links so a YouTube account is only
+	// required to be healthy when the livestream uses it.
+	usedAccounts := map[string]bool{}
+	for _, link := range links {
+		if link.YouTube != nil {
+			usedAccounts[fmt.Sprintf("youtube-account-%d", link.YouTube.AccountID)] = true
+		}
+	}
+	for _, dep := range report.Checks {
+		name := "dependency " + dep.Name
+		switch {
+		case dep.Status == client.HealthOK:
+			add(name, checkOK, "%s", dep.Detail)
+		case strings.HasPrefix(dep.Name, "youtube-account-") && !usedAccounts[dep.Name]:
+			add(name, checkWarn, "%s (not used by this livestream)", dep.Error)
+		default:
+			add(name, checkFail, "%s", dep.Error)
+		}
+	}
+
+	evts, err := c.st.ListLivestreamEvents(ctx, livestreamID)
+	if err != nil {
+		add("ingest", checkWarn, "failed to list events: %v", err)
+		return checks
+	}
+	receiving := false
+	for _, evt := range evts {
+		switch evt.Type {
+		case client.EventStreamReceived:
+			receiving = true
+		case client.EventStreamLost, client.EventEnded:
+			receiving = false
+		}
+	}
+	if receiving {
+		add("ingest", checkOK, "receiving stream")
+	} else {
+		add("ingest", checkWarn, "no stream is being received")
+	}
+
+	return checks
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ystv/showtime/auth"
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/client"
+	"github.com/ystv/showtime/db"
+	"github.com/ystv/showtime/health"
+	"github.com/ystv/showtime/livestream"
+	"github.com/ystv/showtime/mcr"
+	"github.com/ystv/showtime/youtube"
+)
+
+// showtime is the set of operations commands use, either through the API or
+// directly in admin mode.
+type showtime interface {
+	Ready(ctx context.Context) (client.HealthReport, error)
+	ListLivestreams(ctx context.Context) ([]client.Livestream, error)
+	GetLivestream(ctx context.Context, livestreamID int) (client.Livestream, error)
+	StartLivestream(ctx context.Context, livestreamID int) error
+	EndLivestream(ctx context.Context, livestreamID int) error
+	ListLivestreamEvents(ctx context.Context, livestreamID int) ([]client.Event, error)
+	RefreshStreamKey(ctx context.Context, livestreamID int) (string, error)
+	ListLinks(ctx context.Context, livestreamID int) ([]client.LinkDetails, error)
+	ListChannels(ctx context.Context) ([]client.Channel, error)
+	SetChannelOnAir(ctx context.Context, channelID int) error
+	SetChannelOffAir(ctx context.Context, channelID int) error
+}
+
+var (
+	_ showtime = (*client.Client)(nil)
+	_ showtime = (*adminShowTime)(nil)
+)
+
+func newAPIShowTime(endpoint, token string) (*client.Client, error) {
+	c, err := client.New(client.Config{
+		Endpoint: endpoint,
+		Token:    token,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return c, nil
+}
+
+// adminShowTime bypasses the API, using the packages ShowTime! is built from.
+type adminShowTime struct {
+	health *health.Checker
+	ls     *livestream.Livestreamer
+	mcr    *mcr.MCR
+}
+
+func newAdminShowTime(ctx context.Context) (*adminShowTime, error) {
+	autoInit, _ := strconv.ParseBool(os.Getenv("ST_DB_AUTO_INIT"))
+	db, err := db.New(&db.Config{
+		Host:     os.Getenv("ST_DB_HOST"),
+		Port:     os.Getenv("ST_DB_PORT"),
+		SSLMode:  os.Getenv("ST_DB_SSLMODE"),
+		DBName:   os.Getenv("ST_DB_DBNAME"),
+		Username: os.Getenv("ST_DB_USERNAME"),
+		Password: os.Getenv("ST_DB_PASSWORD"),
+		AutoInit: autoInit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	credentialsPath := os.Getenv("ST_CRED_PATH")
+	if credentialsPath == "" {
+		credentialsPath = "credentials"
+	}
+	b, err := os.ReadFile(credentialsPath + "/youtube.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client secret file: %w", err)
+	}
+	ytConfig, err := auth.NewYouTubeConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create youtube config: %w", err)
+	}
+	auth := auth.NewAuther(db, ytConfig)
+
+	brave, err := brave.New(brave.Config{
+		Endpoint: os.Getenv("ST_BRAVE_ADDR"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create brave client: %w", err)
+	}
+	mcr, err := mcr.NewMCR(&mcr.Config{
+		BaseServeURL:  os.Getenv("ST_BASE_SERVE_ADDR"),
+		OutputAddress: os.Getenv("ST_OUTPUT_ADDR"),
+	}, db, brave)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcr: %w", err)
+	}
+	yt, err := youtube.New(ctx, db, auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create youtube client: %w", err)
+	}
+	ls := livestream.New(livestream.Config{
+		IngestAddress: os.Getenv("ST_INGEST_ADDR"),
+	}, db, mcr, yt)
+
+	return &adminShowTime{
+		health: health.New(health.Config{}, db, brave, yt),
+		ls:     ls,
+		mcr:    mcr,
+	}, nil
+}
+
+func (a *adminShowTime) Ready(ctx context.Context) (client.HealthReport, error) {
+	out := client.HealthReport{}
+	err := convert(a.health.Ready(ctx), &out)
+	if err != nil {
+		return client.HealthReport{}, err
+	}
+	if out.Status != client.HealthOK {
+		return out, client.ErrNotReady
+	}
+	return out, nil
+}
+
+func (a *adminShowTime) ListLivestreams(ctx context.Context) ([]client.Livestream, error) {
+	strms, err := a.ls.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []client.Livestream{}
+	return out, convert(strms, &out)
+}
+
+func (a *adminShowTime) GetLivestream(ctx context.Context, livestreamID int) (client.Livestream, error) {
+	strm, err := a.ls.Get(ctx, livestreamID)
+	if err != nil {
+		return client.Livestream{}, err
+	}
+	out := client.Livestream{}
+	return out, convert(strm, &out)
+}
+
+func (a *adminShowTime) StartLivestream(ctx context.Context, livestreamID int) error {
+	strm, err := a.ls.Get(ctx, livestreamID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
+	}
+	return a.ls.Start(ctx, strm)
+}
+
+func (a *adminShowTime) EndLivestream(ctx context.Context, livestreamID int) error {
+	strm, err := a.ls.Get(ctx, livestreamID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
+	}
+	return a.ls.End(ctx, strm)
+}
+
+func (a *adminShowTime) ListLivestreamEvents(ctx context.Context, livestreamID int) ([]client.Event, error) {
+	evts, err := a.ls.ListEvents(ctx, livestreamID)
+	if err != nil {
+		return nil, err
+	}
+	out := []client.Event{}
+	return out, convert(evts, &out)
+}
+
+func (a *adminShowTime) RefreshStreamKey(ctx context.Context, livestreamID int) (string, error) {
+	return a.ls.RefreshStreamKey(ctx, livestreamID)
+}
+
+func (a *adminShowTime) ListLinks(ctx context.Context, livestreamID int) ([]client.LinkDetails, error) {
+	links, err := a.ls.ListLinkDetails(ctx, livestreamID)
+	if err != nil {
+		return nil, err
+	}
+	out := []client.LinkDetails{}
+	return out, convert(links, &out)
+}
+
+func (a *adminShowTime) ListChannels(ctx context.Context) ([]client.Channel, error) {
+	chs, err := a.mcr.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []client.Channel{}
+	return out, convert(chs, &out)
+}
+
+func (a *adminShowTime) SetChannelOnAir(ctx context.Context, channelID int) error {
+	ch, err := a.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = a.mcr.SetChannelOnAir(ctx, ch)
+	if err != nil {
+		return err
+	}
+	// Nothing runs the card renderer here, the card is the channel's program
+	// until a playout starts.
+	err = a.mcr.RefreshContinuityCard(ctx, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh continuity card: %w", err)
+	}
+	return nil
+}
+
+func (a *adminShowTime) SetChannelOffAir(ctx context.Context, channelID int) error {
+	ch, err := a.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	return a.mcr.SetChannelOffAir(ctx, ch)
+}
+
+// convert copies a value into its client equivalent, they share the same JSON
+// representation since it's what the API serves.
+func convert(in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+	err = json.Unmarshal(b, out)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	return nil
+}
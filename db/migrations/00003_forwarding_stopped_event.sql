@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE livestream_events DROP CONSTRAINT livestream_events_event_type_check;
+ALTER TABLE livestream_events ADD CONSTRAINT livestream_events_event_type_check CHECK (event_type IN (
+  'started',
+  'ended',
+  'linked',
+  'unlinked',
+  'stream-received',
+  'stream-lost',
+  'forwarding-stopped',
+  'error'
+));
+
+-- +goose Down
+DELETE FROM livestream_events WHERE event_type = 'forwarding-stopped';
+ALTER TABLE livestream_events DROP CONSTRAINT livestream_events_event_type_check;
+ALTER TABLE livestream_events ADD CONSTRAINT livestream_events_event_type_check CHECK (event_type IN (
+  'started',
+  'ended',
+  'linked',
+  'unlinked',
+  'stream-received',
+  'stream-lost',
+  'error'
+));
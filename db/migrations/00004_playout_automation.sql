@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE mcr.playouts ADD COLUMN end_mode text NOT NULL DEFAULT 'hard'
+  CHECK (end_mode IN ('hard', 'soft'));
+
+-- Playouts of livestreams are started and ended with their livestream, so
+-- they need telling apart from the ones the scheduler plays.
+UPDATE mcr.playouts SET source_type = 'livestream'
+WHERE playout_id::text IN (
+  SELECT integration_id FROM links WHERE integration_type = 'mcr'
+);
+
+-- +goose Down
+UPDATE mcr.playouts SET source_type = 'uri' WHERE source_type = 'livestream';
+
+ALTER TABLE mcr.playouts DROP COLUMN end_mode;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE mcr.media
+(
+    media_id    bigint GENERATED ALWAYS AS IDENTITY,
+    title       text        NOT NULL,
+    file_name   text        NOT NULL,
+    size_bytes  bigint      NOT NULL,
+    duration    bigint      NOT NULL,
+    video_codec text        NOT NULL,
+    audio_codec text        NOT NULL,
+    res_width   integer     NOT NULL,
+    res_height  integer     NOT NULL,
+    thumbnail   boolean     NOT NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (media_id)
+);
+
+-- 0 when the playout's source isn't from the media library.
+ALTER TABLE mcr.playouts ADD COLUMN media_id bigint NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE mcr.playouts DROP COLUMN media_id;
+
+DROP TABLE mcr.media;
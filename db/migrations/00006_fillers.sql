@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE mcr.fillers
+(
+    filler_id  bigint GENERATED ALWAYS AS IDENTITY,
+    channel_id bigint  NOT NULL,
+    media_id   bigint  NOT NULL,
+    position   integer NOT NULL,
+    PRIMARY KEY (filler_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE,
+    CONSTRAINT fk_media FOREIGN KEY (media_id) REFERENCES mcr.media (media_id)
+);
+
+ALTER TABLE mcr.channels
+    ADD COLUMN filler_order text NOT NULL DEFAULT 'ordered'
+        CHECK (filler_order IN ('ordered', 'shuffled')),
+    ADD COLUMN filler_input_id integer NOT NULL DEFAULT 0,
+    ADD COLUMN last_filler_id bigint NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE mcr.channels
+    DROP COLUMN last_filler_id,
+    DROP COLUMN filler_input_id,
+    DROP COLUMN filler_order;
+
+DROP TABLE mcr.fillers;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE mcr.card_templates
+(
+    channel_id        bigint  NOT NULL,
+    font_path         text    NOT NULL,
+    heading_font_size integer NOT NULL,
+    body_font_size    integer NOT NULL,
+    text_colour       text    NOT NULL,
+    background_colour text    NOT NULL,
+    logo_position     text    NOT NULL
+        CHECK (logo_position IN ('none', 'top-left', 'top-right', 'bottom-left', 'bottom-right')),
+    heading_text      text    NOT NULL,
+    upcoming_text     text    NOT NULL,
+    empty_text        text    NOT NULL,
+    message_text      text    NOT NULL,
+    today_text        text    NOT NULL,
+    max_entries       integer NOT NULL,
+    title_overflow    text    NOT NULL
+        CHECK (title_overflow IN ('truncate', 'wrap')),
+    date_format       text    NOT NULL,
+    time_format       text    NOT NULL,
+    timezone          text    NOT NULL,
+    PRIMARY KEY (channel_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE mcr.card_templates;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE mcr.channel_events (
+   channel_event_id BIGINT GENERATED ALWAYS AS IDENTITY,
+   channel_id BIGINT NOT NULL REFERENCES mcr.channels(channel_id) ON DELETE CASCADE,
+   event_type TEXT NOT NULL,
+   event_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   event_data JSONB DEFAULT '{}'::jsonb,
+   PRIMARY KEY (channel_event_id),
+   CHECK (event_type IN (
+     'card-refreshed',
+     'card-failed'
+   ))
+);
+
+-- +goose Down
+DROP TABLE mcr.channel_events;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE mcr.as_run
+(
+    as_run_id      bigint GENERATED ALWAYS AS IDENTITY,
+    channel_id     bigint      NOT NULL,
+    event_time     timestamptz NOT NULL DEFAULT NOW(),
+    event_type     text        NOT NULL
+        CHECK (event_type IN ('cut', 'on-air', 'off-air', 'playout-start', 'playout-end')),
+    program        text        NOT NULL DEFAULT ''
+        CHECK (program IN ('', 'playout', 'continuity', 'filler')),
+    input_id       integer     NOT NULL DEFAULT 0,
+    playout_id     bigint      NOT NULL DEFAULT 0,
+    playout_title  text        NOT NULL DEFAULT '',
+    scheduled_time timestamptz,
+    source         text        NOT NULL,
+    detail         text        NOT NULL DEFAULT '',
+    PRIMARY KEY (as_run_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE
+);
+
+CREATE INDEX as_run_channel_time ON mcr.as_run (channel_id, event_time);
+
+-- +goose Down
+DROP TABLE mcr.as_run;
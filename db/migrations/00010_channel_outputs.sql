@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE mcr.channel_outputs
+(
+    channel_output_id bigint GENERATED ALWAYS AS IDENTITY,
+    channel_id        bigint  NOT NULL,
+    output_type       text    NOT NULL
+        CHECK (output_type IN ('local', 'rtmp', 'tcp')),
+    name              text    NOT NULL,
+    url               text    NOT NULL DEFAULT '',
+    port              integer NOT NULL DEFAULT 0,
+    brave_output_id   integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (channel_output_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX channel_outputs_local ON mcr.channel_outputs (channel_id)
+    WHERE output_type = 'local';
+
+INSERT INTO mcr.channel_outputs (channel_id, output_type, name, brave_output_id)
+SELECT channel_id, 'local', 'Local', program_output_id
+FROM mcr.channels;
+
+ALTER TABLE mcr.channels
+    DROP COLUMN program_output_id;
+
+-- +goose Down
+ALTER TABLE mcr.channels
+    ADD COLUMN program_output_id integer NOT NULL DEFAULT 0;
+
+UPDATE mcr.channels c
+SET program_output_id = o.brave_output_id
+FROM mcr.channel_outputs o
+WHERE o.channel_id = c.channel_id
+  AND o.output_type = 'local';
+
+DROP TABLE mcr.channel_outputs;
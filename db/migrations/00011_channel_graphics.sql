@@ -0,0 +1,43 @@
+-- +goose Up
+CREATE TABLE mcr.channel_graphics
+(
+    channel_id       bigint  NOT NULL,
+    bug_visible      boolean NOT NULL DEFAULT false,
+    bug_position     text    NOT NULL DEFAULT 'top-right'
+        CHECK (bug_position IN ('top-left', 'top-right', 'bottom-left', 'bottom-right')),
+    clock_visible    boolean NOT NULL DEFAULT false,
+    clock_position   text    NOT NULL DEFAULT 'top-left'
+        CHECK (clock_position IN ('top-left', 'top-right', 'bottom-left', 'bottom-right')),
+    next_up          boolean NOT NULL DEFAULT false,
+    next_up_lead     bigint  NOT NULL DEFAULT 60000000000,
+    next_up_text     text    NOT NULL DEFAULT 'Coming up next: {title}',
+    bug_overlay_id   integer NOT NULL DEFAULT 0,
+    clock_overlay_id integer NOT NULL DEFAULT 0,
+    strap_overlay_id integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (channel_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE
+);
+
+INSERT INTO mcr.channel_graphics (channel_id)
+SELECT channel_id
+FROM mcr.channels;
+
+CREATE TABLE mcr.lower_thirds
+(
+    lower_third_id bigint GENERATED ALWAYS AS IDENTITY,
+    channel_id     bigint  NOT NULL,
+    playout_id     bigint,
+    text           text    NOT NULL DEFAULT '',
+    start_offset   bigint  NOT NULL DEFAULT 0,
+    duration       bigint  NOT NULL DEFAULT 0,
+    shown_at       timestamptz,
+    hidden         boolean NOT NULL DEFAULT false,
+    PRIMARY KEY (lower_third_id),
+    CONSTRAINT fk_channel FOREIGN KEY (channel_id) REFERENCES mcr.channels (channel_id) ON DELETE CASCADE,
+    CONSTRAINT fk_playout FOREIGN KEY (playout_id) REFERENCES mcr.playouts (playout_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE mcr.lower_thirds;
+
+DROP TABLE mcr.channel_graphics;
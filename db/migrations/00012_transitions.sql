@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE mcr.channels
+    ADD COLUMN transition          text   NOT NULL DEFAULT 'cut'
+        CHECK (transition IN ('cut', 'crossfade', 'dip')),
+    ADD COLUMN transition_duration bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE mcr.playouts
+    ADD COLUMN start_transition          text   NOT NULL DEFAULT ''
+        CHECK (start_transition IN ('', 'cut', 'crossfade', 'dip')),
+    ADD COLUMN start_transition_duration bigint NOT NULL DEFAULT 0,
+    ADD COLUMN end_transition            text   NOT NULL DEFAULT ''
+        CHECK (end_transition IN ('', 'cut', 'crossfade', 'dip')),
+    ADD COLUMN end_transition_duration   bigint NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE mcr.playouts
+    DROP COLUMN start_transition,
+    DROP COLUMN start_transition_duration,
+    DROP COLUMN end_transition,
+    DROP COLUMN end_transition_duration;
+
+ALTER TABLE mcr.channels
+    DROP COLUMN transition,
+    DROP COLUMN transition_duration;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE mcr.channels
+    ADD COLUMN audio_only    boolean          NOT NULL DEFAULT false,
+    ADD COLUMN filler_volume double precision NOT NULL DEFAULT 1
+        CHECK (filler_volume BETWEEN 0 AND 1),
+    ADD COLUMN filler_gain   double precision NOT NULL DEFAULT 0
+        CHECK (filler_gain BETWEEN -20 AND 0),
+    ADD COLUMN filler_muted  boolean          NOT NULL DEFAULT false;
+
+ALTER TABLE mcr.playouts
+    ADD COLUMN volume double precision NOT NULL DEFAULT 1
+        CHECK (volume BETWEEN 0 AND 1),
+    ADD COLUMN gain   double precision NOT NULL DEFAULT 0
+        CHECK (gain BETWEEN -20 AND 0),
+    ADD COLUMN muted  boolean          NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE mcr.playouts
+    DROP COLUMN volume,
+    DROP COLUMN gain,
+    DROP COLUMN muted;
+
+ALTER TABLE mcr.channels
+    DROP COLUMN audio_only,
+    DROP COLUMN filler_volume,
+    DROP COLUMN filler_gain,
+    DROP COLUMN filler_muted;
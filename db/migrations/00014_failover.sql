@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE mcr.channels
+    ADD COLUMN failover            text    NOT NULL DEFAULT 'continuity'
+        CHECK (failover IN ('continuity', 'slate')),
+    ADD COLUMN failover_playout_id bigint  NOT NULL DEFAULT 0,
+    ADD COLUMN slate_input_id      integer NOT NULL DEFAULT 0;
+
+ALTER TABLE mcr.channel_events DROP CONSTRAINT channel_events_event_type_check;
+ALTER TABLE mcr.channel_events ADD CONSTRAINT channel_events_event_type_check CHECK (event_type IN (
+  'card-refreshed',
+  'card-failed',
+  'failover',
+  'failback'
+));
+
+-- +goose Down
+DELETE FROM mcr.channel_events WHERE event_type IN ('failover', 'failback');
+ALTER TABLE mcr.channel_events DROP CONSTRAINT channel_events_event_type_check;
+ALTER TABLE mcr.channel_events ADD CONSTRAINT channel_events_event_type_check CHECK (event_type IN (
+  'card-refreshed',
+  'card-failed'
+));
+
+ALTER TABLE mcr.channels
+    DROP COLUMN failover,
+    DROP COLUMN failover_playout_id,
+    DROP COLUMN slate_input_id;
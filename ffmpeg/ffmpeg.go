@@ -2,21 +2,68 @@ package ffmpeg
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"syscall"
 )
 
+// Process is a running ffmpeg process.
+type Process struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
 // NewForwardStream takes a FFmpeg input and copies it to an RTMP URL.
-func NewForwardStream(ctx context.Context, srcURL, dstURL string) error {
-	cmdString := fmt.Sprintf("ffmpeg -i \"%s\" -c copy -f flv \"%s\"",
-		srcURL, dstURL)
-	cmd := exec.CommandContext(ctx, "sh", "-c", cmdString)
+func NewForwardStream(ctx context.Context, srcURL, dstURL string) (*Process, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", srcURL, "-c", "copy", "-f", "flv", dstURL)
 
 	err := cmd.Start()
 	if err != nil {
-		return fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	p := &Process{
+		cmd:  cmd,
+		done: make(chan struct{}),
 	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Wait blocks until the process has exited, returning an error if it failed.
+func (p *Process) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Stop asks ffmpeg to finish, letting it close the output cleanly. It is
+// killed if it hasn't exited by the time the context is done.
+func (p *Process) Stop(ctx context.Context) error {
+	err := p.cmd.Process.Signal(syscall.SIGTERM)
+	if err != nil {
+		return p.kill()
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return p.kill()
+	}
+}
+
+func (p *Process) kill() error {
+	err := p.cmd.Process.Kill()
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill ffmpeg: %w", err)
+	}
+	<-p.done
 	return nil
 }
 
@@ -37,3 +84,13 @@ func NewVideoFromSingleImage(ctx context.Context, srcPath, dstPath string) error
 	}
 	return nil
 }
+
+// Version returns the first line of "ffmpeg -version", checking the binary is
+// installed and runs.
+func Version(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "ffmpeg", "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run ffmpeg: %w", err)
+	}
+	return strings.SplitN(string(out), "\n", 2)[0], nil
+}
@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+type (
+	// Probe is what ffprobe found in a media file.
+	Probe struct {
+		Duration time.Duration
+		// VideoCodec and AudioCodec are empty when there isn't a stream of
+		// that type.
+		VideoCodec string
+		AudioCodec string
+		Width      int
+		Height     int
+	}
+	// probeOutput is ffprobe's JSON output.
+	probeOutput struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+	}
+)
+
+// ErrNotPlayable when a file has no audio or video that can be played over
+// time, i.e. it isn't media or is a still image.
+var ErrNotPlayable = errors.New("no playable audio or video")
+
+// NewProbe runs ffprobe on a media file, checking it can be played.
+func NewProbe(ctx context.Context, srcPath string) (Probe, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-print_format", "json",
+		"-show_format", "-show_streams", srcPath).Output()
+	if err != nil {
+		return Probe{}, fmt.Errorf("%w: ffprobe failed: %v", ErrNotPlayable, err)
+	}
+	po := probeOutput{}
+	err = json.Unmarshal(out, &po)
+	if err != nil {
+		return Probe{}, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+
+	p := Probe{}
+	for _, s := range po.Streams {
+		switch {
+		case s.CodecType == "video" && p.VideoCodec == "":
+			p.VideoCodec = s.CodecName
+			p.Width = s.Width
+			p.Height = s.Height
+		case s.CodecType == "audio" && p.AudioCodec == "":
+			p.AudioCodec = s.CodecName
+		}
+	}
+	seconds, err := strconv.ParseFloat(po.Format.Duration, 64)
+	if err == nil {
+		p.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	if p.Duration <= 0 || (p.VideoCodec == "" && p.AudioCodec == "") {
+		return Probe{}, ErrNotPlayable
+	}
+	return p, nil
+}
+
+// NewThumbnail saves a frame of a video as a JPEG image 320 pixels wide.
+func NewThumbnail(ctx context.Context, srcPath, dstPath string, at time.Duration) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64),
+		"-i", srcPath, "-frames:v", "1", "-vf", "scale=320:-2", dstPath)
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	return nil
+}
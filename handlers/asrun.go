@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+// asRunQuery selects part of a channel's as-run log. Times are RFC 3339 or
+// dates, which are midnight in the server's time zone, and either can be
+// empty to leave that end open. To is exclusive, except a date includes that
+// whole day.
+type asRunQuery struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// asRunDateFormat is the date format as-run queries accept.
+const asRunDateFormat = "2006-01-02"
+
+// times parses the query's times.
+func (q asRunQuery) times() (from, to time.Time, err error) {
+	from, err = parseAsRunTime(q.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err = parseAsRunTime(q.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if len(q.To) == len(asRunDateFormat) {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func parseAsRunTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if len(s) == len(asRunDateFormat) {
+		return time.ParseInLocation(asRunDateFormat, s, time.Local)
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func (h *Handlers) listChannelAsRun(c echo.Context) error {
+	entries, err := h.getAsRun(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+func (h *Handlers) exportChannelAsRun(c echo.Context) error {
+	entries, err := h.getAsRun(c)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="as-run-%s.csv"`, c.Param("channelID")))
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return writeAsRunCSV(c.Response(), entries)
+}
+
+// getAsRun lists the as-run log of the channel in the path for the query.
+func (h *Handlers) getAsRun(c echo.Context) ([]mcr.AsRunEntry, error) {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	q := asRunQuery{}
+	err = c.Bind(&q)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	from, to, err := q.times()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	entries, err := h.mcr.ListAsRun(ctx, ch.ID, from, to)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return entries, nil
+}
+
+// writeAsRunCSV writes as-run entries as CSV with a header row. Deviations are
+// in seconds and empty when the entry wasn't scheduled.
+func writeAsRunCSV(w io.Writer, entries []mcr.AsRunEntry) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"time", "type", "program", "input_id", "playout_id", "playout_title",
+		"scheduled", "deviation_seconds", "source", "detail",
+	})
+	for _, e := range entries {
+		var scheduled, deviation string
+		if e.Scheduled != nil {
+			scheduled = e.Scheduled.Format(time.RFC3339)
+			deviation = strconv.FormatFloat(e.Deviation.Seconds(), 'f', 3, 64)
+		}
+		var inputID, playoutID string
+		if e.InputID != 0 {
+			inputID = strconv.Itoa(e.InputID)
+		}
+		if e.PlayoutID != 0 {
+			playoutID = strconv.Itoa(e.PlayoutID)
+		}
+		_ = cw.Write([]string{
+			e.Time.Format(time.RFC3339), string(e.Type), e.Program, inputID,
+			playoutID, e.PlayoutTitle, scheduled, deviation, e.Source, e.Detail,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handlers) getChannelAssets(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	a, err := h.mcr.GetChannelAssets(c.Request().Context(), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel assets: %w", err)
+	}
+	return c.JSON(http.StatusOK, a)
+}
+
+func (h *Handlers) setChannelAsset(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.setAsset(c, channelID, fh)
+	if err != nil {
+		return apiError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// setAsset stores an uploaded file as the channel asset named in the path.
+func (h *Handlers) setAsset(c echo.Context, channelID int, fh *multipart.FileHeader) error {
+	f, err := fh.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+	err = h.mcr.SetChannelAsset(c.Request().Context(), channelID, c.Param("asset"), f)
+	if err != nil {
+		return fmt.Errorf("failed to set asset: %w", err)
+	}
+	return nil
+}
+
+func (h *Handlers) deleteChannelAsset(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.DeleteChannelAsset(c.Request().Context(), channelID, c.Param("asset"))
+	if err != nil {
+		return apiError(fmt.Errorf("failed to delete asset: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+func (h *Handlers) listChannels(c echo.Context) error {
+	ch, err := h.mcr.ListChannels(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, ch)
+}
+
+func (h *Handlers) getChannel(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(c.Request().Context(), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	return c.JSON(http.StatusOK, ch)
+}
+
+func (h *Handlers) listChannelPlayouts(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	po, err := h.mcr.GetPlayoutsForChannel(ctx, ch)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, po)
+}
+
+func (h *Handlers) newChannelPlayout(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	po := mcr.EditPlayout{}
+	err = c.Bind(&po)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	// Livestream playouts are made by linking the livestream to the channel.
+	if po.SrcType == mcr.SourceLivestream {
+		return apiError(mcr.ErrSrcTypeInvalid)
+	}
+	po.ChannelID = channelID
+	playoutID, err := h.mcr.NewPlayout(c.Request().Context(), po)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to create playout: %w", err))
+	}
+	return c.JSON(http.StatusCreated, playoutID)
+}
+
+func (h *Handlers) deleteChannelPlayout(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	playoutID, err := strconv.Atoi(c.Param("playoutID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	po, err := h.mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to get playout: %w", err))
+	}
+	if po.ChannelID != channelID {
+		return apiError(mcr.ErrPlayoutNotFound)
+	}
+	err = h.mcr.DeletePlayout(ctx, po.ID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to delete playout: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) setPlayoutAudio(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	playoutID, err := strconv.Atoi(c.Param("playoutID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	a := mcr.AudioLevel{}
+	err = c.Bind(&a)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	po, err := h.mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to get playout: %w", err))
+	}
+	if po.ChannelID != channelID {
+		return apiError(mcr.ErrPlayoutNotFound)
+	}
+	err = h.mcr.SetPlayoutAudio(ctx, po.ID, a)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set playout audio: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) listChannelFillers(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	f, err := h.mcr.ListChannelFillers(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, f)
+}
+
+func (h *Handlers) setChannelFillers(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	f := mcr.EditFillers{}
+	err = c.Bind(&f)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetChannelFillers(ctx, ch.ID, f)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set fillers: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) setFillerAudio(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	a := mcr.AudioLevel{}
+	err = c.Bind(&a)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetFillerAudio(ctx, ch.ID, a)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set filler audio: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) setChannelTransition(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	t := mcr.EditTransition{}
+	err = c.Bind(&t)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetChannelTransition(ctx, ch.ID, t)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set transition: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) setChannelFailover(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	f := mcr.EditFailover{}
+	err = c.Bind(&f)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetChannelFailover(ctx, ch.ID, f)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set failover: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) listChannelEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	evts, err := h.mcr.ListChannelEvents(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, evts)
+}
+
+func (h *Handlers) listChannelConflicts(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	conflicts, err := h.mcr.ListScheduleConflicts(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, conflicts)
+}
+
+func (h *Handlers) getCardTemplate(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	t, err := h.mcr.GetCardTemplate(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, t)
+}
+
+func (h *Handlers) setCardTemplate(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	t := mcr.CardTemplate{}
+	err = c.Bind(&t)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.SetCardTemplate(c.Request().Context(), channelID, t)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set card template: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) previewCardTemplate(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	t := mcr.CardTemplate{}
+	err = c.Bind(&t)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	return h.previewCard(c, channelID, t)
+}
+
+// previewCard responds with a channel's continuity card drawn with a template.
+func (h *Handlers) previewCard(c echo.Context, channelID int, t mcr.CardTemplate) error {
+	// Buffered so a failure can still be reported.
+	b := bytes.Buffer{}
+	err := h.mcr.PreviewContinuityCard(c.Request().Context(), channelID, t, &b)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to preview card: %w", err))
+	}
+	return c.Blob(http.StatusOK, "image/png", b.Bytes())
+}
+
+func (h *Handlers) setChannelOnAir(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetChannelOnAir(ctx, ch)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set channel on-air: %w", err))
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handlers) setChannelOffAir(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.SetChannelOffAir(ctx, ch)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set channel off-air: %w", err))
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handlers) reconcileChannels(c echo.Context) error {
+	r, err := h.mcr.Reconcile(c.Request().Context())
+	if err != nil {
+		return apiError(fmt.Errorf("failed to reconcile: %w", err))
+	}
+	return c.JSON(http.StatusOK, r)
+}
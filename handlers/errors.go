@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/livestream"
+	"github.com/ystv/showtime/mcr"
+	"github.com/ystv/showtime/youtube"
+)
+
+// apiErrors are the errors API clients are expected to handle. The code is
+// returned in JSON error responses so clients don't need to match on the
+// message, it must stay in sync with the client package.
+var apiErrors = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{livestream.ErrTitleEmpty, "title-empty", http.StatusBadRequest},
+	{livestream.ErrTitleTooLong, "title-too-long", http.StatusBadRequest},
+	{livestream.ErrDescriptionTooLong, "description-too-long", http.StatusBadRequest},
+	{livestream.ErrVisibilityInvalid, "visibility-invalid", http.StatusBadRequest},
+	{livestream.ErrStartAfterEnd, "start-after-end", http.StatusBadRequest},
+	{livestream.ErrStartInPast, "start-in-past", http.StatusBadRequest},
+	{livestream.ErrStreamKeyNotFound, "stream-key-not-found", http.StatusNotFound},
+	{livestream.ErrUnkownIntegrationType, "unknown-integration-type", http.StatusBadRequest},
+	{livestream.ErrLinkNotFound, "link-not-found", http.StatusNotFound},
+	{livestream.ErrLinkParamsMissing, "link-params-missing", http.StatusBadRequest},
+	{livestream.ErrLinkNotUpdatable, "link-not-updatable", http.StatusBadRequest},
+	{mcr.ErrTitleEmpty, "title-empty", http.StatusBadRequest},
+	{mcr.ErrChannelIDInvalid, "channel-id-invalid", http.StatusBadRequest},
+	{mcr.ErrSrcURIEmpty, "src-uri-empty", http.StatusBadRequest},
+	{mcr.ErrVisibilityEmpty, "visibility-empty", http.StatusBadRequest},
+	{mcr.ErrURLNameEmpty, "url-name-empty", http.StatusBadRequest},
+	{mcr.ErrChannelOnAir, "channel-on-air", http.StatusConflict},
+	{mcr.ErrChannelOffAir, "channel-off-air", http.StatusConflict},
+	{mcr.ErrChannelArchived, "channel-archived", http.StatusConflict},
+	{mcr.ErrChannelNotArchived, "channel-not-archived", http.StatusConflict},
+	{mcr.ErrPlayoutNotFound, "playout-not-found", http.StatusNotFound},
+	{mcr.ErrSourceOnAir, "source-on-air", http.StatusConflict},
+	{mcr.ErrSrcTypeInvalid, "src-type-invalid", http.StatusBadRequest},
+	{mcr.ErrEndModeInvalid, "end-mode-invalid", http.StatusBadRequest},
+	{mcr.ErrMediaNotFound, "media-not-found", http.StatusNotFound},
+	{mcr.ErrMediaNotPlayable, "media-not-playable", http.StatusBadRequest},
+	{mcr.ErrMediaInUse, "media-in-use", http.StatusConflict},
+	{mcr.ErrFillerOrderInvalid, "filler-order-invalid", http.StatusBadRequest},
+	{mcr.ErrCardTemplateInvalid, "card-template-invalid", http.StatusBadRequest},
+	{mcr.ErrAssetTypeInvalid, "asset-type-invalid", http.StatusBadRequest},
+	{mcr.ErrAssetNotImage, "asset-not-image", http.StatusBadRequest},
+	{mcr.ErrAssetTooLarge, "asset-too-large", http.StatusRequestEntityTooLarge},
+	{mcr.ErrScheduleInvalid, "schedule-invalid", http.StatusBadRequest},
+	{mcr.ErrPlayoutConflict, "playout-conflict", http.StatusConflict},
+	{mcr.ErrOutputNotFound, "output-not-found", http.StatusNotFound},
+	{mcr.ErrOutputTypeInvalid, "output-type-invalid", http.StatusBadRequest},
+	{mcr.ErrOutputNameEmpty, "output-name-empty", http.StatusBadRequest},
+	{mcr.ErrOutputURLInvalid, "output-url-invalid", http.StatusBadRequest},
+	{mcr.ErrOutputPortInvalid, "output-port-invalid", http.StatusBadRequest},
+	{mcr.ErrOutputPortInUse, "output-port-in-use", http.StatusConflict},
+	{mcr.ErrOutputLocal, "output-local", http.StatusConflict},
+	{mcr.ErrGraphicsInvalid, "graphics-invalid", http.StatusBadRequest},
+	{mcr.ErrLowerThirdNotFound, "lower-third-not-found", http.StatusNotFound},
+	{mcr.ErrLowerThirdTextEmpty, "lower-third-text-empty", http.StatusBadRequest},
+	{mcr.ErrTransitionInvalid, "transition-invalid", http.StatusBadRequest},
+	{mcr.ErrAudioLevelInvalid, "audio-level-invalid", http.StatusBadRequest},
+	{mcr.ErrFailoverInvalid, "failover-invalid", http.StatusBadRequest},
+	{youtube.ErrNoYouTuberFound, "youtuber-not-found", http.StatusNotFound},
+	{youtube.ErrBroadcastNotFound, "broadcast-not-found", http.StatusNotFound},
+	{brave.ErrBraveUnavailable, "brave-unavailable", http.StatusServiceUnavailable},
+}
+
+// apiError converts an error to a HTTP error, using the status of a known
+// error so clients can tell invalid requests apart from failures.
+func apiError(err error) error {
+	for _, e := range apiErrors {
+		if errors.Is(err, e.err) {
+			return echo.NewHTTPError(e.status, err)
+		}
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err)
+}
+
+// apiErrorCode returns the code of a known error, or an empty string.
+func apiErrorCode(err error) string {
+	for _, e := range apiErrors {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return ""
+}
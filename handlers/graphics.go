@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+func (h *Handlers) getChannelGraphics(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	g, err := h.mcr.GetChannelGraphics(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, g)
+}
+
+func (h *Handlers) setChannelGraphics(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	g := mcr.ChannelGraphics{}
+	err = c.Bind(&g)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.SetChannelGraphics(c.Request().Context(), channelID, g)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to set graphics: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) listLowerThirds(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	lowerThirds, err := h.mcr.ListLowerThirds(ctx, ch)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, lowerThirds)
+}
+
+func (h *Handlers) newLowerThird(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	l := mcr.EditLowerThird{}
+	err = c.Bind(&l)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	lowerThirdID, err := h.mcr.NewLowerThird(ctx, ch, l)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to create lower third: %w", err))
+	}
+	return c.JSON(http.StatusCreated, lowerThirdID)
+}
+
+func (h *Handlers) deleteLowerThird(c echo.Context) error {
+	return h.changeLowerThird(c, "delete", h.mcr.DeleteLowerThird)
+}
+
+func (h *Handlers) showLowerThird(c echo.Context) error {
+	return h.changeLowerThird(c, "show", h.mcr.ShowLowerThird)
+}
+
+func (h *Handlers) hideLowerThird(c echo.Context) error {
+	return h.changeLowerThird(c, "hide", h.mcr.HideLowerThird)
+}
+
+// changeLowerThird deletes, shows or hides the lower third of the request.
+func (h *Handlers) changeLowerThird(c echo.Context, action string, change func(context.Context, mcr.Channel, int) error) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	lowerThirdID, err := strconv.Atoi(c.Param("lowerThirdID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = change(ctx, ch, lowerThirdID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to %s lower third: %w", action, err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+type (
+	// xmltvGuide is the root of an XMLTV document.
+	xmltvGuide struct {
+		XMLName       xml.Name         `xml:"tv"`
+		GeneratorName string           `xml:"generator-info-name,attr"`
+		Channels      []xmltvChannel   `xml:"channel"`
+		Programmes    []xmltvProgramme `xml:"programme"`
+	}
+	xmltvChannel struct {
+		ID          string `xml:"id,attr"`
+		DisplayName string `xml:"display-name"`
+	}
+	xmltvProgramme struct {
+		Start       string   `xml:"start,attr"`
+		Stop        string   `xml:"stop,attr"`
+		Channel     string   `xml:"channel,attr"`
+		Title       string   `xml:"title"`
+		Description string   `xml:"desc,omitempty"`
+		Categories  []string `xml:"category"`
+	}
+)
+
+const (
+	// xmltvTimeFormat is how XMLTV times are written.
+	xmltvTimeFormat = "20060102150405 -0700"
+	// icalTimeFormat is how iCalendar UTC times are written.
+	icalTimeFormat = "20060102T150405Z"
+	// guideLiveCategory marks the programme airing now in XMLTV and iCalendar
+	// guides, which have no field for it.
+	guideLiveCategory = "Live"
+)
+
+// getGuideXMLTV returns a channel's guide as XMLTV, for set-top boxes.
+func (h *Handlers) getGuideXMLTV(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	g, err := h.mcr.GetGuide(c.Request().Context(), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get guide: %w", err)
+	}
+
+	id := h.guideChannelID(g.Channel)
+	tv := xmltvGuide{
+		GeneratorName: "ShowTime!",
+		Channels:      []xmltvChannel{{ID: id, DisplayName: g.Channel.Title}},
+	}
+	for _, p := range g.Programmes {
+		xp := xmltvProgramme{
+			Start:       p.Start.Format(xmltvTimeFormat),
+			Stop:        p.End.Format(xmltvTimeFormat),
+			Channel:     id,
+			Title:       p.Title,
+			Description: p.Description,
+		}
+		if p.Live {
+			xp.Categories = []string{guideLiveCategory}
+		}
+		tv.Programmes = append(tv.Programmes, xp)
+	}
+
+	b, err := xml.MarshalIndent(tv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal xmltv: %w", err)
+	}
+	doc := xml.Header + `<!DOCTYPE tv SYSTEM "xmltv.dtd">` + "\n" + string(b) + "\n"
+	return c.Blob(http.StatusOK, "application/xml; charset=utf-8", []byte(doc))
+}
+
+// getGuideNowNext returns what's airing on a channel and what's on next, for
+// websites.
+func (h *Handlers) getGuideNowNext(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	nn, err := h.mcr.GetNowNext(c.Request().Context(), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get now next: %w", err)
+	}
+	return c.JSON(http.StatusOK, nn)
+}
+
+// getGuideICal returns a channel's guide as an iCalendar, for calendar apps.
+func (h *Handlers) getGuideICal(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	g, err := h.mcr.GetGuide(c.Request().Context(), channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get guide: %w", err)
+	}
+
+	b := strings.Builder{}
+	line := func(name, value string) {
+		writeICalLine(&b, name+":"+value)
+	}
+	now := time.Now().UTC().Format(icalTimeFormat)
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//YSTV//ShowTime!//EN")
+	line("X-WR-CALNAME", escapeICalText(g.Channel.Title))
+	for _, p := range g.Programmes {
+		line("BEGIN", "VEVENT")
+		line("UID", fmt.Sprintf("playout-%d@%s", p.PlayoutID, h.guideChannelID(g.Channel)))
+		line("DTSTAMP", now)
+		line("DTSTART", p.Start.UTC().Format(icalTimeFormat))
+		line("DTEND", p.End.UTC().Format(icalTimeFormat))
+		line("SUMMARY", escapeICalText(p.Title))
+		if p.Description != "" {
+			line("DESCRIPTION", escapeICalText(p.Description))
+		}
+		if p.Live {
+			line("CATEGORIES", guideLiveCategory)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(b.String()))
+}
+
+// guideChannelID is a channel's globally unique ID in guides.
+func (h *Handlers) guideChannelID(ch mcr.GuideChannel) string {
+	if h.conf.DomainName == "" {
+		return ch.URLName
+	}
+	return ch.URLName + "." + h.conf.DomainName
+}
+
+// escapeICalText escapes an iCalendar TEXT value.
+func escapeICalText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeICalLine writes an iCalendar content line, folding it so no line is
+// longer than 75 bytes.
+func writeICalLine(b *strings.Builder, l string) {
+	// Folded lines start with a space.
+	max := 75
+	for len(l) > max {
+		n := max
+		// Don't split a UTF-8 character.
+		for n > 0 && l[n]&0xc0 == 0x80 {
+			n--
+		}
+		b.WriteString(l[:n])
+		b.WriteString("\r\n ")
+		l = l[n:]
+		max = 74
+	}
+	b.WriteString(l)
+	b.WriteString("\r\n")
+}
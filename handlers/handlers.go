@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -14,8 +15,10 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/ystv/showtime/auth"
+	"github.com/ystv/showtime/health"
 	"github.com/ystv/showtime/livestream"
 	"github.com/ystv/showtime/mcr"
 	"github.com/ystv/showtime/youtube"
@@ -27,6 +30,7 @@ type (
 		conf      *Config
 		jwtConfig middleware.JWTConfig
 		auth      *auth.Auther
+		health    *health.Checker
 		mcr       *mcr.MCR
 		ls        *livestream.Livestreamer
 		yt        *youtube.YouTube
@@ -55,7 +59,7 @@ type (
 )
 
 // New creates a new handler instance.
-func New(conf *Config, auth *auth.Auther, ls *livestream.Livestreamer, mcr *mcr.MCR, yt *youtube.YouTube, t *Templater) *Handlers {
+func New(conf *Config, auth *auth.Auther, health *health.Checker, ls *livestream.Livestreamer, mcr *mcr.MCR, yt *youtube.YouTube, t *Templater) *Handlers {
 	e := echo.New()
 	e.Renderer = t
 	e.Debug = conf.Debug
@@ -66,16 +70,17 @@ func New(conf *Config, auth *auth.Auther, ls *livestream.Livestreamer, mcr *mcr.
 			Claims:     &JWTClaims{},
 			SigningKey: []byte(conf.JWTSigningKey),
 		},
-		auth: auth,
-		ls:   ls,
-		mcr:  mcr,
-		yt:   yt,
-		mux:  e,
+		auth:   auth,
+		health: health,
+		ls:     ls,
+		mcr:    mcr,
+		yt:     yt,
+		mux:    e,
 	}
 }
 
-// Start sets up a HTTP server listening.
-func (h *Handlers) Start() {
+// Start sets up a HTTP server listening, blocking until it is shut down.
+func (h *Handlers) Start() error {
 	internal := h.mux.Group("")
 	{
 		// Basic UI endpoints
@@ -121,7 +126,31 @@ func (h *Handlers) Start() {
 			ch.POST("/un-archive", h.obsUnarchiveChannelConfirm)
 			ch.GET("/delete", h.obsDeleteChannel)
 			ch.POST("/delete", h.obsDeleteChannelConfirm)
+			ch.GET("/playouts/new", h.obsNewPlayout)
+			ch.POST("/playouts/new", h.obsNewPlayoutSubmit)
+			ch.GET("/assets", h.obsListChannelAssets)
+			ch.POST("/assets/:asset", h.obsSetChannelAssetSubmit)
+			ch.POST("/assets/:asset/delete", h.obsDeleteChannelAssetSubmit)
+			ch.GET("/outputs", h.obsListChannelOutputs)
+			ch.POST("/outputs", h.obsNewChannelOutputSubmit)
+			ch.POST("/outputs/:outputID/delete", h.obsDeleteChannelOutputSubmit)
+			ch.GET("/graphics", h.obsEditGraphics)
+			ch.POST("/graphics", h.obsEditGraphicsSubmit)
+			ch.POST("/lower-thirds", h.obsNewLowerThirdSubmit)
+			ch.POST("/lower-thirds/:lowerThirdID/show", h.obsShowLowerThirdSubmit)
+			ch.POST("/lower-thirds/:lowerThirdID/hide", h.obsHideLowerThirdSubmit)
+			ch.POST("/lower-thirds/:lowerThirdID/delete", h.obsDeleteLowerThirdSubmit)
+			ch.GET("/card", h.obsEditCard)
+			ch.POST("/card", h.obsEditCardSubmit)
+			ch.GET("/card/preview", h.obsPreviewCard)
+			ch.GET("/fillers", h.obsEditFillers)
+			ch.POST("/fillers", h.obsEditFillersSubmit)
+			ch.GET("/as-run", h.obsListAsRun)
+			ch.GET("/as-run/csv", h.exportChannelAsRun)
 		}
+		internal.GET("/media", h.obsListMedia)
+		internal.POST("/media", h.obsUploadMediaSubmit)
+		internal.POST("/media/:mediaID/delete", h.obsDeleteMediaSubmit)
 
 		internal.GET("/integrations", h.obsListIntegrations)
 		internal.GET("/integrations/unlink/youtube/:accountID", h.obsDeleteYouTubeIntegration)
@@ -132,15 +161,63 @@ func (h *Handlers) Start() {
 		if !h.conf.Debug {
 			api.Use(middleware.JWTWithConfig(h.jwtConfig))
 		}
+		// Again now the token has been checked.
+		api.Use(asRunSourceMiddleware)
 		{
 			api.POST("/livestreams", h.newLivestream)
-			api.PUT("/livestreams", h.updateLivestream)
 			api.GET("/livestreams", h.listLivestreams)
+			api.GET("/livestreams/:livestreamID", h.getLivestream)
+			api.PUT("/livestreams/:livestreamID", h.updateLivestream)
+			api.POST("/livestreams/:livestreamID/start", h.startLivestream)
+			api.POST("/livestreams/:livestreamID/end", h.endLivestream)
 			api.GET("/livestreams/:livestreamID/events", h.getLivestreamEvents)
+			api.GET("/livestreams/:livestreamID/links", h.listLinks)
+			api.POST("/livestreams/:livestreamID/links", h.newLink)
+			api.GET("/livestreams/:livestreamID/links/:linkID", h.getLink)
+			api.PUT("/livestreams/:livestreamID/links/:linkID", h.updateLink)
+			api.DELETE("/livestreams/:livestreamID/links/:linkID", h.deleteLink)
 			api.POST("/livestreams/:livestreamID/refresh-key", h.refreshStreamKey)
 			api.POST("/livestreams/:livestreamID/link/youtube/:broadcastID", h.enableYouTube)
 			api.POST("/livestreams/:livestreamID/unlink/youtube/:broadcastID", h.disableYouTube)
 			api.GET("/youtube/broadcasts", h.listYouTubeBroadcasts)
+			api.GET("/channels", h.listChannels)
+			api.POST("/channels/reconcile", h.reconcileChannels)
+			api.GET("/channels/:channelID", h.getChannel)
+			api.GET("/channels/:channelID/playouts", h.listChannelPlayouts)
+			api.POST("/channels/:channelID/playouts", h.newChannelPlayout)
+			api.DELETE("/channels/:channelID/playouts/:playoutID", h.deleteChannelPlayout)
+			api.PUT("/channels/:channelID/playouts/:playoutID/audio", h.setPlayoutAudio)
+			api.GET("/channels/:channelID/assets", h.getChannelAssets)
+			api.PUT("/channels/:channelID/assets/:asset", h.setChannelAsset)
+			api.DELETE("/channels/:channelID/assets/:asset", h.deleteChannelAsset)
+			api.GET("/channels/:channelID/outputs", h.listChannelOutputs)
+			api.POST("/channels/:channelID/outputs", h.newChannelOutput)
+			api.DELETE("/channels/:channelID/outputs/:outputID", h.deleteChannelOutput)
+			api.GET("/channels/:channelID/graphics", h.getChannelGraphics)
+			api.PUT("/channels/:channelID/graphics", h.setChannelGraphics)
+			api.GET("/channels/:channelID/lower-thirds", h.listLowerThirds)
+			api.POST("/channels/:channelID/lower-thirds", h.newLowerThird)
+			api.DELETE("/channels/:channelID/lower-thirds/:lowerThirdID", h.deleteLowerThird)
+			api.POST("/channels/:channelID/lower-thirds/:lowerThirdID/show", h.showLowerThird)
+			api.POST("/channels/:channelID/lower-thirds/:lowerThirdID/hide", h.hideLowerThird)
+			api.GET("/channels/:channelID/card-template", h.getCardTemplate)
+			api.PUT("/channels/:channelID/card-template", h.setCardTemplate)
+			api.POST("/channels/:channelID/card-template/preview", h.previewCardTemplate)
+			api.GET("/channels/:channelID/fillers", h.listChannelFillers)
+			api.PUT("/channels/:channelID/fillers", h.setChannelFillers)
+			api.PUT("/channels/:channelID/fillers/audio", h.setFillerAudio)
+			api.PUT("/channels/:channelID/transition", h.setChannelTransition)
+			api.PUT("/channels/:channelID/failover", h.setChannelFailover)
+			api.GET("/channels/:channelID/events", h.listChannelEvents)
+			api.GET("/channels/:channelID/conflicts", h.listChannelConflicts)
+			api.GET("/channels/:channelID/as-run", h.listChannelAsRun)
+			api.GET("/channels/:channelID/as-run/csv", h.exportChannelAsRun)
+			api.POST("/channels/:channelID/on-air", h.setChannelOnAir)
+			api.POST("/channels/:channelID/off-air", h.setChannelOffAir)
+			api.GET("/media", h.listMedia)
+			api.POST("/media", h.uploadMedia)
+			api.GET("/media/:mediaID", h.getMedia)
+			api.DELETE("/media/:mediaID", h.deleteMedia)
 		}
 	}
 
@@ -148,6 +225,12 @@ func (h *Handlers) Start() {
 	h.mux.GET("/api/health", func(c echo.Context) error {
 		return c.NoContent(http.StatusOK)
 	})
+	h.mux.GET("/api/health/live", h.getLiveness)
+	h.mux.GET("/api/health/ready", h.getReadiness)
+	h.mux.GET("/api/openapi.yaml", h.getOpenAPISpec)
+	h.mux.GET("/api/guide/:channelID/xmltv", h.getGuideXMLTV)
+	h.mux.GET("/api/guide/:channelID/now-next", h.getGuideNowNext)
+	h.mux.GET("/api/guide/:channelID/ical", h.getGuideICal)
 	h.mux.GET("/api/version", func(c echo.Context) error {
 		info, ok := debug.ReadBuildInfo()
 		if !ok {
@@ -155,8 +238,9 @@ func (h *Handlers) Start() {
 		}
 		return c.JSON(http.StatusOK, info.Settings)
 	})
-	h.mux.POST("/api/hooks/nginx/on_publish", h.hookStreamStart)
-	h.mux.POST("/api/hooks/nginx/on_publish_done", h.hookStreamDone)
+	h.mux.POST("/api/hooks/nginx/on_publish", countHook("on_publish", h.hookStreamStart))
+	h.mux.POST("/api/hooks/nginx/on_publish_done", countHook("on_publish_done", h.hookStreamDone))
+	h.mux.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 	h.mux.GET("/oauth/google/login", h.loginGoogle)
 	h.mux.GET("/oauth/google/callback", h.callbackGoogle)
 	h.mux.Static("/assets", "assets")
@@ -179,12 +263,44 @@ func (h *Handlers) Start() {
 
 	h.mux.Pre(middleware.RemoveTrailingSlash())
 	h.mux.Use(middleware.Logger())
+	h.mux.Use(metricsMiddleware)
+	h.mux.Use(asRunSourceMiddleware)
 	h.mux.Use(middleware.Recover())
 	h.mux.Use(middleware.CORSWithConfig(corsConfig))
 	h.mux.HideBanner = true
 	h.mux.HTTPErrorHandler = h.handleError
 
-	h.mux.Logger.Fatal(h.mux.Start(":8080"))
+	err := h.mux.Start(":8080")
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// asRunSourceMiddleware records who changes channels in the as-run log. API
+// requests are by the token's user, or by "api" when authentication is
+// disabled, other requests are from the web UI, which doesn't know its users.
+func asRunSourceMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		source := "web"
+		if strings.HasPrefix(c.Path(), "/api/") {
+			source = "api"
+			if token, ok := c.Get("user").(*jwt.Token); ok {
+				if claims, ok := token.Claims.(*JWTClaims); ok {
+					source = fmt.Sprintf("user %d", claims.UserID)
+				}
+			}
+		}
+		ctx := mcr.WithAsRunSource(c.Request().Context(), source)
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// Shutdown stops accepting connections, waiting for in-flight requests to
+// finish until the context is done.
+func (h *Handlers) Shutdown(ctx context.Context) error {
+	return h.mux.Shutdown(ctx)
 }
 
 func (h *Handlers) handleError(err error, c echo.Context) {
@@ -201,7 +317,13 @@ func (h *Handlers) handleError(err error, c echo.Context) {
 	var httpErr *echo.HTTPError
 	if errors.As(err, &httpErr) {
 		if isJSON {
-			_ = c.JSON(httpErr.Code, map[string]string{"error": fmt.Sprintf("%v", httpErr.Message)})
+			res := map[string]string{"error": fmt.Sprintf("%v", httpErr.Message)}
+			if msgErr, ok := httpErr.Message.(error); ok {
+				if code := apiErrorCode(msgErr); code != "" {
+					res["code"] = code
+				}
+			}
+			_ = c.JSON(httpErr.Code, res)
 		} else {
 			_ = c.String(httpErr.Code, fmt.Sprintf("%s: %v", http.StatusText(httpErr.Code), httpErr.Message))
 		}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/health"
+)
+
+// getLiveness responds as long as the server is serving requests, it doesn't
+// check any dependencies.
+func (h *Handlers) getLiveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, health.Report{
+		Status: health.StatusOK,
+		Checks: []health.Check{},
+	})
+}
+
+// getReadiness checks every dependency, responding with 503 when any of them
+// fail.
+func (h *Handlers) getReadiness(c echo.Context) error {
+	r := h.health.Ready(c.Request().Context())
+	status := http.StatusOK
+	if r.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, r)
+}
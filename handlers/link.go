@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/livestream"
+)
+
+func (h *Handlers) listLinks(c echo.Context) error {
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	links, err := h.ls.ListLinkDetails(c.Request().Context(), strmID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, links)
+}
+
+func (h *Handlers) newLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	p := livestream.EditLink{}
+	err = c.Bind(&p)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	strm, err := h.ls.Get(ctx, strmID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
+	}
+
+	link, err := h.ls.LinkIntegration(ctx, strm, p)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to create link: %w", err))
+	}
+	d, err := h.ls.GetLinkDetails(ctx, link)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusCreated, d)
+}
+
+func (h *Handlers) getLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	link, err := h.getLivestreamLink(c)
+	if err != nil {
+		return err
+	}
+	d, err := h.ls.GetLinkDetails(ctx, link)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, d)
+}
+
+func (h *Handlers) updateLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	link, err := h.getLivestreamLink(c)
+	if err != nil {
+		return err
+	}
+	p := livestream.EditLink{}
+	err = c.Bind(&p)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = h.ls.UpdateLink(ctx, link, p)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to update link: %w", err))
+	}
+	d, err := h.ls.GetLinkDetails(ctx, link)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, d)
+}
+
+func (h *Handlers) deleteLink(c echo.Context) error {
+	link, err := h.getLivestreamLink(c)
+	if err != nil {
+		return err
+	}
+	err = h.ls.DeleteLink(c.Request().Context(), link)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to delete link: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getLivestreamLink retrieves the link in the path, making sure it belongs to
+// the livestream in the path.
+func (h *Handlers) getLivestreamLink(c echo.Context) (livestream.Link, error) {
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return livestream.Link{}, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	linkID, err := strconv.Atoi(c.Param("linkID"))
+	if err != nil {
+		return livestream.Link{}, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	link, err := h.ls.GetLink(c.Request().Context(), linkID)
+	if err != nil {
+		if errors.Is(err, livestream.ErrLinkNotFound) {
+			return livestream.Link{}, echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return livestream.Link{}, echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	if link.LivestreamID != strmID {
+		return livestream.Link{}, echo.NewHTTPError(http.StatusNotFound, livestream.ErrLinkNotFound)
+	}
+	return link, nil
+}
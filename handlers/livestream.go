@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -17,11 +18,23 @@ func (h *Handlers) newLivestream(c echo.Context) error {
 	}
 	strmID, err := h.ls.New(c.Request().Context(), strm)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		return apiError(err)
 	}
 	return c.JSON(http.StatusCreated, strmID)
 }
 
+func (h *Handlers) getLivestream(c echo.Context) error {
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	strm, err := h.ls.Get(c.Request().Context(), strmID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
+	}
+	return c.JSON(http.StatusOK, strm)
+}
+
 func (h *Handlers) listLivestreams(c echo.Context) error {
 	strms, err := h.ls.List(c.Request().Context())
 	if err != nil {
@@ -57,15 +70,57 @@ func (h *Handlers) updateLivestream(c echo.Context) error {
 	}
 	err = h.ls.Update(c.Request().Context(), strmID, strm)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		return apiError(err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handlers) startLivestream(c echo.Context) error {
+	ctx := c.Request().Context()
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	strm, err := h.ls.Get(ctx, strmID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
 	}
-	return nil
+	err = h.ls.Start(ctx, strm)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to start livestream: %w", err))
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handlers) endLivestream(c echo.Context) error {
+	ctx := c.Request().Context()
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	strm, err := h.ls.Get(ctx, strmID)
+	if err != nil {
+		return fmt.Errorf("failed to get livestream: %w", err)
+	}
+	err = h.ls.End(ctx, strm)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to end livestream: %w", err))
+	}
+	return c.NoContent(http.StatusOK)
 }
 
 func (h *Handlers) refreshStreamKey(c echo.Context) error {
-	err := h.ls.RefreshStreamKey(c.Request().Context(), c.Param("playoutID"))
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	streamKey, err := h.ls.RefreshStreamKey(c.Request().Context(), strmID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, struct {
+		StreamKey string `json:"streamKey"`
+	}{
+		StreamKey: streamKey,
+	})
 }
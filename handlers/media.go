@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+func (h *Handlers) listMedia(c echo.Context) error {
+	m, err := h.mcr.ListMedia(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, m)
+}
+
+func (h *Handlers) getMedia(c echo.Context) error {
+	mediaID, err := strconv.Atoi(c.Param("mediaID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	m, err := h.mcr.GetMedia(c.Request().Context(), mediaID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to get media: %w", err))
+	}
+	return c.JSON(http.StatusOK, m)
+}
+
+func (h *Handlers) uploadMedia(c echo.Context) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	m, err := h.newMedia(c.Request().Context(), fh, c.FormValue("title"))
+	if err != nil {
+		return apiError(err)
+	}
+	return c.JSON(http.StatusCreated, m)
+}
+
+// newMedia adds an uploaded file to the media library, the title defaults to
+// the file's name.
+func (h *Handlers) newMedia(ctx context.Context, fh *multipart.FileHeader, title string) (mcr.Media, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return mcr.Media{}, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+
+	if title == "" {
+		title = strings.TrimSuffix(fh.Filename, filepath.Ext(fh.Filename))
+	}
+	m, err := h.mcr.NewMedia(ctx, mcr.NewMediaParams{
+		Title:    title,
+		FileName: fh.Filename,
+		Body:     f,
+	})
+	if err != nil {
+		return mcr.Media{}, fmt.Errorf("failed to add media: %w", err)
+	}
+	return m, nil
+}
+
+func (h *Handlers) deleteMedia(c echo.Context) error {
+	mediaID, err := strconv.Atoi(c.Param("mediaID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.DeleteMedia(c.Request().Context(), mediaID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to delete media: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
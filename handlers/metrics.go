@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/metrics"
+)
+
+// metricsMiddleware records each request by its route, rather than path, so
+// IDs don't create a series per resource.
+func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		status := responseStatus(c, err)
+		route := c.Path()
+		// Echo uses the request path when no route matched, which would let
+		// anyone create series.
+		if route == "" || (status == http.StatusNotFound && route == c.Request().URL.Path) {
+			route = "unmatched"
+		}
+		method := c.Request().Method
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// countHook records the result of an nginx hook, a bad request means nginx
+// was told to reject the stream.
+func countHook(hook string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		switch status := responseStatus(c, err); {
+		case status < 400:
+			metrics.NginxHooks.WithLabelValues(hook, metrics.HookOK).Inc()
+		case status < 500:
+			metrics.NginxHooks.WithLabelValues(hook, metrics.HookRejected).Inc()
+		default:
+			metrics.NginxHooks.WithLabelValues(hook, metrics.HookError).Inc()
+		}
+		return err
+	}
+}
+
+// responseStatus is the status code a request will be responded with, errors
+// aren't written until they reach the error handler.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
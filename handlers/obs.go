@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"strconv"
 	"time"
@@ -104,6 +106,7 @@ type (
 		ScheduledStart string `form:"scheduledStart"`
 		ScheduledEnd   string `form:"scheduledEnd"`
 		Visibility     string `form:"visibility"`
+		AllowConflict  bool   `form:"allowConflict"`
 	}
 )
 
@@ -199,6 +202,7 @@ func (h *Handlers) obsEditLivestreamSubmit(c echo.Context) error {
 		ScheduledStart: scheduledStart,
 		ScheduledEnd:   scheduledEnd,
 		Visibility:     form.Fields.Visibility,
+		AllowConflict:  form.Fields.AllowConflict,
 	}
 	err = h.ls.Update(c.Request().Context(), strmID, strm)
 	if err != nil {
@@ -344,6 +348,9 @@ func (h *Handlers) obsUnlink(c echo.Context) error {
 
 	link, err := h.ls.GetLink(ctx, linkID)
 	if err != nil {
+		if errors.Is(err, livestream.ErrLinkNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
 		return fmt.Errorf("failed to get link: %w", err)
 	}
 
@@ -400,28 +407,9 @@ func (h *Handlers) obsLinkToMCRConfirm(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
 
-	po := mcr.EditPlayout{
-		ChannelID:      res.ChannelID,
-		SrcURI:         h.conf.IngestAddress + "/" + strm.StreamKey,
-		Title:          strm.Title,
-		Description:    strm.Description,
-		ScheduledStart: strm.ScheduledStart,
-		ScheduledEnd:   strm.ScheduledEnd,
-		Visibility:     strm.Visibility,
-	}
-	playoutID, err := h.mcr.NewPlayout(ctx, po)
-	if err != nil {
-		err = fmt.Errorf("failed to create new playout: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
-	_, err = h.ls.NewLink(ctx, livestream.NewLinkParams{
-		LivestreamID:    strmID,
-		IntegrationType: livestream.LinkMCR,
-		IntegrationID:   strconv.Itoa(playoutID),
-	})
+	_, err = h.ls.NewMCRLink(ctx, strm, res.ChannelID)
 	if err != nil {
-		err = fmt.Errorf("failed to create new link: %w", err)
+		err = fmt.Errorf("failed to create new mcr link: %w", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
@@ -474,32 +462,9 @@ func (h *Handlers) obsLinkToYouTubeConfirm(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	yt, err := h.yt.GetYouTuber(accountID)
-	if err != nil {
-		err = fmt.Errorf("failed to get youtuber: %w", err)
-		return echo.NewHTTPError(http.StatusNotFound, err)
-	}
-
-	b, err := yt.NewBroadcast(ctx, youtube.EditBroadcast{
-		Title:          strm.Title,
-		Description:    strm.Description,
-		ScheduledStart: strm.ScheduledStart,
-		ScheduledEnd:   strm.ScheduledEnd,
-		Visibility:     strm.Visibility,
-	})
-	if err != nil {
-		err = fmt.Errorf("failed to create new broadcast: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
-	_, err = h.ls.NewLink(ctx, livestream.NewLinkParams{
-		LivestreamID:    strmID,
-		IntegrationType: livestream.LinkYTNew,
-		IntegrationID:   b.ID,
-	})
+	_, err = h.ls.NewYTNewLink(ctx, strm, accountID)
 	if err != nil {
-		err = fmt.Errorf("failed to create new link: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		return apiError(fmt.Errorf("failed to create new yt-new link: %w", err))
 	}
 
 	return c.Render(http.StatusCreated, "successful-link", strmID)
@@ -589,26 +554,9 @@ func (h *Handlers) obsLinkToYouTubeExistingConfirm(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
 
-	yt, err := h.yt.GetYouTuber(accountID)
+	_, err = h.ls.NewYTExistingLink(ctx, strmID, accountID, newExistingBroadcast.ID)
 	if err != nil {
-		err = fmt.Errorf("failed to get youtuber: %w", err)
-		return echo.NewHTTPError(http.StatusNotFound, err)
-	}
-
-	err = yt.NewExistingBroadcast(ctx, newExistingBroadcast.ID)
-	if err != nil {
-		err = fmt.Errorf("failed to create new existing broadcast: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
-	_, err = h.ls.NewLink(ctx, livestream.NewLinkParams{
-		LivestreamID:    strmID,
-		IntegrationType: livestream.LinkYTExisting,
-		IntegrationID:   newExistingBroadcast.ID,
-	})
-	if err != nil {
-		err = fmt.Errorf("failed to create new link: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		return apiError(fmt.Errorf("failed to create new yt-existing link: %w", err))
 	}
 
 	return c.Render(http.StatusCreated, "successful-link", strmID)
@@ -646,19 +594,9 @@ func (h *Handlers) obsLinkToRTMPConfirm(c echo.Context) error {
 
 	outputURL := c.FormValue("outputURL")
 
-	rtmpOutput, err := h.ls.NewRTMPOutput(ctx, outputURL)
-	if err != nil {
-		err = fmt.Errorf("failed to create new rtmp output: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
-	_, err = h.ls.NewLink(ctx, livestream.NewLinkParams{
-		LivestreamID:    strm.ID,
-		IntegrationType: livestream.LinkRTMPOutput,
-		IntegrationID:   strconv.Itoa(rtmpOutput.ID),
-	})
+	_, err = h.ls.NewRTMPOutputLink(ctx, strm.ID, outputURL)
 	if err != nil {
-		err = fmt.Errorf("failed to create new link: %w", err)
+		err = fmt.Errorf("failed to create new rtmp link: %w", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
@@ -680,7 +618,9 @@ func (h *Handlers) obsListChannels(c echo.Context) error {
 
 func (h *Handlers) obsNewChannel(c echo.Context) error {
 	return c.Render(http.StatusOK, "edit-channel", editChannelForm{
-		Fields: mcr.EditChannel{},
+		Fields: channelFormFields{
+			EditChannel: mcr.EditChannel{Transition: mcr.TransitionCut, Failover: mcr.FailoverContinuity},
+		},
 		Title:  "New",
 		Action: "Create",
 	})
@@ -689,16 +629,21 @@ func (h *Handlers) obsNewChannel(c echo.Context) error {
 type (
 	editChannelForm struct {
 		ID     int
-		Fields mcr.EditChannel
+		Fields channelFormFields
 		Title  string
 		Action string
 		Errors []string
 	}
+	// channelFormFields are the channel's fields with the transition's
+	// duration in seconds.
+	channelFormFields struct {
+		mcr.EditChannel
+		TransitionSeconds float64 `form:"transitionSeconds"`
+	}
 )
 
 func (h *Handlers) obsNewChannelSubmit(c echo.Context) error {
 	form := editChannelForm{
-		Fields: mcr.EditChannel{},
 		Title:  "New",
 		Action: "Create",
 	}
@@ -708,8 +653,9 @@ func (h *Handlers) obsNewChannelSubmit(c echo.Context) error {
 		form.Errors = append(form.Errors, err.Error())
 		return c.Render(http.StatusBadRequest, "edit-channel", form)
 	}
+	form.Fields.TransitionDuration = time.Duration(form.Fields.TransitionSeconds * float64(time.Second))
 
-	chID, err := h.mcr.NewChannel(c.Request().Context(), form.Fields)
+	chID, err := h.mcr.NewChannel(c.Request().Context(), form.Fields.EditChannel)
 	if err != nil {
 		form.Errors = append(form.Errors, err.Error())
 		return c.Render(http.StatusBadRequest, "edit-channel", form)
@@ -732,12 +678,35 @@ func (h *Handlers) obsGetChannel(c echo.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get playuts: %w", err)
 	}
+	evts, err := h.mcr.ListChannelEvents(ctx, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel events: %w", err)
+	}
+	// Only shown until the card is next refreshed.
+	var cardFailure *mcr.ChannelEvent
+	for i := len(evts) - 1; i >= 0; i-- {
+		if evts[i].Type == mcr.EventCardRefreshed {
+			break
+		}
+		if evts[i].Type == mcr.EventCardFailed {
+			cardFailure = &evts[i]
+			break
+		}
+	}
+	conflicts, err := h.mcr.ListScheduleConflicts(ctx, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule conflicts: %w", err)
+	}
 	data := struct {
-		Channel  mcr.Channel
-		Playouts []mcr.Playout
+		Channel     mcr.Channel
+		Playouts    []mcr.Playout
+		CardFailure *mcr.ChannelEvent
+		Conflicts   []mcr.ScheduleConflict
 	}{
-		Channel:  ch,
-		Playouts: po,
+		Channel:     ch,
+		Playouts:    po,
+		CardFailure: cardFailure,
+		Conflicts:   conflicts,
 	}
 	return c.Render(http.StatusOK, "get-channel", data)
 }
@@ -755,9 +724,15 @@ func (h *Handlers) obsEditChannel(c echo.Context) error {
 	}
 
 	return c.Render(http.StatusOK, "edit-channel", editChannelForm{
-		Fields: mcr.EditChannel{
-			Title:   ch.Title,
-			URLName: ch.URLName,
+		Fields: channelFormFields{
+			EditChannel: mcr.EditChannel{
+				Title:      ch.Title,
+				URLName:    ch.URLName,
+				Transition: ch.Transition,
+				AudioOnly:  ch.AudioOnly,
+				Failover:   ch.Failover,
+			},
+			TransitionSeconds: ch.TransitionDuration.Seconds(),
 		},
 		ID:     ch.ID,
 		Title:  "Edit",
@@ -778,7 +753,6 @@ func (h *Handlers) obsEditChannelSubmit(c echo.Context) error {
 	}
 
 	form := editChannelForm{
-		Fields: mcr.EditChannel{},
 		ID:     ch.ID,
 		Title:  "Edit",
 		Action: "Save",
@@ -789,8 +763,9 @@ func (h *Handlers) obsEditChannelSubmit(c echo.Context) error {
 		form.Errors = append(form.Errors, err.Error())
 		return c.Render(http.StatusBadRequest, "edit-channel", form)
 	}
+	form.Fields.TransitionDuration = time.Duration(form.Fields.TransitionSeconds * float64(time.Second))
 
-	err = h.mcr.UpdateChannel(ctx, ch.ID, form.Fields)
+	err = h.mcr.UpdateChannel(ctx, ch.ID, form.Fields.EditChannel)
 	if err != nil {
 		form.Errors = append(form.Errors, err.Error())
 		return c.Render(http.StatusBadRequest, "edit-channel", form)
@@ -996,6 +971,718 @@ type listIntegrationsResponse struct {
 	Integrations integrations
 }
 
+type (
+	newPlayoutForm struct {
+		Channel mcr.Channel
+		Media   []mcr.Media
+		Fields  NewPlayoutFormFields
+		Errors  []string
+	}
+	// NewPlayoutFormFields are fields on the form.
+	NewPlayoutFormFields struct {
+		MediaID        int    `form:"mediaID"`
+		Title          string `form:"title"`
+		Description    string `form:"description"`
+		ScheduledStart string `form:"scheduledStart"`
+		// ScheduledEnd defaults to the end of the media.
+		ScheduledEnd string `form:"scheduledEnd"`
+		Visibility   string `form:"visibility"`
+		EndMode      string `form:"endMode"`
+		// AllowConflict schedules the playout even when it overlaps another.
+		AllowConflict bool `form:"allowConflict"`
+		// StartTransition and EndTransition are empty for the channel's.
+		StartTransition        string  `form:"startTransition"`
+		StartTransitionSeconds float64 `form:"startTransitionSeconds"`
+		EndTransition          string  `form:"endTransition"`
+		EndTransitionSeconds   float64 `form:"endTransitionSeconds"`
+		// Volume, Gain and Muted are the playout's audio level.
+		Volume float64 `form:"volume"`
+		Gain   float64 `form:"gain"`
+		Muted  bool    `form:"muted"`
+	}
+)
+
+func (h *Handlers) obsNewPlayout(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	media, err := h.mcr.ListMedia(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.Render(http.StatusOK, "new-playout", newPlayoutForm{
+		Channel: ch,
+		Media:   media,
+		Fields: NewPlayoutFormFields{
+			Visibility: "public",
+			EndMode:    mcr.EndHard,
+			Volume:     mcr.DefaultAudioLevel().Volume,
+		},
+	})
+}
+
+func (h *Handlers) obsNewPlayoutSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	media, err := h.mcr.ListMedia(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	form := newPlayoutForm{
+		Channel: ch,
+		Media:   media,
+	}
+
+	err = c.Bind(&form.Fields)
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "new-playout", form)
+	}
+	if form.Fields.MediaID == 0 {
+		form.Errors = append(form.Errors, "media is required")
+	}
+	if form.Fields.ScheduledStart == "" {
+		form.Errors = append(form.Errors, "scheduled start is required")
+	}
+	if len(form.Errors) != 0 {
+		return c.Render(http.StatusBadRequest, "new-playout", form)
+	}
+
+	po := mcr.EditPlayout{
+		ChannelID:               ch.ID,
+		MediaID:                 form.Fields.MediaID,
+		Title:                   form.Fields.Title,
+		Description:             form.Fields.Description,
+		Visibility:              form.Fields.Visibility,
+		EndMode:                 form.Fields.EndMode,
+		AllowConflict:           form.Fields.AllowConflict,
+		StartTransition:         form.Fields.StartTransition,
+		StartTransitionDuration: time.Duration(form.Fields.StartTransitionSeconds * float64(time.Second)),
+		EndTransition:           form.Fields.EndTransition,
+		EndTransitionDuration:   time.Duration(form.Fields.EndTransitionSeconds * float64(time.Second)),
+		Audio: &mcr.AudioLevel{
+			Volume: form.Fields.Volume,
+			Gain:   form.Fields.Gain,
+			Muted:  form.Fields.Muted,
+		},
+	}
+	po.ScheduledStart, err = time.Parse(time.RFC3339, form.Fields.ScheduledStart+":00Z")
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "new-playout", form)
+	}
+	if form.Fields.ScheduledEnd != "" {
+		po.ScheduledEnd, err = time.Parse(time.RFC3339, form.Fields.ScheduledEnd+":00Z")
+		if err != nil {
+			form.Errors = append(form.Errors, err.Error())
+			return c.Render(http.StatusBadRequest, "new-playout", form)
+		}
+	}
+	if po.Title == "" {
+		for _, m := range media {
+			if m.ID == po.MediaID {
+				po.Title = m.Title
+			}
+		}
+	}
+
+	_, err = h.mcr.NewPlayout(ctx, po)
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "new-playout", form)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d", ch.ID))
+}
+
+type (
+	channelAssets struct {
+		Channel mcr.Channel
+		Assets  []channelAsset
+		Errors  []string
+	}
+	channelAsset struct {
+		Type string
+		Name string
+		Help string
+		// URI is empty when the asset hasn't been uploaded.
+		URI string
+	}
+)
+
+func (h *Handlers) obsListChannelAssets(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	return h.renderChannelAssets(c, channelID, http.StatusOK, nil)
+}
+
+func (h *Handlers) obsSetChannelAssetSubmit(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	fh, err := c.FormFile("file")
+	if err == nil {
+		err = h.setAsset(c, channelID, fh)
+	}
+	if err != nil {
+		return h.renderChannelAssets(c, channelID, http.StatusBadRequest, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/assets", channelID))
+}
+
+func (h *Handlers) obsDeleteChannelAssetSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.DeleteChannelAsset(ctx, channelID, c.Param("asset"))
+	if err != nil {
+		return h.renderChannelAssets(c, channelID, http.StatusBadRequest, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/assets", channelID))
+}
+
+// renderChannelAssets shows a channel's assets, with an error from changing
+// them when it isn't nil.
+func (h *Handlers) renderChannelAssets(c echo.Context, channelID, status int, formErr error) error {
+	ctx := c.Request().Context()
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	a, err := h.mcr.GetChannelAssets(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	data := channelAssets{
+		Channel: ch,
+		Assets: []channelAsset{
+			{mcr.AssetBackground, "Background", "Behind the continuity card, resized to fill the channel.", a.BackgroundURI},
+			{mcr.AssetLogo, "Logo", "Drawn on the continuity card when its template has a logo position.", a.LogoURI},
+			{mcr.AssetSlate, "Holding slate", "Shown instead of the continuity card when it can't be drawn.", a.SlateURI},
+		},
+	}
+	if formErr != nil {
+		data.Errors = append(data.Errors, formErr.Error())
+	}
+	return c.Render(status, "list-channel-assets", data)
+}
+
+type channelOutputs struct {
+	Channel mcr.Channel
+	Outputs []mcr.ChannelOutput
+	Fields  mcr.EditChannelOutput
+	Errors  []string
+}
+
+func (h *Handlers) obsListChannelOutputs(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	return h.renderChannelOutputs(c, channelID, http.StatusOK, mcr.EditChannelOutput{Type: mcr.OutputRTMP}, nil)
+}
+
+func (h *Handlers) obsNewChannelOutputSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	o := mcr.EditChannelOutput{}
+	err = c.Bind(&o)
+	if err != nil {
+		return h.renderChannelOutputs(c, channelID, http.StatusBadRequest, o, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	_, err = h.mcr.NewChannelOutput(ctx, ch, o)
+	if err != nil {
+		return h.renderChannelOutputs(c, channelID, http.StatusBadRequest, o, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/outputs", channelID))
+}
+
+func (h *Handlers) obsDeleteChannelOutputSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	outputID, err := strconv.Atoi(c.Param("outputID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	err = h.mcr.DeleteChannelOutput(ctx, ch, outputID)
+	if err != nil {
+		return h.renderChannelOutputs(c, channelID, http.StatusBadRequest, mcr.EditChannelOutput{Type: mcr.OutputRTMP}, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/outputs", channelID))
+}
+
+// renderChannelOutputs shows a channel's outputs and the form to add one, with
+// an error from changing them when it isn't nil.
+func (h *Handlers) renderChannelOutputs(c echo.Context, channelID, status int, fields mcr.EditChannelOutput, formErr error) error {
+	ctx := c.Request().Context()
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	outputs, err := h.mcr.ListChannelOutputs(ctx, ch)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	data := channelOutputs{
+		Channel: ch,
+		Outputs: outputs,
+		Fields:  fields,
+	}
+	if formErr != nil {
+		data.Errors = append(data.Errors, formErr.Error())
+	}
+	return c.Render(status, "list-channel-outputs", data)
+}
+
+type (
+	channelGraphics struct {
+		Channel     mcr.Channel
+		Fields      graphicsFormFields
+		LowerThirds []mcr.LowerThird
+		// Playouts are the scheduled and live playouts lower thirds can be
+		// shown during.
+		Playouts      []mcr.Playout
+		NewLowerThird lowerThirdFormFields
+		Errors        []string
+	}
+	// graphicsFormFields are the channel's graphics with the next up lead in
+	// seconds.
+	graphicsFormFields struct {
+		mcr.ChannelGraphics
+		NextUpLeadSeconds int `form:"nextUpLeadSeconds"`
+	}
+	// lowerThirdFormFields are fields on the new lower third form.
+	lowerThirdFormFields struct {
+		PlayoutID       int    `form:"playoutID"`
+		Text            string `form:"text"`
+		OffsetSeconds   int    `form:"offsetSeconds"`
+		DurationSeconds int    `form:"durationSeconds"`
+	}
+)
+
+func (h *Handlers) obsEditGraphics(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	return h.renderChannelGraphics(c, channelID, http.StatusOK, nil, lowerThirdFormFields{}, nil)
+}
+
+func (h *Handlers) obsEditGraphicsSubmit(c echo.Context) error {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	fields := graphicsFormFields{}
+	err = c.Bind(&fields)
+	if err != nil {
+		return h.renderChannelGraphics(c, channelID, http.StatusBadRequest, &fields, lowerThirdFormFields{}, err)
+	}
+	fields.NextUpLead = time.Duration(fields.NextUpLeadSeconds) * time.Second
+	err = h.mcr.SetChannelGraphics(c.Request().Context(), channelID, fields.ChannelGraphics)
+	if err != nil {
+		return h.renderChannelGraphics(c, channelID, http.StatusBadRequest, &fields, lowerThirdFormFields{}, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/graphics", channelID))
+}
+
+func (h *Handlers) obsNewLowerThirdSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	fields := lowerThirdFormFields{}
+	err = c.Bind(&fields)
+	if err != nil {
+		return h.renderChannelGraphics(c, channelID, http.StatusBadRequest, nil, fields, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	_, err = h.mcr.NewLowerThird(ctx, ch, mcr.EditLowerThird{
+		PlayoutID: fields.PlayoutID,
+		Text:      fields.Text,
+		Offset:    time.Duration(fields.OffsetSeconds) * time.Second,
+		Duration:  time.Duration(fields.DurationSeconds) * time.Second,
+	})
+	if err != nil {
+		return h.renderChannelGraphics(c, channelID, http.StatusBadRequest, nil, fields, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/graphics", channelID))
+}
+
+func (h *Handlers) obsShowLowerThirdSubmit(c echo.Context) error {
+	return h.obsChangeLowerThird(c, h.mcr.ShowLowerThird)
+}
+
+func (h *Handlers) obsHideLowerThirdSubmit(c echo.Context) error {
+	return h.obsChangeLowerThird(c, h.mcr.HideLowerThird)
+}
+
+func (h *Handlers) obsDeleteLowerThirdSubmit(c echo.Context) error {
+	return h.obsChangeLowerThird(c, h.mcr.DeleteLowerThird)
+}
+
+// obsChangeLowerThird shows, hides or deletes the lower third of the request,
+// going back to the channel's graphics.
+func (h *Handlers) obsChangeLowerThird(c echo.Context, change func(context.Context, mcr.Channel, int) error) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	lowerThirdID, err := strconv.Atoi(c.Param("lowerThirdID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	err = change(ctx, ch, lowerThirdID)
+	if err != nil {
+		return h.renderChannelGraphics(c, channelID, http.StatusBadRequest, nil, lowerThirdFormFields{}, err)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d/graphics", channelID))
+}
+
+// renderChannelGraphics shows a channel's graphics and lower thirds with the
+// forms to change them, with an error from changing them when it isn't nil.
+// The stored graphics are shown when fields is nil.
+func (h *Handlers) renderChannelGraphics(c echo.Context, channelID, status int, fields *graphicsFormFields, newLowerThird lowerThirdFormFields, formErr error) error {
+	ctx := c.Request().Context()
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	if fields == nil {
+		g, err := h.mcr.GetChannelGraphics(ctx, ch.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+		fields = &graphicsFormFields{
+			ChannelGraphics:   g,
+			NextUpLeadSeconds: int(g.NextUpLead / time.Second),
+		}
+	}
+	lowerThirds, err := h.mcr.ListLowerThirds(ctx, ch)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	playouts, err := h.mcr.GetPlayoutsForChannel(ctx, ch)
+	if err != nil {
+		return fmt.Errorf("failed to get playouts: %w", err)
+	}
+	data := channelGraphics{
+		Channel:       ch,
+		Fields:        *fields,
+		LowerThirds:   lowerThirds,
+		NewLowerThird: newLowerThird,
+	}
+	for _, po := range playouts {
+		if po.Status == "scheduled" || po.Status == "live" {
+			data.Playouts = append(data.Playouts, po)
+		}
+	}
+	if formErr != nil {
+		data.Errors = append(data.Errors, formErr.Error())
+	}
+	return c.Render(status, "channel-graphics", data)
+}
+
+type editCardForm struct {
+	Channel mcr.Channel
+	Fields  mcr.CardTemplate
+	// Preview is the query of the preview image.
+	Preview template.URL
+	Errors  []string
+}
+
+func (h *Handlers) obsEditCard(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	t, err := h.mcr.GetCardTemplate(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.Render(http.StatusOK, "edit-card", editCardForm{
+		Channel: ch,
+		Fields:  t,
+		Preview: cardPreviewQuery(c),
+	})
+}
+
+func (h *Handlers) obsEditCardSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	form := editCardForm{
+		Channel: ch,
+		Preview: cardPreviewQuery(c),
+	}
+
+	err = c.Bind(&form.Fields)
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "edit-card", form)
+	}
+	err = h.mcr.SetCardTemplate(ctx, ch.ID, form.Fields)
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "edit-card", form)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d", ch.ID))
+}
+
+// cardPreviewQuery is the form's fields as a preview image query, so the
+// preview shows what was submitted.
+func cardPreviewQuery(c echo.Context) template.URL {
+	if c.Request().Method != http.MethodPost {
+		return ""
+	}
+	f, err := c.FormParams()
+	if err != nil {
+		return ""
+	}
+	return template.URL("?" + f.Encode())
+}
+
+func (h *Handlers) obsPreviewCard(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	t := mcr.CardTemplate{}
+	if len(c.QueryParams()) == 0 {
+		t, err = h.mcr.GetCardTemplate(ctx, channelID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+	} else {
+		err = c.Bind(&t)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+	}
+	return h.previewCard(c, channelID, t)
+}
+
+type (
+	editFillersForm struct {
+		Channel mcr.Channel
+		Media   []mcr.Media
+		Fields  EditFillersFormFields
+		Errors  []string
+	}
+	// EditFillersFormFields are fields on the form.
+	EditFillersFormFields struct {
+		Order string `form:"order"`
+		// MediaIDs are in playlist order, 0 is an empty slot.
+		MediaIDs []int `form:"mediaID"`
+		// Volume, Gain and Muted are the audio level of every filler.
+		Volume float64 `form:"volume"`
+		Gain   float64 `form:"gain"`
+		Muted  bool    `form:"muted"`
+	}
+)
+
+// fillerSlots is how many empty slots the fillers form has for adding media.
+const fillerSlots = 3
+
+func (h *Handlers) obsEditFillers(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	media, err := h.mcr.ListMedia(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	fillers, err := h.mcr.ListChannelFillers(ctx, ch.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	form := editFillersForm{
+		Channel: ch,
+		Media:   media,
+		Fields: EditFillersFormFields{
+			Order:  ch.FillerOrder,
+			Volume: ch.FillerAudio.Volume,
+			Gain:   ch.FillerAudio.Gain,
+			Muted:  ch.FillerAudio.Muted,
+		},
+	}
+	for _, f := range fillers {
+		form.Fields.MediaIDs = append(form.Fields.MediaIDs, f.MediaID)
+	}
+	form.Fields.MediaIDs = append(form.Fields.MediaIDs, make([]int, fillerSlots)...)
+	return c.Render(http.StatusOK, "edit-fillers", form)
+}
+
+func (h *Handlers) obsEditFillersSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	media, err := h.mcr.ListMedia(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	form := editFillersForm{
+		Channel: ch,
+		Media:   media,
+	}
+
+	err = c.Bind(&form.Fields)
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "edit-fillers", form)
+	}
+
+	f := mcr.EditFillers{
+		Order:    form.Fields.Order,
+		MediaIDs: []int{},
+	}
+	for _, mediaID := range form.Fields.MediaIDs {
+		if mediaID != 0 {
+			f.MediaIDs = append(f.MediaIDs, mediaID)
+		}
+	}
+	err = h.mcr.SetChannelFillers(ctx, ch.ID, f)
+	if err == nil {
+		err = h.mcr.SetFillerAudio(ctx, ch.ID, mcr.AudioLevel{
+			Volume: form.Fields.Volume,
+			Gain:   form.Fields.Gain,
+			Muted:  form.Fields.Muted,
+		})
+	}
+	if err != nil {
+		form.Errors = append(form.Errors, err.Error())
+		form.Fields.MediaIDs = append(f.MediaIDs, make([]int, fillerSlots)...)
+		return c.Render(http.StatusBadRequest, "edit-fillers", form)
+	}
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/channels/%d", ch.ID))
+}
+
+type mediaLibrary struct {
+	Media  []mcr.Media
+	Errors []string
+}
+
+func (h *Handlers) obsListMedia(c echo.Context) error {
+	m, err := h.mcr.ListMedia(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.Render(http.StatusOK, "list-media", mediaLibrary{Media: m})
+}
+
+func (h *Handlers) obsUploadMediaSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	data := mediaLibrary{}
+	fh, err := c.FormFile("file")
+	if err == nil {
+		_, err = h.newMedia(ctx, fh, c.FormValue("title"))
+	}
+	if err != nil {
+		data.Errors = append(data.Errors, err.Error())
+		data.Media, err = h.mcr.ListMedia(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+		return c.Render(http.StatusBadRequest, "list-media", data)
+	}
+	return c.Redirect(http.StatusFound, "/media")
+}
+
+func (h *Handlers) obsDeleteMediaSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	mediaID, err := strconv.Atoi(c.Param("mediaID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = h.mcr.DeleteMedia(ctx, mediaID)
+	if err != nil {
+		data := mediaLibrary{Errors: []string{err.Error()}}
+		data.Media, err = h.mcr.ListMedia(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+		return c.Render(http.StatusBadRequest, "list-media", data)
+	}
+	return c.Redirect(http.StatusFound, "/media")
+}
+
 func (h *Handlers) obsListIntegrations(c echo.Context) error {
 	info, err := h.yt.About(c.Request().Context())
 	if err != nil {
@@ -1074,3 +1761,41 @@ func (h *Handlers) obsDeleteYouTubeIntegrationConfirm(c echo.Context) error {
 
 	return c.Render(http.StatusOK, "successful-unintegration", nil)
 }
+
+type listAsRun struct {
+	Channel mcr.Channel
+	Query   asRunQuery
+	Entries []mcr.AsRunEntry
+	Errors  []string
+}
+
+func (h *Handlers) obsListAsRun(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		err = fmt.Errorf("failed to get channel: %w", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	data := listAsRun{Channel: ch}
+	err = c.Bind(&data.Query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	if data.Query.From == "" && data.Query.To == "" {
+		data.Query.From = time.Now().Format(asRunDateFormat)
+	}
+	from, to, err := data.Query.times()
+	if err != nil {
+		data.Errors = append(data.Errors, err.Error())
+		return c.Render(http.StatusBadRequest, "list-as-run", data)
+	}
+	data.Entries, err = h.mcr.ListAsRun(ctx, ch.ID, from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.Render(http.StatusOK, "list-as-run", data)
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openAPISpec describes the API, it needs updating alongside the routes in
+// Start.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+func (h *Handlers) getOpenAPISpec(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/yaml", openAPISpec)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/showtime/mcr"
+)
+
+func (h *Handlers) listChannelOutputs(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	outputs, err := h.mcr.ListChannelOutputs(ctx, ch)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, outputs)
+}
+
+func (h *Handlers) newChannelOutput(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	o := mcr.EditChannelOutput{}
+	err = c.Bind(&o)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	outputID, err := h.mcr.NewChannelOutput(ctx, ch, o)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to create output: %w", err))
+	}
+	return c.JSON(http.StatusCreated, outputID)
+}
+
+func (h *Handlers) deleteChannelOutput(c echo.Context) error {
+	ctx := c.Request().Context()
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	outputID, err := strconv.Atoi(c.Param("outputID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	ch, err := h.mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	err = h.mcr.DeleteChannelOutput(ctx, ch, outputID)
+	if err != nil {
+		return apiError(fmt.Errorf("failed to delete output: %w", err))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
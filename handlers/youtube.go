@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -19,9 +20,13 @@ func (h *Handlers) listYouTubeBroadcasts(c echo.Context) error {
 	return c.JSON(http.StatusOK, broadcasts)
 }
 
+// enableYouTube links an existing YouTube broadcast to a livestream.
+//
+// The account the broadcast belongs to is given by the "accountID" query or
+// form value.
 func (h *Handlers) enableYouTube(c echo.Context) error {
 	ctx := c.Request().Context()
-	accountID, err := strconv.Atoi(c.Param("accountID"))
+	accountID, err := strconv.Atoi(c.FormValue("accountID"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
@@ -31,47 +36,29 @@ func (h *Handlers) enableYouTube(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
 
-	yt, err := h.yt.GetYouTuber(accountID)
+	_, err = h.ls.NewYTExistingLink(ctx, strmID, accountID, broadcastID)
 	if err != nil {
-		err = fmt.Errorf("failed to get youtuber: %w", err)
-		return echo.NewHTTPError(http.StatusNotFound, err)
-	}
-
-	err = yt.NewExistingBroadcast(ctx, broadcastID)
-	if err != nil {
-		err = fmt.Errorf("failed to create new existing broadcast: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
-	_, err = h.ls.NewLink(ctx, livestream.NewLinkParams{
-		LivestreamID:    strmID,
-		IntegrationType: livestream.LinkYTExisting,
-		IntegrationID:   broadcastID,
-	})
-	if err != nil {
-		err = fmt.Errorf("failed to create new link: %w", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		return apiError(fmt.Errorf("failed to link existing broadcast: %w", err))
 	}
 	return c.NoContent(http.StatusCreated)
 }
 
+// disableYouTube unlinks an existing YouTube broadcast from a livestream.
 func (h *Handlers) disableYouTube(c echo.Context) error {
 	ctx := c.Request().Context()
-	linkID, err := strconv.Atoi(c.Param("linkID"))
+	strmID, err := strconv.Atoi(c.Param("livestreamID"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
 
-	link, err := h.ls.GetLink(ctx, linkID)
+	link, err := h.ls.GetLinkByIntegration(ctx, strmID, livestream.LinkYTExisting, c.Param("broadcastID"))
 	if err != nil {
+		if errors.Is(err, livestream.ErrLinkNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
 		return fmt.Errorf("failed to get link: %w", err)
 	}
 
-	err = h.yt.DeleteExistingBroadcast(ctx, link.IntegrationID)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
-	}
-
 	err = h.ls.DeleteLink(ctx, link)
 	if err != nil {
 		return fmt.Errorf("failed to delete link: %w", err)
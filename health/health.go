@@ -0,0 +1,190 @@
+// Package health checks the dependencies ShowTime! needs to serve requests.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/db/migrations"
+	"github.com/ystv/showtime/ffmpeg"
+	"github.com/ystv/showtime/youtube"
+)
+
+type (
+	// Checker checks ShowTime!'s dependencies.
+	Checker struct {
+		conf  Config
+		db    *sqlx.DB
+		brave *brave.Braver
+		yt    *youtube.YouTube
+
+		// YouTube tokens are checked less often as it calls Google.
+		ytMu        sync.Mutex
+		ytChecks    []Check
+		ytCheckedAt time.Time
+	}
+	// Config to configure the checker.
+	Config struct {
+		// Timeout of each check, defaults to 5 seconds.
+		Timeout time.Duration
+		// YouTubeInterval is how long YouTube token checks are cached, defaults
+		// to 5 minutes.
+		YouTubeInterval time.Duration
+	}
+	// Status of a check.
+	Status string
+	// Check is the result of checking a dependency.
+	Check struct {
+		Name     string  `json:"name"`
+		Status   Status  `json:"status"`
+		Detail   string  `json:"detail,omitempty"`
+		Error    string  `json:"error,omitempty"`
+		Duration float64 `json:"durationSeconds"`
+	}
+	// Report is the result of checking all dependencies.
+	Report struct {
+		Status Status  `json:"status"`
+		Checks []Check `json:"checks"`
+	}
+)
+
+// Check statuses.
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// New creates a new dependency checker.
+func New(c Config, db *sqlx.DB, brave *brave.Braver, yt *youtube.YouTube) *Checker {
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.YouTubeInterval == 0 {
+		c.YouTubeInterval = 5 * time.Minute
+	}
+	return &Checker{
+		conf:  c,
+		db:    db,
+		brave: brave,
+		yt:    yt,
+	}
+}
+
+// Ready checks every dependency concurrently, the report's status is only ok
+// when every check is.
+func (h *Checker) Ready(ctx context.Context) Report {
+	checks := []func(context.Context) []Check{
+		h.single("database", h.checkDatabase),
+		h.single("migrations", h.checkMigrations),
+		h.single("brave", h.checkBrave),
+		h.single("ffmpeg", ffmpeg.Version),
+		h.checkYouTube,
+	}
+
+	results := make([][]Check, len(checks))
+	wg := sync.WaitGroup{}
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func(context.Context) []Check) {
+			defer wg.Done()
+			results[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	r := Report{Status: StatusOK, Checks: []Check{}}
+	for _, res := range results {
+		for _, c := range res {
+			if c.Status != StatusOK {
+				r.Status = StatusFail
+			}
+			r.Checks = append(r.Checks, c)
+		}
+	}
+	return r
+}
+
+// single wraps a check of a single dependency with the timeout, the detail
+// returned is included in the result.
+func (h *Checker) single(name string, check func(context.Context) (string, error)) func(context.Context) []Check {
+	return func(ctx context.Context) []Check {
+		ctx, cancel := context.WithTimeout(ctx, h.conf.Timeout)
+		defer cancel()
+		start := time.Now()
+		detail, err := check(ctx)
+		return []Check{newCheck(name, detail, err, time.Since(start))}
+	}
+}
+
+func newCheck(name, detail string, err error, d time.Duration) Check {
+	c := Check{
+		Name:     name,
+		Status:   StatusOK,
+		Detail:   detail,
+		Duration: d.Seconds(),
+	}
+	if err != nil {
+		c.Status = StatusFail
+		c.Error = err.Error()
+	}
+	return c
+}
+
+func (h *Checker) checkDatabase(ctx context.Context) (string, error) {
+	return "", h.db.PingContext(ctx)
+}
+
+func (h *Checker) checkMigrations(ctx context.Context) (string, error) {
+	upToDate, err := migrations.IsUpToDate(h.db.DB)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if migrations are up to date: %w", err)
+	}
+	if !upToDate {
+		return "", fmt.Errorf("database not up to date")
+	}
+	return "", nil
+}
+
+func (h *Checker) checkBrave(ctx context.Context) (string, error) {
+	return "", h.brave.Ping(ctx)
+}
+
+// checkYouTube checks the token of each YouTube account, results are reused
+// for the YouTube interval.
+func (h *Checker) checkYouTube(ctx context.Context) []Check {
+	h.ytMu.Lock()
+	defer h.ytMu.Unlock()
+	if h.ytChecks != nil && time.Since(h.ytCheckedAt) < h.conf.YouTubeInterval {
+		return h.ytChecks
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.conf.Timeout)
+	defer cancel()
+	start := time.Now()
+	errs, err := h.yt.CheckAccounts(ctx)
+	if err != nil {
+		// Not cached so it's retried on the next check.
+		return []Check{newCheck("youtube", "", err, time.Since(start))}
+	}
+
+	accountIDs := make([]int, 0, len(errs))
+	for accountID := range errs {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Ints(accountIDs)
+
+	checks := make([]Check, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		name := fmt.Sprintf("youtube-account-%d", accountID)
+		checks = append(checks, newCheck(name, "", errs[accountID], time.Since(start)))
+	}
+	h.ytChecks = checks
+	h.ytCheckedAt = time.Now()
+	return checks
+}
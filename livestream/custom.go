@@ -1,6 +1,10 @@
 package livestream
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"strings"
+)
 
 type (
 	// RTMPOutput is a simple send livestream to an RTMP endpoint.
@@ -31,6 +35,16 @@ func (ls *Livestreamer) GetRTMPOutput(ctx context.Context, rtmpOutputID int) (RT
 	return custom, err
 }
 
+// UpdateRTMPOutput changes the destination of a RTMP output.
+func (ls *Livestreamer) UpdateRTMPOutput(ctx context.Context, rtmpOutputID int, outputURL string) error {
+	_, err := ls.db.ExecContext(ctx, `
+		UPDATE rtmp_outputs SET
+			output_url = $1
+		WHERE rtmp_output_id = $2;
+	`, outputURL, rtmpOutputID)
+	return err
+}
+
 // DeleteRTMPOutput deletes a RTMP output by ID.
 func (ls *Livestreamer) DeleteRTMPOutput(ctx context.Context, rtmpOutputID int) error {
 	_, err := ls.db.ExecContext(ctx, `
@@ -39,3 +53,38 @@ func (ls *Livestreamer) DeleteRTMPOutput(ctx context.Context, rtmpOutputID int)
 	`, rtmpOutputID)
 	return err
 }
+
+// MaskedOutputURL returns the output URL with the stream key and any
+// credentials hidden so it is safe to display.
+//
+// RTMP services put the stream key in the last path segment, so only the
+// start of it is kept to help tell keys apart.
+func (o RTMPOutput) MaskedOutputURL() string {
+	u, err := url.Parse(o.OutputURL)
+	if err != nil {
+		return maskSecret(o.OutputURL)
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	u.RawQuery = ""
+	i := strings.LastIndex(u.Path, "/")
+	if i != -1 && i != len(u.Path)-1 {
+		u.Path = u.Path[:i+1] + maskSecret(u.Path[i+1:])
+	}
+	// Avoid the mask being escaped.
+	u.RawPath = ""
+	masked, err := url.PathUnescape(u.String())
+	if err != nil {
+		return u.String()
+	}
+	return masked
+}
+
+func maskSecret(s string) string {
+	const shown = 4
+	if len(s) <= shown {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:shown] + strings.Repeat("*", len(s)-shown)
+}
@@ -0,0 +1,124 @@
+package livestream
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type (
+	// LinkDetails is a link including information retrieved from its
+	// integration.
+	LinkDetails struct {
+		Link
+		MCR        *MCRLinkDetails        `json:"mcr,omitempty"`
+		YouTube    *YouTubeLinkDetails    `json:"youtube,omitempty"`
+		RTMPOutput *RTMPOutputLinkDetails `json:"rtmp,omitempty"`
+	}
+	// MCRLinkDetails describes the playout and the channel it is on.
+	MCRLinkDetails struct {
+		PlayoutID     int    `json:"playoutID"`
+		PlayoutStatus string `json:"playoutStatus"`
+		ChannelID     int    `json:"channelID"`
+		ChannelTitle  string `json:"channelTitle"`
+		ChannelStatus string `json:"channelStatus"`
+		OutputURL     string `json:"outputURL"`
+	}
+	// YouTubeLinkDetails describes the linked YouTube broadcast.
+	YouTubeLinkDetails struct {
+		BroadcastID    string `json:"broadcastID"`
+		AccountID      int    `json:"accountID"`
+		WatchURL       string `json:"watchURL"`
+		Title          string `json:"title"`
+		ScheduledStart string `json:"scheduledStart"`
+		ScheduledEnd   string `json:"scheduledEnd"`
+		Visibility     string `json:"visibility"`
+	}
+	// RTMPOutputLinkDetails describes the RTMP destination, the stream key is
+	// masked.
+	RTMPOutputLinkDetails struct {
+		RTMPOutputID int    `json:"rtmpOutputID"`
+		OutputURL    string `json:"outputURL"`
+	}
+)
+
+// GetLinkDetails retrieves the integration-specific details of a link.
+func (ls *Livestreamer) GetLinkDetails(ctx context.Context, link Link) (LinkDetails, error) {
+	d := LinkDetails{Link: link}
+
+	switch link.IntegrationType {
+	case LinkMCR:
+		playoutID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to convert integration id to playout id: %w", err)
+		}
+		po, err := ls.mcr.GetPlayout(ctx, playoutID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to get playout: %w", err)
+		}
+		ch, err := ls.mcr.GetChannel(ctx, po.ChannelID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to get channel: %w", err)
+		}
+		d.MCR = &MCRLinkDetails{
+			PlayoutID:     po.ID,
+			PlayoutStatus: po.Status,
+			ChannelID:     ch.ID,
+			ChannelTitle:  ch.Title,
+			ChannelStatus: ch.Status,
+			OutputURL:     ch.OutputURL,
+		}
+
+	case LinkYTNew, LinkYTExisting:
+		b, err := ls.yt.GetBroadcast(ctx, link.IntegrationID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to get broadcast: %w", err)
+		}
+		d.YouTube = &YouTubeLinkDetails{
+			BroadcastID:    b.ID,
+			AccountID:      b.AccountID,
+			WatchURL:       b.WatchURL(),
+			Title:          b.Title,
+			ScheduledStart: b.ScheduledStart,
+			ScheduledEnd:   b.ScheduledEnd,
+			Visibility:     b.Visibility,
+		}
+
+	case LinkRTMPOutput:
+		rtmpOutputID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to convert integration id to rtmp output id: %w", err)
+		}
+		rtmpOutput, err := ls.GetRTMPOutput(ctx, rtmpOutputID)
+		if err != nil {
+			return LinkDetails{}, fmt.Errorf("failed to get rtmp output: %w", err)
+		}
+		d.RTMPOutput = &RTMPOutputLinkDetails{
+			RTMPOutputID: rtmpOutput.ID,
+			OutputURL:    rtmpOutput.MaskedOutputURL(),
+		}
+
+	default:
+		return LinkDetails{}, ErrUnkownIntegrationType
+	}
+
+	return d, nil
+}
+
+// ListLinkDetails returns the links of a livestream including their
+// integration-specific details.
+func (ls *Livestreamer) ListLinkDetails(ctx context.Context, livestreamID int) ([]LinkDetails, error) {
+	links, err := ls.ListLinks(ctx, livestreamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	details := make([]LinkDetails, 0, len(links))
+	for _, link := range links {
+		d, err := ls.GetLinkDetails(ctx, link)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details of link %d: %w", link.ID, err)
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
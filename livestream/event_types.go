@@ -35,6 +35,9 @@ const (
 	EventStreamReceived EventType = "stream-received"
 	// EventStreamLost is when nginx reports it has stopped receiving a stream.
 	EventStreamLost EventType = "stream-lost"
+	// EventForwardingStopped is when ShowTime! stops forwarding a stream it is
+	// still receiving, such as when shutting down.
+	EventForwardingStopped EventType = "forwarding-stopped"
 	// EventError is when an error occurs while forwarding a stream.
 	EventError EventType = "error"
 )
@@ -60,6 +63,8 @@ func UnmarshalEventPayload(typ EventType, raw json.RawMessage) (EventPayload, er
 		data = &EventStreamReceivedPayload{}
 	case EventStreamLost:
 		data = &EventStreamLostPayload{}
+	case EventForwardingStopped:
+		data = &EventForwardingStoppedPayload{}
 	case EventError:
 		data = &EventErrorPayload{}
 	default:
@@ -101,6 +106,13 @@ type EventStreamLostPayload struct{}
 
 func (EventStreamLostPayload) isEventPayload() {}
 
+type EventForwardingStoppedPayload struct {
+	Reason  string `json:"reason"`
+	LinkIDs []int  `json:"linkIDs"`
+}
+
+func (EventForwardingStoppedPayload) isEventPayload() {}
+
 type EventErrorPayload struct {
 	Err     string `json:"err"`
 	Context string `json:"context"`
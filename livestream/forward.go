@@ -6,8 +6,6 @@ import (
 	"log"
 	"strconv"
 	"time"
-
-	"github.com/ystv/showtime/ffmpeg"
 )
 
 // Forward a livestream to it's linked platforms.
@@ -45,13 +43,13 @@ func (ls *Livestreamer) Forward(ctx context.Context, strm ConsumeLivestream) err
 			}()
 
 		case LinkYTNew:
-			err = ls.ytForward(ctx, strm.StreamKey, link.IntegrationID)
+			err = ls.ytForward(ctx, strm, link)
 			if err != nil {
 				return fmt.Errorf("failed to forward to yt-new: %w", err)
 			}
 
 		case LinkYTExisting:
-			err = ls.ytForward(ctx, strm.StreamKey, link.IntegrationID)
+			err = ls.ytForward(ctx, strm, link)
 			if err != nil {
 				return fmt.Errorf("failed to forward to yt-existing: %w", err)
 			}
@@ -69,9 +67,10 @@ func (ls *Livestreamer) Forward(ctx context.Context, strm ConsumeLivestream) err
 
 			srcURL := ls.ingestAddress + "/" + strm.StreamKey
 
+			link := link
 			go func() {
 				time.Sleep(1 * time.Second)
-				err = ffmpeg.NewForwardStream(context.Background(), srcURL, rtmpOutput.OutputURL)
+				err = ls.startForwarder(strm.ID, link, srcURL, rtmpOutput.OutputURL)
 				if err != nil {
 					log.Printf("failed to forward custom rtmp stream: %+v", err)
 					err = ls.CreateEvent(ctx, strm.ID, EventError, EventErrorPayload{
@@ -89,18 +88,18 @@ func (ls *Livestreamer) Forward(ctx context.Context, strm ConsumeLivestream) err
 	return nil
 }
 
-func (ls *Livestreamer) ytForward(ctx context.Context, streamKey string, broadcastID string) error {
-	b, err := ls.yt.GetBroadcast(ctx, broadcastID)
+func (ls *Livestreamer) ytForward(ctx context.Context, strm ConsumeLivestream, link Link) error {
+	b, err := ls.yt.GetBroadcast(ctx, link.IntegrationID)
 	if err != nil {
 		return fmt.Errorf("failed to get broadcast details: %w", err)
 	}
 
-	srcURL := ls.ingestAddress + "/" + streamKey
+	srcURL := ls.ingestAddress + "/" + strm.StreamKey
 	dstURL := b.IngestAddress + "/" + b.IngestKey
 
 	go func() {
 		time.Sleep(1 * time.Second)
-		err = ffmpeg.NewForwardStream(context.Background(), srcURL, dstURL)
+		err = ls.startForwarder(strm.ID, link, srcURL, dstURL)
 		if err != nil {
 			log.Printf("failed to forward youtube stream: %+v", err)
 		}
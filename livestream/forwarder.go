@@ -0,0 +1,158 @@
+package livestream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ystv/showtime/ffmpeg"
+	"github.com/ystv/showtime/metrics"
+)
+
+type (
+	// forwarders tracks the ffmpeg processes forwarding livestreams.
+	forwarders struct {
+		mu      sync.Mutex
+		running map[int]forwarder
+		// forwarded are links that have had a forwarder since ShowTime!
+		// started, starting another one counts as a restart.
+		forwarded map[int]bool
+		// closing once shutdown has started, no more forwarders are started.
+		closing bool
+	}
+	// forwarder is a running ffmpeg process forwarding a link.
+	forwarder struct {
+		livestreamID int
+		link         Link
+		p            *ffmpeg.Process
+	}
+)
+
+// ErrShuttingDown when a forwarder is started after shutdown has begun.
+var ErrShuttingDown = errors.New("shutting down")
+
+func newForwarders() *forwarders {
+	return &forwarders{
+		running:   map[int]forwarder{},
+		forwarded: map[int]bool{},
+	}
+}
+
+// startForwarder starts ffmpeg forwarding the source of a link to its
+// destination, recording an error event if it fails.
+func (ls *Livestreamer) startForwarder(strmID int, link Link, srcURL, dstURL string) error {
+	ls.forwarders.mu.Lock()
+	closing := ls.forwarders.closing
+	ls.forwarders.mu.Unlock()
+	if closing {
+		return ErrShuttingDown
+	}
+
+	p, err := ffmpeg.NewForwardStream(context.Background(), srcURL, dstURL)
+	if err != nil {
+		return err
+	}
+
+	typ := link.IntegrationType.String()
+	ls.forwarders.mu.Lock()
+	if ls.forwarders.closing {
+		// Shutdown started whilst ffmpeg was starting, it won't have seen it.
+		ls.forwarders.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = p.Stop(ctx)
+		return ErrShuttingDown
+	}
+	if ls.forwarders.forwarded[link.ID] {
+		metrics.FFmpegRestarts.WithLabelValues(typ).Inc()
+	}
+	ls.forwarders.forwarded[link.ID] = true
+	ls.forwarders.running[link.ID] = forwarder{
+		livestreamID: strmID,
+		link:         link,
+		p:            p,
+	}
+	ls.forwarders.mu.Unlock()
+	metrics.ForwardersActive.WithLabelValues(typ).Inc()
+
+	go func() {
+		err := p.Wait()
+
+		ls.forwarders.mu.Lock()
+		if ls.forwarders.running[link.ID].p == p {
+			delete(ls.forwarders.running, link.ID)
+		}
+		closing := ls.forwarders.closing
+		ls.forwarders.mu.Unlock()
+		metrics.ForwardersActive.WithLabelValues(typ).Dec()
+
+		if err == nil {
+			metrics.FFmpegExits.WithLabelValues(typ, "ok").Inc()
+			return
+		}
+		metrics.FFmpegExits.WithLabelValues(typ, "failed").Inc()
+		if closing {
+			// Stopped by shutdown, which records its own event.
+			return
+		}
+		log.Printf("forwarder for link %d exited: %v", link.ID, err)
+		err = ls.CreateEvent(context.Background(), strmID, EventError, EventErrorPayload{
+			Err:     fmt.Sprintf("forwarder exited: %v", err),
+			Context: "ffmpeg.NewForwardStream",
+		})
+		if err != nil {
+			log.Printf("failed to log error event: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops every forwarder, recording a forwarding stopped event on each
+// livestream that was being forwarded. Forwarders still running when the
+// context is done are killed. No forwarders can be started afterwards.
+func (ls *Livestreamer) Shutdown(ctx context.Context) error {
+	ls.forwarders.mu.Lock()
+	ls.forwarders.closing = true
+	running := make([]forwarder, 0, len(ls.forwarders.running))
+	for _, f := range ls.forwarders.running {
+		running = append(running, f)
+	}
+	ls.forwarders.mu.Unlock()
+
+	linkIDs := map[int][]int{}
+	wg := sync.WaitGroup{}
+	for _, f := range running {
+		linkIDs[f.livestreamID] = append(linkIDs[f.livestreamID], f.link.ID)
+		wg.Add(1)
+		go func(f forwarder) {
+			defer wg.Done()
+			err := f.p.Stop(ctx)
+			if err != nil {
+				log.Printf("failed to stop forwarder for link %d: %v", f.link.ID, err)
+			}
+		}(f)
+	}
+	wg.Wait()
+
+	// The events are still recorded when the forwarders had to be killed, so
+	// they get their own timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var errs []error
+	for strmID, ids := range linkIDs {
+		err := ls.CreateEvent(ctx, strmID, EventForwardingStopped, EventForwardingStoppedPayload{
+			Reason:  "ShowTime! shut down",
+			LinkIDs: ids,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create event for livestream %d: %w", strmID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to record stopped forwarders: %v", errs)
+	}
+	return nil
+}
@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 
 	petname "github.com/dustinkirkland/golang-petname"
+
+	"github.com/ystv/showtime/mcr"
 )
 
 // ErrStreamKeyNotFound when a given stream key is not found.
@@ -32,18 +35,52 @@ func (ls *Livestreamer) GetByStreamKey(ctx context.Context, streamKey string) (C
 	return strm, nil
 }
 
-// RefreshStreamKey rotates the stream key to a new randomly generated one.
-func (ls *Livestreamer) RefreshStreamKey(ctx context.Context, livestreamID string) error {
+// RefreshStreamKey rotates the stream key to a new randomly generated one,
+// returning the new key.
+func (ls *Livestreamer) RefreshStreamKey(ctx context.Context, livestreamID int) (string, error) {
+	streamKey := ls.generateStreamkey()
 	_, err := ls.db.ExecContext(ctx, `
 		UPDATE
 			livestreams SET
 				stream_key = $1
 		WHERE
-			livestream_id = $2;`, ls.generateStreamkey(), livestreamID)
+			livestream_id = $2;`, streamKey, livestreamID)
 	if err != nil {
-		return fmt.Errorf("failed to update stream key: %w", err)
+		return "", fmt.Errorf("failed to update stream key: %w", err)
+	}
+
+	// MCR playouts pull from the ingest using the stream key.
+	links, err := ls.ListLinks(ctx, livestreamID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list links: %w", err)
+	}
+	for _, link := range links {
+		if link.IntegrationType != LinkMCR {
+			continue
+		}
+		playoutID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse string to int: %w", err)
+		}
+		po, err := ls.mcr.GetPlayout(ctx, playoutID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get playout: %w", err)
+		}
+		err = ls.mcr.UpdatePlayout(ctx, po.ID, mcr.EditPlayout{
+			ChannelID:      po.ChannelID,
+			SrcURI:         ls.ingestAddress + "/" + streamKey,
+			Title:          po.Title,
+			Description:    po.Description,
+			ScheduledStart: po.ScheduledStart,
+			ScheduledEnd:   po.ScheduledEnd,
+			Visibility:     po.Visibility,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to update playout source: %w", err)
+		}
 	}
-	return nil
+
+	return streamKey, nil
 }
 
 func (ls *Livestreamer) generateStreamkey() string {
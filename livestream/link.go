@@ -2,20 +2,23 @@ package livestream
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"strconv"
 
 	"github.com/ystv/showtime/mcr"
+	"github.com/ystv/showtime/youtube"
 )
 
 type (
 	// Link is a relationship between a livestream and an integration.
 	Link struct {
-		ID              int             `db:"link_id"`
-		IntegrationType IntegrationType `db:"integration_type"`
-		IntegrationID   string          `db:"integration_id"`
+		ID              int             `db:"link_id" json:"linkID"`
+		LivestreamID    int             `db:"livestream_id" json:"livestreamID"`
+		IntegrationType IntegrationType `db:"integration_type" json:"integrationType"`
+		IntegrationID   string          `db:"integration_id" json:"integrationID"`
 	}
 	// NewLinkParams are params to create a new link.
 	NewLinkParams struct {
@@ -25,6 +28,34 @@ type (
 	}
 	// IntegrationType is a type of intergration with a platform.
 	IntegrationType string
+
+	// EditLink creates or updates a link to an integration. Only the
+	// parameters matching the integration type are used.
+	EditLink struct {
+		IntegrationType IntegrationType     `json:"integrationType"`
+		MCR             *EditMCRLink        `json:"mcr,omitempty"`
+		YTNew           *EditYTNewLink      `json:"ytNew,omitempty"`
+		YTExisting      *EditYTExistingLink `json:"ytExisting,omitempty"`
+		RTMPOutput      *EditRTMPOutputLink `json:"rtmp,omitempty"`
+	}
+	// EditMCRLink are parameters for a link to an MCR channel.
+	EditMCRLink struct {
+		ChannelID int `json:"channelID"`
+	}
+	// EditYTNewLink are parameters for a link to a new YouTube broadcast.
+	EditYTNewLink struct {
+		AccountID int `json:"accountID"`
+	}
+	// EditYTExistingLink are parameters for a link to an existing YouTube
+	// broadcast.
+	EditYTExistingLink struct {
+		AccountID   int    `json:"accountID"`
+		BroadcastID string `json:"broadcastID"`
+	}
+	// EditRTMPOutputLink are parameters for a link to a custom RTMP endpoint.
+	EditRTMPOutputLink struct {
+		OutputURL string `json:"outputURL"`
+	}
 )
 
 const (
@@ -41,12 +72,193 @@ const (
 var (
 	// ErrUnkownIntegrationType when the integration type is unknown.
 	ErrUnkownIntegrationType = errors.New("unknown integration type")
+	// ErrLinkNotFound when a link cannot be found.
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrLinkParamsMissing when the parameters for the integration type are not given.
+	ErrLinkParamsMissing = errors.New("link parameters missing for integration type")
+	// ErrLinkNotUpdatable when the integration type of a link cannot be changed after creation.
+	ErrLinkNotUpdatable = errors.New("link cannot be updated for integration type")
 )
 
 func (i IntegrationType) String() string {
 	return string(i)
 }
 
+// LinkIntegration creates the resource on the integration described by the
+// parameters and links it to the livestream.
+func (ls *Livestreamer) LinkIntegration(ctx context.Context, strm Livestream, p EditLink) (Link, error) {
+	switch p.IntegrationType {
+	case LinkMCR:
+		if p.MCR == nil {
+			return Link{}, ErrLinkParamsMissing
+		}
+		return ls.NewMCRLink(ctx, strm, p.MCR.ChannelID)
+
+	case LinkYTNew:
+		if p.YTNew == nil {
+			return Link{}, ErrLinkParamsMissing
+		}
+		return ls.NewYTNewLink(ctx, strm, p.YTNew.AccountID)
+
+	case LinkYTExisting:
+		if p.YTExisting == nil || p.YTExisting.BroadcastID == "" {
+			return Link{}, ErrLinkParamsMissing
+		}
+		return ls.NewYTExistingLink(ctx, strm.ID, p.YTExisting.AccountID, p.YTExisting.BroadcastID)
+
+	case LinkRTMPOutput:
+		if p.RTMPOutput == nil || p.RTMPOutput.OutputURL == "" {
+			return Link{}, ErrLinkParamsMissing
+		}
+		return ls.NewRTMPOutputLink(ctx, strm.ID, p.RTMPOutput.OutputURL)
+
+	default:
+		return Link{}, ErrUnkownIntegrationType
+	}
+}
+
+// NewMCRLink creates a playout of the livestream on an MCR channel and links
+// it.
+func (ls *Livestreamer) NewMCRLink(ctx context.Context, strm Livestream, channelID int) (Link, error) {
+	playoutID, err := ls.mcr.NewPlayout(ctx, mcr.EditPlayout{
+		ChannelID:      channelID,
+		SrcType:        mcr.SourceLivestream,
+		SrcURI:         ls.ingestAddress + "/" + strm.StreamKey,
+		Title:          strm.Title,
+		Description:    strm.Description,
+		ScheduledStart: strm.ScheduledStart,
+		ScheduledEnd:   strm.ScheduledEnd,
+		Visibility:     strm.Visibility,
+	})
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to create new playout: %w", err)
+	}
+
+	return ls.NewLink(ctx, NewLinkParams{
+		LivestreamID:    strm.ID,
+		IntegrationType: LinkMCR,
+		IntegrationID:   strconv.Itoa(playoutID),
+	})
+}
+
+// NewYTNewLink creates a new YouTube broadcast of the livestream and links
+// it.
+func (ls *Livestreamer) NewYTNewLink(ctx context.Context, strm Livestream, accountID int) (Link, error) {
+	yt, err := ls.yt.GetYouTuber(accountID)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to get youtuber: %w", err)
+	}
+
+	b, err := yt.NewBroadcast(ctx, youtube.EditBroadcast{
+		Title:          strm.Title,
+		Description:    strm.Description,
+		ScheduledStart: strm.ScheduledStart,
+		ScheduledEnd:   strm.ScheduledEnd,
+		Visibility:     strm.Visibility,
+	})
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to create new broadcast: %w", err)
+	}
+
+	return ls.NewLink(ctx, NewLinkParams{
+		LivestreamID:    strm.ID,
+		IntegrationType: LinkYTNew,
+		IntegrationID:   b.ID,
+	})
+}
+
+// NewYTExistingLink enables ShowTime! integration on an existing YouTube
+// broadcast and links it.
+func (ls *Livestreamer) NewYTExistingLink(ctx context.Context, livestreamID int, accountID int, broadcastID string) (Link, error) {
+	yt, err := ls.yt.GetYouTuber(accountID)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to get youtuber: %w", err)
+	}
+
+	err = yt.NewExistingBroadcast(ctx, broadcastID)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to create new existing broadcast: %w", err)
+	}
+
+	return ls.NewLink(ctx, NewLinkParams{
+		LivestreamID:    livestreamID,
+		IntegrationType: LinkYTExisting,
+		IntegrationID:   broadcastID,
+	})
+}
+
+// NewRTMPOutputLink creates a custom RTMP output and links it.
+func (ls *Livestreamer) NewRTMPOutputLink(ctx context.Context, livestreamID int, outputURL string) (Link, error) {
+	rtmpOutput, err := ls.NewRTMPOutput(ctx, outputURL)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to create new rtmp output: %w", err)
+	}
+
+	return ls.NewLink(ctx, NewLinkParams{
+		LivestreamID:    livestreamID,
+		IntegrationType: LinkRTMPOutput,
+		IntegrationID:   strconv.Itoa(rtmpOutput.ID),
+	})
+}
+
+// UpdateLink changes the integration-specific parameters of a link.
+//
+// Only MCR links (moving the playout to another channel) and RTMP links
+// (changing the destination) can be updated, YouTube links need to be
+// re-created.
+func (ls *Livestreamer) UpdateLink(ctx context.Context, link Link, p EditLink) error {
+	if p.IntegrationType != "" && p.IntegrationType != link.IntegrationType {
+		return ErrLinkNotUpdatable
+	}
+
+	switch link.IntegrationType {
+	case LinkMCR:
+		if p.MCR == nil {
+			return ErrLinkParamsMissing
+		}
+		playoutID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return fmt.Errorf("failed to convert integration id to playout id: %w", err)
+		}
+		po, err := ls.mcr.GetPlayout(ctx, playoutID)
+		if err != nil {
+			return fmt.Errorf("failed to get playout: %w", err)
+		}
+		err = ls.mcr.UpdatePlayout(ctx, po.ID, mcr.EditPlayout{
+			ChannelID:      p.MCR.ChannelID,
+			SrcURI:         po.SrcURI,
+			Title:          po.Title,
+			Description:    po.Description,
+			ScheduledStart: po.ScheduledStart,
+			ScheduledEnd:   po.ScheduledEnd,
+			Visibility:     po.Visibility,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update playout: %w", err)
+		}
+
+	case LinkRTMPOutput:
+		if p.RTMPOutput == nil || p.RTMPOutput.OutputURL == "" {
+			return ErrLinkParamsMissing
+		}
+		rtmpOutputID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return fmt.Errorf("failed to convert integration id to rtmp output id: %w", err)
+		}
+		err = ls.UpdateRTMPOutput(ctx, rtmpOutputID, p.RTMPOutput.OutputURL)
+		if err != nil {
+			return fmt.Errorf("failed to update rtmp output: %w", err)
+		}
+
+	case LinkYTNew, LinkYTExisting:
+		return ErrLinkNotUpdatable
+
+	default:
+		return ErrUnkownIntegrationType
+	}
+	return nil
+}
+
 // NewLink creates a new relationship between a
 func (ls *Livestreamer) NewLink(ctx context.Context, l NewLinkParams) (Link, error) {
 	linkID := 0
@@ -64,27 +276,59 @@ func (ls *Livestreamer) NewLink(ctx context.Context, l NewLinkParams) (Link, err
 	}); err != nil {
 		log.Printf("failed to log link event: %v", err)
 	}
-	return Link{ID: linkID, IntegrationType: l.IntegrationType, IntegrationID: l.IntegrationID}, nil
+	return Link{
+		ID:              linkID,
+		LivestreamID:    l.LivestreamID,
+		IntegrationType: l.IntegrationType,
+		IntegrationID:   l.IntegrationID,
+	}, nil
 }
 
 // GetLink returns a single link.
 func (ls *Livestreamer) GetLink(ctx context.Context, linkID int) (Link, error) {
 	link := Link{}
 	err := ls.db.GetContext(ctx, &link, `
-		SELECT link_id, integration_type, integration_id
+		SELECT link_id, livestream_id, integration_type, integration_id
 		FROM links
 		WHERE link_id = $1;
 	`, linkID)
-	return link, err
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Link{}, fmt.Errorf("%w: %v", ErrLinkNotFound, err)
+		}
+		return Link{}, fmt.Errorf("failed to get link: %w", err)
+	}
+	return link, nil
+}
+
+// GetLinkByIntegration returns the link of a livestream to a specific
+// integration.
+func (ls *Livestreamer) GetLinkByIntegration(ctx context.Context, livestreamID int, integrationType IntegrationType, integrationID string) (Link, error) {
+	link := Link{}
+	err := ls.db.GetContext(ctx, &link, `
+		SELECT link_id, livestream_id, integration_type, integration_id
+		FROM links
+		WHERE livestream_id = $1
+		AND integration_type = $2
+		AND integration_id = $3;
+	`, livestreamID, integrationType, integrationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Link{}, fmt.Errorf("%w: %v", ErrLinkNotFound, err)
+		}
+		return Link{}, fmt.Errorf("failed to get link: %w", err)
+	}
+	return link, nil
 }
 
 // ListLinks returns a list of links for a given livestream.
 func (ls *Livestreamer) ListLinks(ctx context.Context, livestreamID int) ([]Link, error) {
 	links := []Link{}
 	err := ls.db.SelectContext(ctx, &links, `
-		SELECT link_id, integration_type, integration_id
+		SELECT link_id, livestream_id, integration_type, integration_id
 		FROM links
-		WHERE livestream_id = $1;
+		WHERE livestream_id = $1
+		ORDER BY link_id;
 	`, livestreamID)
 	return links, err
 }
@@ -150,3 +394,31 @@ func (ls *Livestreamer) DeleteLink(ctx context.Context, link Link) error {
 	}
 	return nil
 }
+
+// mcrProgramLost records an error event on the livestream linked to a playout
+// that MCR had to cut away from.
+func (ls *Livestreamer) mcrProgramLost(ctx context.Context, ch mcr.Channel, po mcr.Playout, reason string) {
+	if po.ID == 0 {
+		return
+	}
+	link := Link{}
+	err := ls.db.GetContext(ctx, &link, `
+		SELECT link_id, livestream_id, integration_type, integration_id
+		FROM links
+		WHERE integration_type = $1
+		AND integration_id = $2;
+	`, LinkMCR, strconv.Itoa(po.ID))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("failed to get link of playout %d: %v", po.ID, err)
+		}
+		return
+	}
+	err = ls.CreateEvent(ctx, link.LivestreamID, EventError, EventErrorPayload{
+		Err:     fmt.Sprintf("%s on channel %q", reason, ch.Title),
+		Context: "mcr",
+	})
+	if err != nil {
+		log.Printf("failed to log error event: %v", err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/jmoiron/sqlx/types"
 
 	"github.com/ystv/showtime/mcr"
+	"github.com/ystv/showtime/metrics"
 	"github.com/ystv/showtime/youtube"
 )
 
@@ -28,6 +29,7 @@ type (
 		db            *sqlx.DB
 		mcr           *mcr.MCR
 		yt            *youtube.YouTube
+		forwarders    *forwarders
 	}
 	// EditLivestream are parameters required to create or update a livestream.
 	EditLivestream struct {
@@ -37,6 +39,9 @@ type (
 		ScheduledEnd   time.Time `json:"scheduledEnd" form:"scheduledEnd"`
 		Visibility     string    `json:"visbility" form:"visibility"`
 		Thumbnail      string    `json:"thumbnail" form:"thumbnail"`
+		// AllowConflict moves the livestream's playouts even when they
+		// overlap others on their channels.
+		AllowConflict bool `json:"allowConflict" form:"allowConflict"`
 	}
 	// Livestream is the metadata of a stream and the links to external
 	// platforms.
@@ -59,12 +64,15 @@ type (
 
 // New creates an instance of livestreamer.
 func New(c Config, db *sqlx.DB, mcr *mcr.MCR, yt *youtube.YouTube) *Livestreamer {
-	return &Livestreamer{
+	ls := &Livestreamer{
 		ingestAddress: c.IngestAddress,
 		db:            db,
 		mcr:           mcr,
 		yt:            yt,
+		forwarders:    newForwarders(),
 	}
+	mcr.OnProgramLost(ls.mcrProgramLost)
+	return ls
 }
 
 var (
@@ -172,7 +180,43 @@ func (ls *Livestreamer) Update(ctx context.Context, livestreamID int, strm EditL
 		return ErrStartInPast
 	}
 
-	_, err := ls.db.ExecContext(ctx, `
+	links, err := ls.ListLinks(ctx, livestreamID)
+	if err != nil {
+		return fmt.Errorf("failed to list links: %w", err)
+	}
+
+	// Playouts are checked before anything changes, so a clash with a
+	// channel's schedule doesn't leave the livestream half updated. They are
+	// checked again as they're updated, a clash scheduled in between still
+	// leaves it half updated.
+	for _, link := range links {
+		if link.IntegrationType != LinkMCR {
+			continue
+		}
+		playoutID, err := strconv.Atoi(link.IntegrationID)
+		if err != nil {
+			return fmt.Errorf("failed to parse string to int: %w", err)
+		}
+		po, err := ls.mcr.GetPlayout(ctx, playoutID)
+		if err != nil {
+			return fmt.Errorf("failed to get playout: %w", err)
+		}
+		if po.ScheduledStart.Equal(strm.ScheduledStart) && po.ScheduledEnd.Equal(strm.ScheduledEnd) {
+			continue
+		}
+		err = ls.mcr.CheckSchedule(ctx, po.ID, mcr.EditPlayout{
+			ChannelID:      po.ChannelID,
+			Title:          strm.Title,
+			ScheduledStart: strm.ScheduledStart,
+			ScheduledEnd:   strm.ScheduledEnd,
+			AllowConflict:  strm.AllowConflict,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to schedule playout: %w", err)
+		}
+	}
+
+	_, err = ls.db.ExecContext(ctx, `
 		UPDATE livestreams SET
 			title = $1,
 			description = $2,
@@ -185,11 +229,6 @@ func (ls *Livestreamer) Update(ctx context.Context, livestreamID int, strm EditL
 		return fmt.Errorf("failed to update livestream: %w", err)
 	}
 
-	links, err := ls.ListLinks(ctx, livestreamID)
-	if err != nil {
-		return fmt.Errorf("failed to list links: %w", err)
-	}
-
 	for _, link := range links {
 		switch link.IntegrationType {
 		case LinkMCR:
@@ -204,6 +243,7 @@ func (ls *Livestreamer) Update(ctx context.Context, livestreamID int, strm EditL
 				ScheduledStart: strm.ScheduledStart,
 				ScheduledEnd:   strm.ScheduledEnd,
 				Visibility:     strm.Visibility,
+				AllowConflict:  strm.AllowConflict,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to update playout: %w", err)
@@ -317,9 +357,30 @@ func (ls *Livestreamer) CreateEvent(ctx context.Context, strmID int, typ EventTy
 	if err != nil {
 		return fmt.Errorf("failed to insert event: %w", err)
 	}
+	metrics.LivestreamEvents.WithLabelValues(string(typ)).Inc()
 	return nil
 }
 
+// CountByStatus counts livestreams by their status.
+func (ls *Livestreamer) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows := []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}{}
+	err := ls.db.SelectContext(ctx, &rows, `
+		SELECT status, COUNT(*) AS count
+		FROM livestreams
+		GROUP BY status;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count livestreams: %w", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
 // PrettyDateTime formats dates to a more readable string.
 func (strm Livestream) PrettyDateTime(ts time.Time) string {
 	if ts.After(time.Now().Add(time.Hour * 24)) {
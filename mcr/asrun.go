@@ -0,0 +1,178 @@
+package mcr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type (
+	// AsRunType is the value of an as_run record's event_type field. This
+	// type must stay in sync with the check in the database.
+	AsRunType string
+	// AsRunEntry is a record of something that happened on a channel, when
+	// it actually happened.
+	AsRunEntry struct {
+		ID        int       `db:"as_run_id" json:"asRunID"`
+		ChannelID int       `db:"channel_id" json:"channelID"`
+		Time      time.Time `db:"event_time" json:"time"`
+		Type      AsRunType `db:"event_type" json:"type"`
+		// Program is what a cut went to, playout, continuity or filler.
+		Program string `db:"program" json:"program,omitempty"`
+		// InputID is the Brave input cut to or of the playout, 0 when there
+		// isn't one.
+		InputID      int    `db:"input_id" json:"inputID,omitempty"`
+		PlayoutID    int    `db:"playout_id" json:"playoutID,omitempty"`
+		PlayoutTitle string `db:"playout_title" json:"playoutTitle,omitempty"`
+		// Scheduled is when the playout was scheduled to start or end, nil
+		// for entries that weren't scheduled.
+		Scheduled *time.Time `db:"scheduled_time" json:"scheduled,omitempty"`
+		// Deviation is how late the entry was compared to Scheduled, negative
+		// when it was early.
+		Deviation time.Duration `db:"-" json:"deviation"`
+		// Source is the operator or automation that made it happen, see
+		// WithAsRunSource.
+		Source string `db:"source" json:"source"`
+		// Detail is why it happened.
+		Detail string `db:"detail" json:"detail,omitempty"`
+	}
+)
+
+// As-run entry types.
+const (
+	AsRunCut          AsRunType = "cut"
+	AsRunOnAir        AsRunType = "on-air"
+	AsRunOffAir       AsRunType = "off-air"
+	AsRunPlayoutStart AsRunType = "playout-start"
+	AsRunPlayoutEnd   AsRunType = "playout-end"
+)
+
+// What a channel's program is cut to.
+const (
+	ProgramPlayout    = "playout"
+	ProgramContinuity = "continuity"
+	ProgramFiller     = "filler"
+)
+
+// Automation as-run sources.
+const (
+	AsRunSourceScheduler    = "scheduler"
+	AsRunSourceReconciler   = "reconciler"
+	AsRunSourceCardRenderer = "card-renderer"
+)
+
+// asRunSourceKey is the context key of the as-run source.
+type asRunSourceKey struct{}
+
+// WithAsRunSource returns a context that records changes made to channels
+// with it as made by source, an operator or automation.
+func WithAsRunSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, asRunSourceKey{}, source)
+}
+
+// asRunSource returns who or what a context is making changes for.
+func asRunSource(ctx context.Context) string {
+	source, ok := ctx.Value(asRunSourceKey{}).(string)
+	if !ok || source == "" {
+		return "unknown"
+	}
+	return source
+}
+
+// ListAsRun lists a channel's as-run log between two times, oldest first. A
+// zero time leaves that end open.
+func (mcr *MCR) ListAsRun(ctx context.Context, channelID int, from, to time.Time) ([]AsRunEntry, error) {
+	fromArg := sql.NullTime{Time: from, Valid: !from.IsZero()}
+	toArg := sql.NullTime{Time: to, Valid: !to.IsZero()}
+
+	entries := []AsRunEntry{}
+	err := mcr.db.SelectContext(ctx, &entries, `
+		SELECT as_run_id, channel_id, event_time, event_type, program, input_id,
+					 playout_id, playout_title, scheduled_time, source, detail
+		FROM mcr.as_run
+		WHERE channel_id = $1
+		AND ($2::timestamptz IS NULL OR event_time >= $2)
+		AND ($3::timestamptz IS NULL OR event_time < $3)
+		ORDER BY event_time ASC, as_run_id ASC;`, channelID, fromArg, toArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list as-run: %w", err)
+	}
+	for i := range entries {
+		if entries[i].Scheduled != nil {
+			entries[i].Deviation = entries[i].Time.Sub(*entries[i].Scheduled)
+		}
+	}
+	return entries, nil
+}
+
+// recordAsRun adds an entry to a channel's as-run log, made by the context's
+// source. Failing to is only logged, it doesn't stop the channel changing.
+func (mcr *MCR) recordAsRun(ctx context.Context, e AsRunEntry) {
+	_, err := mcr.db.ExecContext(ctx, `
+		INSERT INTO mcr.as_run (
+			channel_id, event_type, program, input_id, playout_id, playout_title,
+			scheduled_time, source, detail
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		e.ChannelID, e.Type, e.Program, e.InputID, e.PlayoutID, e.PlayoutTitle,
+		e.Scheduled, asRunSource(ctx), e.Detail)
+	if err != nil {
+		log.Printf("failed to record channel %d as-run %s: %v", e.ChannelID, e.Type, err)
+	}
+}
+
+// recordPlayoutAsRun adds a playout's start or end to its channel's as-run
+// log.
+func (mcr *MCR) recordPlayoutAsRun(ctx context.Context, typ AsRunType, po Playout, scheduled time.Time, detail string) {
+	mcr.recordAsRun(ctx, AsRunEntry{
+		ChannelID:    po.ChannelID,
+		Type:         typ,
+		InputID:      po.BraveInputID,
+		PlayoutID:    po.ID,
+		PlayoutTitle: po.Title,
+		Scheduled:    &scheduled,
+		Detail:       detail,
+	})
+}
+
+// recordCutAsRun adds a cut to a channel's as-run log, working out what was
+// cut to from the input.
+func (mcr *MCR) recordCutAsRun(ctx context.Context, channelID, inputID int, detail string) {
+	e := AsRunEntry{
+		ChannelID: channelID,
+		Type:      AsRunCut,
+		Program:   ProgramContinuity,
+		InputID:   inputID,
+		Detail:    detail,
+	}
+	po := Playout{}
+	err := mcr.db.GetContext(ctx, &po, `
+		SELECT playout_id, title, scheduled_start
+		FROM mcr.playouts
+		WHERE channel_id = $1
+		AND brave_input_id = $2
+		AND status IN ('scheduled', 'live');`, channelID, inputID)
+	switch {
+	case err == nil:
+		e.Program = ProgramPlayout
+		e.PlayoutID = po.ID
+		e.PlayoutTitle = po.Title
+		e.Scheduled = &po.ScheduledStart
+	case errors.Is(err, sql.ErrNoRows):
+		fillerInputID := 0
+		err = mcr.db.GetContext(ctx, &fillerInputID, `
+			SELECT filler_input_id
+			FROM mcr.channels
+			WHERE channel_id = $1;`, channelID)
+		if err == nil && inputID != 0 && fillerInputID == inputID {
+			e.Program = ProgramFiller
+		}
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("failed to get channel %d program for as-run: %v", channelID, err)
+	}
+	mcr.recordAsRun(ctx, e)
+}
@@ -0,0 +1,242 @@
+package mcr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Decoded for uploads.
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path"
+
+	"golang.org/x/image/draw"
+)
+
+// ChannelAssets are the images a channel's continuity card is made from, the
+// URIs are empty when they haven't been uploaded.
+type ChannelAssets struct {
+	BackgroundURI string `json:"backgroundURI"`
+	LogoURI       string `json:"logoURI"`
+	// SlateURI is the holding slate, shown instead of the continuity card
+	// when it can't be drawn.
+	SlateURI string `json:"slateURI"`
+}
+
+// Channel asset types.
+const (
+	AssetBackground = "background"
+	AssetLogo       = "logo"
+	AssetSlate      = "slate"
+)
+
+const (
+	// channelAssetsDir is where channel assets and continuity cards are
+	// stored, it is served under baseServeURL.
+	channelAssetsDir = "assets/ch"
+	// maxAssetBytes is the largest asset that can be uploaded.
+	maxAssetBytes = 20 << 20
+	// maxAssetPixels is the largest asset resolution that will be decoded.
+	maxAssetPixels = 8192 * 8192
+)
+
+var (
+	// ErrAssetTypeInvalid when an asset isn't a background, logo or slate.
+	ErrAssetTypeInvalid = errors.New("asset type is invalid")
+	// ErrAssetNotImage when an uploaded asset isn't a JPEG, PNG or GIF image.
+	ErrAssetNotImage = errors.New("asset is not an image")
+	// ErrAssetTooLarge when an uploaded asset's file or resolution is too
+	// large.
+	ErrAssetTooLarge = errors.New("asset is too large")
+)
+
+// GetChannelAssets returns which assets a channel has.
+func (mcr *MCR) GetChannelAssets(ctx context.Context, channelID int) (ChannelAssets, error) {
+	ch, err := mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return ChannelAssets{}, err
+	}
+	a := ChannelAssets{}
+	for asset, uri := range map[string]*string{
+		AssetBackground: &a.BackgroundURI,
+		AssetLogo:       &a.LogoURI,
+		AssetSlate:      &a.SlateURI,
+	} {
+		*uri, err = mcr.assetURI(ch.ID, asset)
+		if err != nil {
+			return ChannelAssets{}, err
+		}
+	}
+	return a, nil
+}
+
+// assetURI is where a channel's asset is served, empty when it hasn't been
+// uploaded. It is versioned so a replaced asset isn't cached.
+func (mcr *MCR) assetURI(channelID int, asset string) (string, error) {
+	p, err := assetPath(channelID, asset)
+	if err != nil {
+		return "", err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to stat %s: %w", asset, err)
+	}
+	return mcr.baseServeURL.ResolveReference(&url.URL{
+		Path:     p,
+		RawQuery: fmt.Sprintf("v=%d", fi.ModTime().Unix()),
+	}).String(), nil
+}
+
+// SetChannelAsset uploads or replaces a channel's asset, asking for its
+// continuity card to be refreshed. Backgrounds and slates are
+// resized to fill the channel, logos are only shrunk to fit it.
+func (mcr *MCR) SetChannelAsset(ctx context.Context, channelID int, asset string, r io.Reader) error {
+	p, err := assetPath(channelID, asset)
+	if err != nil {
+		return err
+	}
+	ch, err := mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, maxAssetBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read asset: %w", err)
+	}
+	if len(b) > maxAssetBytes {
+		return ErrAssetTooLarge
+	}
+	conf, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAssetNotImage, err)
+	}
+	if conf.Width*conf.Height > maxAssetPixels {
+		return ErrAssetTooLarge
+	}
+	im, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAssetNotImage, err)
+	}
+
+	// Logos keep their transparency.
+	if asset == AssetLogo {
+		err = writeAsset(p, func(w io.Writer) error {
+			return png.Encode(w, containImage(im, ch.Width, ch.Height))
+		})
+	} else {
+		err = writeAsset(p, func(w io.Writer) error {
+			return jpeg.Encode(w, coverImage(im, ch.Width, ch.Height), &jpeg.Options{Quality: 90})
+		})
+	}
+	if err != nil {
+		return err
+	}
+	mcr.requestCardRefresh(ch.ID)
+	return nil
+}
+
+// DeleteChannelAsset removes a channel's asset, asking for its continuity card
+// to be refreshed.
+func (mcr *MCR) DeleteChannelAsset(ctx context.Context, channelID int, asset string) error {
+	p, err := assetPath(channelID, asset)
+	if err != nil {
+		return err
+	}
+	ch, err := mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete asset: %w", err)
+	}
+	mcr.requestCardRefresh(ch.ID)
+	return nil
+}
+
+// deleteChannelAssets removes all of a channel's assets.
+func deleteChannelAssets(channelID int) error {
+	for _, asset := range []string{AssetBackground, AssetLogo, AssetSlate} {
+		p, _ := assetPath(channelID, asset)
+		err := os.Remove(p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", asset, err)
+		}
+	}
+	return nil
+}
+
+// assetPath is where a channel's asset is stored.
+func assetPath(channelID int, asset string) (string, error) {
+	switch asset {
+	case AssetBackground:
+		return cardBackgroundPath(channelID), nil
+	case AssetLogo:
+		return cardLogoPath(channelID), nil
+	case AssetSlate:
+		return path.Join(channelAssetsDir, fmt.Sprintf("%d-slate.jpg", channelID)), nil
+	}
+	return "", ErrAssetTypeInvalid
+}
+
+// writeAsset writes an asset to a temporary file and moves it into place, so
+// the card is never drawn from half an asset.
+func writeAsset(p string, encode func(io.Writer) error) error {
+	err := os.MkdirAll(channelAssetsDir, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create assets directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(channelAssetsDir, "upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = encode(tmp)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode asset: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write asset: %w", err)
+	}
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		return fmt.Errorf("failed to store asset: %w", err)
+	}
+	return nil
+}
+
+// coverImage scales an image to cover w×h, cropping what overhangs evenly.
+func coverImage(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	scale := math.Max(float64(w)/float64(b.Dx()), float64(h)/float64(b.Dy()))
+	sw, sh := int(float64(w)/scale), int(float64(h)/scale)
+	x0, y0 := b.Min.X+(b.Dx()-sw)/2, b.Min.Y+(b.Dy()-sh)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, image.Rect(x0, y0, x0+sw, y0+sh), draw.Src, nil)
+	return dst
+}
+
+// containImage shrinks an image to fit within w×h, smaller ones are left
+// alone.
+func containImage(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	scale := math.Min(float64(w)/float64(b.Dx()), float64(h)/float64(b.Dy()))
+	if scale >= 1 {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, int(float64(b.Dx())*scale), int(float64(b.Dy())*scale)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
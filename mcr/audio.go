@@ -0,0 +1,118 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ystv/showtime/brave"
+)
+
+// AudioLevel is how loud a source is played, it can be changed whilst the
+// source is live.
+type AudioLevel struct {
+	// Volume is from 0 to 1.
+	Volume float64 `db:"volume" json:"volume"`
+	// Gain is in dB from -20 to 0, applied on top of the volume. Brave can't
+	// play a source louder than it is, so it can't be boosted.
+	Gain  float64 `db:"gain" json:"gain"`
+	Muted bool    `db:"muted" json:"muted"`
+}
+
+const (
+	minGain = -20
+	maxGain = 0
+)
+
+// ErrAudioLevelInvalid when a volume isn't between 0 and 1, or a gain isn't
+// between -20 and 0dB.
+var ErrAudioLevelInvalid = errors.New("audio level is invalid")
+
+// DefaultAudioLevel plays a source as it is.
+func DefaultAudioLevel() AudioLevel {
+	return AudioLevel{Volume: 1}
+}
+
+func (a AudioLevel) validate() error {
+	if a.Volume < 0 || a.Volume > 1 || a.Gain < minGain || a.Gain > maxGain {
+		return ErrAudioLevelInvalid
+	}
+	return nil
+}
+
+// braveVolume is the volume Brave plays a source at.
+func (a AudioLevel) braveVolume() float64 {
+	if a.Muted {
+		return 0
+	}
+	return a.Volume * math.Pow(10, a.Gain/20)
+}
+
+// SetPlayoutAudio sets a playout's audio level, straight away when its source
+// is in Brave.
+func (mcr *MCR) SetPlayoutAudio(ctx context.Context, playoutID int, a AudioLevel) error {
+	err := a.validate()
+	if err != nil {
+		return err
+	}
+	po, err := mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return err
+	}
+	// So a transition doesn't fade the source over the new level.
+	unlock := mcr.lockChannel(po.ChannelID)
+	defer unlock()
+	// Its input may have changed whilst waiting.
+	po, err = mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return err
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.playouts SET
+			volume = $1,
+			gain = $2,
+			muted = $3
+		WHERE playout_id = $4;`, a.Volume, a.Gain, a.Muted, po.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update audio level: %w", err)
+	}
+	return mcr.setInputAudio(ctx, po.BraveInputID, a)
+}
+
+// SetFillerAudio sets the audio level of a channel's fillers, straight away
+// when one is playing.
+func (mcr *MCR) SetFillerAudio(ctx context.Context, channelID int, a AudioLevel) error {
+	err := a.validate()
+	if err != nil {
+		return err
+	}
+	unlock := mcr.lockChannel(channelID)
+	defer unlock()
+
+	fillerInputID := 0
+	err = mcr.db.GetContext(ctx, &fillerInputID, `
+		UPDATE mcr.channels SET
+			filler_volume = $1,
+			filler_gain = $2,
+			filler_muted = $3
+		WHERE channel_id = $4
+		RETURNING filler_input_id;`, a.Volume, a.Gain, a.Muted, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update filler audio level: %w", err)
+	}
+	return mcr.setInputAudio(ctx, fillerInputID, a)
+}
+
+// setInputAudio changes the volume of an input in Brave, if there is one. The
+// reconciler recreates missing inputs at their level.
+func (mcr *MCR) setInputAudio(ctx context.Context, inputID int, a AudioLevel) error {
+	if inputID == 0 {
+		return nil
+	}
+	err := mcr.brave.SetInputVolume(ctx, inputID, a.braveVolume())
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
+		return fmt.Errorf("failed to set input volume: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package mcr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// CardTemplate is how a channel's continuity card is laid out. Font sizes are
+// for a 1080 line channel and are scaled to the channel's height.
+type CardTemplate struct {
+	// FontPath is a TrueType font on the server.
+	FontPath        string `db:"font_path" json:"fontPath" form:"fontPath" query:"fontPath"`
+	HeadingFontSize int    `db:"heading_font_size" json:"headingFontSize" form:"headingFontSize" query:"headingFontSize"`
+	BodyFontSize    int    `db:"body_font_size" json:"bodyFontSize" form:"bodyFontSize" query:"bodyFontSize"`
+	// TextColour and BackgroundColour are hex colours, the background colour
+	// is seen where there isn't a background image.
+	TextColour       string `db:"text_colour" json:"textColour" form:"textColour" query:"textColour"`
+	BackgroundColour string `db:"background_colour" json:"backgroundColour" form:"backgroundColour" query:"backgroundColour"`
+	// LogoPosition is which corner the channel's logo is drawn in, or none.
+	LogoPosition string `db:"logo_position" json:"logoPosition" form:"logoPosition" query:"logoPosition"`
+	// Heading has {channel} replaced with the channel's title.
+	Heading string `db:"heading_text" json:"heading" form:"heading" query:"heading"`
+	// Upcoming is shown above the upcoming playouts, Empty instead of them
+	// when there aren't any.
+	Upcoming string `db:"upcoming_text" json:"upcoming" form:"upcoming" query:"upcoming"`
+	Empty    string `db:"empty_text" json:"empty" form:"empty" query:"empty"`
+	// Message is shown at the bottom of the card.
+	Message string `db:"message_text" json:"message" form:"message" query:"message"`
+	// Today replaces the date of playouts starting today.
+	Today string `db:"today_text" json:"today" form:"today" query:"today"`
+	// MaxEntries is the most upcoming playouts shown, fewer are when they
+	// don't fit.
+	MaxEntries int `db:"max_entries" json:"maxEntries" form:"maxEntries" query:"maxEntries"`
+	// TitleOverflow is whether playout titles too long for their column are
+	// truncated or wrapped onto a second line.
+	TitleOverflow string `db:"title_overflow" json:"titleOverflow" form:"titleOverflow" query:"titleOverflow"`
+	// DateFormat and TimeFormat are Go time layouts.
+	DateFormat string `db:"date_format" json:"dateFormat" form:"dateFormat" query:"dateFormat"`
+	TimeFormat string `db:"time_format" json:"timeFormat" form:"timeFormat" query:"timeFormat"`
+	// Timezone is an IANA time zone name, or Local for the server's.
+	Timezone string `db:"timezone" json:"timezone" form:"timezone" query:"timezone"`
+}
+
+// Card logo positions.
+const (
+	LogoNone        = "none"
+	LogoTopLeft     = "top-left"
+	LogoTopRight    = "top-right"
+	LogoBottomLeft  = "bottom-left"
+	LogoBottomRight = "bottom-right"
+)
+
+// Card title overflows.
+const (
+	TitleTruncate = "truncate"
+	TitleWrap     = "wrap"
+)
+
+// ErrCardTemplateInvalid when a card template can't be drawn.
+var ErrCardTemplateInvalid = errors.New("card template is invalid")
+
+var hexColour = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// DefaultCardTemplate is the card template of channels that haven't set one.
+func DefaultCardTemplate() CardTemplate {
+	return CardTemplate{
+		FontPath:         "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		HeadingFontSize:  96,
+		BodyFontSize:     50,
+		TextColour:       "#ffffff",
+		BackgroundColour: "#000000",
+		LogoPosition:     LogoNone,
+		Heading:          "{channel} - We're not on-air right now",
+		Upcoming:         "Upcoming content",
+		Empty:            "No content scheduled right now, check back soon!",
+		Today:            "Today",
+		MaxEntries:       5,
+		TitleOverflow:    TitleTruncate,
+		DateFormat:       "2 January",
+		TimeFormat:       "3:04PM",
+		Timezone:         "Local",
+	}
+}
+
+// withDefaults fills in the fields of a card template that can't be empty.
+func (t CardTemplate) withDefaults() CardTemplate {
+	d := DefaultCardTemplate()
+	if t.FontPath == "" {
+		t.FontPath = d.FontPath
+	}
+	if t.HeadingFontSize == 0 {
+		t.HeadingFontSize = d.HeadingFontSize
+	}
+	if t.BodyFontSize == 0 {
+		t.BodyFontSize = d.BodyFontSize
+	}
+	if t.TextColour == "" {
+		t.TextColour = d.TextColour
+	}
+	if t.BackgroundColour == "" {
+		t.BackgroundColour = d.BackgroundColour
+	}
+	if t.LogoPosition == "" {
+		t.LogoPosition = d.LogoPosition
+	}
+	if t.MaxEntries == 0 {
+		t.MaxEntries = d.MaxEntries
+	}
+	if t.TitleOverflow == "" {
+		t.TitleOverflow = d.TitleOverflow
+	}
+	if t.DateFormat == "" {
+		t.DateFormat = d.DateFormat
+	}
+	if t.TimeFormat == "" {
+		t.TimeFormat = d.TimeFormat
+	}
+	if t.Timezone == "" {
+		t.Timezone = d.Timezone
+	}
+	return t
+}
+
+// validate checks a card template can be drawn.
+func (t CardTemplate) validate() error {
+	for _, size := range []int{t.HeadingFontSize, t.BodyFontSize} {
+		if size < 8 || size > 400 {
+			return fmt.Errorf("%w: font size %d isn't between 8 and 400", ErrCardTemplateInvalid, size)
+		}
+	}
+	_, err := gg.LoadFontFace(t.FontPath, float64(t.BodyFontSize))
+	if err != nil {
+		return fmt.Errorf("%w: font: %v", ErrCardTemplateInvalid, err)
+	}
+	for _, colour := range []string{t.TextColour, t.BackgroundColour} {
+		if !hexColour.MatchString(colour) {
+			return fmt.Errorf("%w: colour %q isn't hex", ErrCardTemplateInvalid, colour)
+		}
+	}
+	switch t.LogoPosition {
+	case LogoNone, LogoTopLeft, LogoTopRight, LogoBottomLeft, LogoBottomRight:
+	default:
+		return fmt.Errorf("%w: logo position %q", ErrCardTemplateInvalid, t.LogoPosition)
+	}
+	if t.MaxEntries < 1 || t.MaxEntries > 20 {
+		return fmt.Errorf("%w: max entries %d isn't between 1 and 20", ErrCardTemplateInvalid, t.MaxEntries)
+	}
+	if t.TitleOverflow != TitleTruncate && t.TitleOverflow != TitleWrap {
+		return fmt.Errorf("%w: title overflow %q", ErrCardTemplateInvalid, t.TitleOverflow)
+	}
+	_, err = time.LoadLocation(t.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: timezone: %v", ErrCardTemplateInvalid, err)
+	}
+	return nil
+}
+
+// GetCardTemplate returns a channel's continuity card template.
+func (mcr *MCR) GetCardTemplate(ctx context.Context, channelID int) (CardTemplate, error) {
+	t := CardTemplate{}
+	err := mcr.db.GetContext(ctx, &t, `
+		SELECT font_path, heading_font_size, body_font_size, text_colour,
+					 background_colour, logo_position, heading_text, upcoming_text,
+					 empty_text, message_text, today_text, max_entries, title_overflow,
+					 date_format, time_format, timezone
+		FROM mcr.card_templates
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DefaultCardTemplate(), nil
+		}
+		return CardTemplate{}, fmt.Errorf("failed to get card template: %w", err)
+	}
+	return t, nil
+}
+
+// SetCardTemplate replaces a channel's continuity card template, asking for
+// the card to be refreshed. Empty fields that have to be set use
+// the default template's.
+func (mcr *MCR) SetCardTemplate(ctx context.Context, channelID int, t CardTemplate) error {
+	t = t.withDefaults()
+	err := t.validate()
+	if err != nil {
+		return err
+	}
+
+	ch, err := mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	_, err = mcr.db.ExecContext(ctx, `
+		INSERT INTO mcr.card_templates (
+			channel_id, font_path, heading_font_size, body_font_size, text_colour,
+			background_colour, logo_position, heading_text, upcoming_text,
+			empty_text, message_text, today_text, max_entries, title_overflow,
+			date_format, time_format, timezone
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (channel_id) DO UPDATE SET
+			font_path = EXCLUDED.font_path,
+			heading_font_size = EXCLUDED.heading_font_size,
+			body_font_size = EXCLUDED.body_font_size,
+			text_colour = EXCLUDED.text_colour,
+			background_colour = EXCLUDED.background_colour,
+			logo_position = EXCLUDED.logo_position,
+			heading_text = EXCLUDED.heading_text,
+			upcoming_text = EXCLUDED.upcoming_text,
+			empty_text = EXCLUDED.empty_text,
+			message_text = EXCLUDED.message_text,
+			today_text = EXCLUDED.today_text,
+			max_entries = EXCLUDED.max_entries,
+			title_overflow = EXCLUDED.title_overflow,
+			date_format = EXCLUDED.date_format,
+			time_format = EXCLUDED.time_format,
+			timezone = EXCLUDED.timezone;`,
+		ch.ID, t.FontPath, t.HeadingFontSize, t.BodyFontSize, t.TextColour,
+		t.BackgroundColour, t.LogoPosition, t.Heading, t.Upcoming,
+		t.Empty, t.Message, t.Today, t.MaxEntries, t.TitleOverflow,
+		t.DateFormat, t.TimeFormat, t.Timezone)
+	if err != nil {
+		return fmt.Errorf("failed to update card template: %w", err)
+	}
+
+	mcr.requestCardRefresh(ch.ID)
+	return nil
+}
+
+// PreviewContinuityCard draws a channel's continuity card with a template as
+// a PNG image, without changing the channel.
+func (mcr *MCR) PreviewContinuityCard(ctx context.Context, channelID int, t CardTemplate, w io.Writer) error {
+	t = t.withDefaults()
+	err := t.validate()
+	if err != nil {
+		return err
+	}
+	cr, err := mcr.getChannelRundown(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel rundown: %w", err)
+	}
+
+	im, err := newContinuityCard(newContinuityCardParams{
+		X:              cr.Width,
+		Y:              cr.Height,
+		BackgroundPath: cardBackgroundPath(channelID),
+		LogoPath:       cardLogoPath(channelID),
+		Title:          cr.Title,
+		Playouts:       cr.Playouts,
+		Template:       t,
+		Now:            time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate card: %w", err)
+	}
+	err = png.Encode(w, im)
+	if err != nil {
+		return fmt.Errorf("failed to encode png: %w", err)
+	}
+	return nil
+}
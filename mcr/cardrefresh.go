@@ -0,0 +1,224 @@
+package mcr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// cardRefresh tracks a channel's continuity card refreshes.
+type cardRefresh struct {
+	// render stops the card's files being written by two refreshes at once.
+	render sync.Mutex
+	// requested is when the first refresh waiting to run was asked for, zero
+	// when none is waiting.
+	requested time.Time
+	// due is when the waiting refresh runs.
+	due time.Time
+	// running is whether a refresh is running, another waits until it's
+	// done.
+	running bool
+}
+
+const (
+	// cardDebounce is how long a card refresh waits for more to be asked for,
+	// so a burst of edits redraws the card once.
+	cardDebounce = 500 * time.Millisecond
+	// cardMaxDelay is the longest a card refresh is put off by more being
+	// asked for.
+	cardMaxDelay = 5 * time.Second
+	// bravePollInterval is how often Brave is checked while waiting on it.
+	bravePollInterval = 100 * time.Millisecond
+	// braveWaitTimeout is how long to wait on Brave before giving up.
+	braveWaitTimeout = 15 * time.Second
+)
+
+// cardRefreshFor returns a channel's card refresh tracking.
+func (mcr *MCR) cardRefreshFor(channelID int) *cardRefresh {
+	mcr.cardsMu.Lock()
+	defer mcr.cardsMu.Unlock()
+	return mcr.cardRefreshLocked(channelID)
+}
+
+func (mcr *MCR) cardRefreshLocked(channelID int) *cardRefresh {
+	r, ok := mcr.cards[channelID]
+	if !ok {
+		r = &cardRefresh{}
+		mcr.cards[channelID] = r
+	}
+	return r
+}
+
+// requestCardRefresh asks for a channel's continuity card to be refreshed by
+// RunCardRenderer. Requests close together are coalesced into one refresh.
+func (mcr *MCR) requestCardRefresh(channelID int) {
+	now := time.Now()
+	mcr.cardsMu.Lock()
+	r := mcr.cardRefreshLocked(channelID)
+	if r.requested.IsZero() {
+		r.requested = now
+	}
+	r.due = now.Add(cardDebounce)
+	if latest := r.requested.Add(cardMaxDelay); r.due.After(latest) {
+		r.due = latest
+	}
+	mcr.cardsMu.Unlock()
+	mcr.wakeCardRenderer()
+}
+
+func (mcr *MCR) wakeCardRenderer() {
+	select {
+	case mcr.cardsWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunCardRenderer refreshes on-air channels' continuity cards as they are
+// requested until the context is done, each channel in the background of the
+// others.
+//
+// The outcome of each refresh is recorded as a channel event, since whatever
+// asked for it has moved on.
+func (mcr *MCR) RunCardRenderer(ctx context.Context) {
+	ctx = WithAsRunSource(ctx, AsRunSourceCardRenderer)
+	running := sync.WaitGroup{}
+	defer running.Wait()
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-mcr.cardsWake:
+		case <-t.C:
+		}
+		next := mcr.startDueCardRefreshes(ctx, &running, time.Now())
+		if !t.Stop() {
+			select {
+			case <-t.C:
+			default:
+			}
+		}
+		if next > 0 {
+			t.Reset(next)
+		}
+	}
+}
+
+// startDueCardRefreshes starts the card refreshes that are due, returning how
+// long until the next one is or 0 if none are waiting.
+func (mcr *MCR) startDueCardRefreshes(ctx context.Context, running *sync.WaitGroup, now time.Time) time.Duration {
+	mcr.cardsMu.Lock()
+	defer mcr.cardsMu.Unlock()
+	var next time.Duration
+	for channelID, r := range mcr.cards {
+		if r.requested.IsZero() || r.running {
+			continue
+		}
+		if wait := r.due.Sub(now); wait > 0 {
+			if next == 0 || wait < next {
+				next = wait
+			}
+			continue
+		}
+		r.requested = time.Time{}
+		r.running = true
+		running.Add(1)
+		go func(channelID int, r *cardRefresh) {
+			defer running.Done()
+			mcr.runCardRefresh(ctx, channelID)
+			mcr.cardsMu.Lock()
+			r.running = false
+			mcr.cardsMu.Unlock()
+			mcr.wakeCardRenderer()
+		}(channelID, r)
+	}
+	return next
+}
+
+// runCardRefresh refreshes a channel's continuity card, recording how it went.
+func (mcr *MCR) runCardRefresh(ctx context.Context, channelID int) {
+	inputID, err := mcr.refreshCardInBackground(ctx, channelID)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to refresh channel %d continuity card: %v", channelID, err)
+		err = mcr.createChannelEvent(ctx, channelID, EventCardFailed, &EventCardFailedPayload{
+			Err: err.Error(),
+		})
+	} else if inputID != 0 {
+		err = mcr.createChannelEvent(ctx, channelID, EventCardRefreshed, &EventCardRefreshedPayload{
+			ContinuityInputID: inputID,
+		})
+	}
+	if err != nil {
+		log.Printf("failed to create channel %d event: %v", channelID, err)
+	}
+}
+
+// RefreshContinuityCard draws a channel's continuity card and plays it
+// straight away, for when nothing is running RunCardRenderer. It does nothing
+// when the channel isn't on-air.
+func (mcr *MCR) RefreshContinuityCard(ctx context.Context, channelID int) error {
+	_, err := mcr.refreshCardInBackground(ctx, channelID)
+	return err
+}
+
+// refreshCardInBackground draws a channel's continuity card and waits for Brave
+// to play it, only holding the channel's lock to swap it in. The new continuity
+// input's ID is returned, or 0 when the channel isn't on-air.
+func (mcr *MCR) refreshCardInBackground(ctx context.Context, channelID int) (int, error) {
+	cr, err := mcr.getChannelRundown(ctx, channelID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get channel rundown: %w", err)
+	}
+	if cr.Status != "on-air" {
+		return 0, nil
+	}
+	err = mcr.renderContinuityCard(ctx, channelID, cr)
+	if err != nil {
+		return 0, err
+	}
+	return mcr.playContinuityCard(ctx, channelID)
+}
+
+// playContinuityCard swaps a channel's drawn continuity card into Brave, only
+// holding the channel's lock for the swap. The new continuity input's ID is
+// returned, or 0 when the channel has gone off-air.
+func (mcr *MCR) playContinuityCard(ctx context.Context, channelID int) (int, error) {
+	inputID, err := mcr.newContinuityInput(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock := mcr.lockChannel(channelID)
+	swap, err := mcr.swapContinuityInput(ctx, channelID, inputID)
+	unlock()
+	if err != nil {
+		return 0, err
+	}
+	return swap.inputID, mcr.finishCardSwap(ctx, swap)
+}
+
+// waitForBrave polls Brave until done returns true or an error, giving up
+// after braveWaitTimeout.
+func waitForBrave(ctx context.Context, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, braveWaitTimeout)
+	defer cancel()
+	t := time.NewTicker(bravePollInterval)
+	defer t.Stop()
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
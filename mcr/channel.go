@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/ystv/showtime/brave"
 )
@@ -11,25 +13,51 @@ import (
 type (
 	// Channel add redundancy to a stream.
 	Channel struct {
-		ID                int    `db:"channel_id"`
-		Status            string `db:"status"`
-		URLName           string `db:"url_name"`
-		OutputURL         string
-		Width             int    `db:"res_width"`
-		Height            int    `db:"res_height"`
-		Title             string `db:"title"`
-		MixerID           int    `db:"mixer_id"`
-		ProgramInputID    int    `db:"program_input_id"`
-		ContinuityInputID int    `db:"continuity_input_id"`
-		ProgramOutputID   int    `db:"program_output_id"`
+		ID                int    `db:"channel_id" json:"channelID"`
+		Status            string `db:"status" json:"status"`
+		URLName           string `db:"url_name" json:"urlName"`
+		OutputURL         string `json:"outputURL"`
+		Width             int    `db:"res_width" json:"width"`
+		Height            int    `db:"res_height" json:"height"`
+		Title             string `db:"title" json:"title"`
+		MixerID           int    `db:"mixer_id" json:"mixerID"`
+		ProgramInputID    int    `db:"program_input_id" json:"programInputID"`
+		ContinuityInputID int    `db:"continuity_input_id" json:"continuityInputID"`
+		FillerOrder       string `db:"filler_order" json:"fillerOrder"`
+		// FillerInputID is the filler being played whilst nothing is
+		// scheduled, 0 when there isn't one.
+		FillerInputID int `db:"filler_input_id" json:"fillerInputID"`
+		// Transition is how the channel changes source, unless a playout
+		// has its own.
+		Transition         string        `db:"transition" json:"transition"`
+		TransitionDuration time.Duration `db:"transition_duration" json:"transitionDuration"`
+		// AudioOnly channels show their continuity card over whatever is
+		// on, playing only its sound.
+		AudioOnly   bool       `db:"audio_only" json:"audioOnly"`
+		FillerAudio AudioLevel `db:"filler" json:"fillerAudio"`
+		// Failover is what the channel cuts to when a live playout's source
+		// fails, continuity or slate.
+		Failover string `db:"failover" json:"failover"`
+		// FailoverPlayoutID is the playout the channel has failed over from,
+		// 0 when it hasn't.
+		FailoverPlayoutID int `db:"failover_playout_id" json:"failoverPlayoutID"`
+		// SlateInputID is the holding slate being shown whilst failed over, 0
+		// when it isn't.
+		SlateInputID int `db:"slate_input_id" json:"slateInputID"`
 	}
 
 	// EditChannel creates or updates a channel.
 	EditChannel struct {
 		Title   string `json:"title" form:"title"`
 		URLName string `json:"urlName" form:"urlName"`
-		Width   int
-		Height  int
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		// Transition defaults to a cut, when updating it is left as it is.
+		Transition         string        `json:"transition" form:"transition"`
+		TransitionDuration time.Duration `json:"transitionDuration"`
+		AudioOnly          bool          `json:"audioOnly" form:"audioOnly"`
+		// Failover defaults to continuity, when updating it is left as it is.
+		Failover string `json:"failover" form:"failover"`
 	}
 )
 
@@ -38,23 +66,59 @@ var (
 	ErrURLNameEmpty = errors.New("url name is empty")
 	// ErrChannelOnAir when the channel is on air.
 	ErrChannelOnAir = errors.New("channel is on-air")
+	// ErrChannelOffAir when the channel is off air.
+	ErrChannelOffAir = errors.New("channel is off-air")
+	// ErrChannelArchived when the channel is archived.
+	ErrChannelArchived = errors.New("channel is archived")
 	// ErrChannelNotArchived when a channel is not in the archive status.
 	ErrChannelNotArchived = errors.New("channel is not archived")
 )
 
-// setChannelProgram
-func (mcr *MCR) setChannelProgram(ctx context.Context, channelID int, inputID int) error {
-	mixerID := 0
-	err := mcr.db.GetContext(ctx, &mixerID, `
-		SELECT mixer_id
+// setChannelProgram changes a channel's program to an input with a
+// transition, the channel's own when the transition's type is empty. A failed
+// transition falls back to a cut so the channel isn't left part way through.
+// The caller must hold the channel's lock.
+//
+// An audio-only channel keeps its continuity card in the program over the
+// input, see transitionUnderCard.
+func (mcr *MCR) setChannelProgram(ctx context.Context, channelID int, inputID int, t transition, reason string) error {
+	ch := struct {
+		MixerID            int           `db:"mixer_id"`
+		ProgramInputID     int           `db:"program_input_id"`
+		ContinuityInputID  int           `db:"continuity_input_id"`
+		Transition         string        `db:"transition"`
+		TransitionDuration time.Duration `db:"transition_duration"`
+		AudioOnly          bool          `db:"audio_only"`
+	}{}
+	err := mcr.db.GetContext(ctx, &ch, `
+		SELECT mixer_id, program_input_id, continuity_input_id, transition,
+					 transition_duration, audio_only
 		FROM mcr.channels
 		WHERE channel_id = $1`, channelID)
 	if err != nil {
 		return fmt.Errorf("failed to get mixer id: %w", err)
 	}
-	err = mcr.brave.CutMixerToInput(ctx, mixerID, inputID)
-	if err != nil {
-		return fmt.Errorf("failed to cut mixer to input: %w", err)
+	if t.Type == "" {
+		t = transition{Type: ch.Transition, Duration: ch.TransitionDuration}
+	}
+	if ch.AudioOnly && t.Type != transitionSeamless {
+		err = mcr.transitionUnderCard(ctx, ch.MixerID, ch.ContinuityInputID, ch.ProgramInputID, inputID, t)
+		if err != nil {
+			log.Printf("channel %d transition failed, cutting: %v", channelID, err)
+			err = mcr.cutUnderCard(ctx, ch.MixerID, ch.ContinuityInputID, inputID)
+			if err != nil {
+				return fmt.Errorf("failed to cut mixer to input: %w", err)
+			}
+		}
+	} else {
+		err = mcr.transitionMixer(ctx, ch.MixerID, ch.ProgramInputID, inputID, t)
+		if err != nil {
+			log.Printf("channel %d transition failed, cutting: %v", channelID, err)
+			err = mcr.brave.CutMixerToInput(ctx, ch.MixerID, inputID)
+			if err != nil {
+				return fmt.Errorf("failed to cut mixer to input: %w", err)
+			}
+		}
 	}
 	_, err = mcr.db.ExecContext(ctx, `
 		UPDATE mcr.channels
@@ -64,11 +128,22 @@ func (mcr *MCR) setChannelProgram(ctx context.Context, channelID int, inputID in
 	if err != nil {
 		return fmt.Errorf("failed to update program input in store: %w", err)
 	}
+	mcr.recordCutAsRun(ctx, channelID, inputID, reason)
 	return nil
 }
 
 // SetChannelOnAir starts the channel's broadcast.
 func (mcr *MCR) SetChannelOnAir(ctx context.Context, ch Channel) error {
+	switch ch.Status {
+	case "on-air":
+		return ErrChannelOnAir
+	case "archived":
+		return ErrChannelArchived
+	}
+
+	unlock := mcr.lockChannel(ch.ID)
+	defer unlock()
+
 	p := brave.NewMixerParams{
 		Width:  ch.Width,
 		Height: ch.Height,
@@ -79,51 +154,75 @@ func (mcr *MCR) SetChannelOnAir(ctx context.Context, ch Channel) error {
 		return fmt.Errorf("failed to create mixer: %w", err)
 	}
 
-	o, err := mcr.brave.NewRTMPOutput(ctx, m, mcr.outputAddress.String()+"/"+ch.URLName)
+	err = mcr.startChannelOutputs(ctx, m, ch)
 	if err != nil {
-		return fmt.Errorf("failed to create output: %w", err)
+		return fmt.Errorf("failed to start outputs: %w", err)
 	}
 
 	_, err = mcr.db.ExecContext(ctx, `
 		UPDATE mcr.channels SET
 			status = 'on-air',
-			mixer_id = $1,
-			program_output_id = $2
-		WHERE channel_id = $3;
-	`, m.ID, o.ID, ch.ID)
+			mixer_id = $1
+		WHERE channel_id = $2;
+	`, m.ID, ch.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update channel in store: %w", err)
 	}
+	mcr.recordAsRun(ctx, AsRunEntry{ChannelID: ch.ID, Type: AsRunOnAir})
 
-	err = mcr.refreshContinuityCard(ctx, ch.ID)
-	if err != nil {
-		return fmt.Errorf("failed to refresh continuity card: %w", err)
-	}
+	mcr.requestCardRefresh(ch.ID)
 
 	return nil
 }
 
 // SetChannelOffAir ends the channel's broadcast.
 func (mcr *MCR) SetChannelOffAir(ctx context.Context, ch Channel) error {
-	err := mcr.brave.DeleteOutput(ctx, ch.ProgramOutputID)
+	if ch.Status != "on-air" {
+		return ErrChannelOffAir
+	}
+	unlock := mcr.lockChannel(ch.ID)
+	defer unlock()
+
+	err := mcr.stopChannelOutputs(ctx, ch.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete program output: %w", err)
+		return fmt.Errorf("failed to stop outputs: %w", err)
 	}
 	err = mcr.brave.DeleteMixer(ctx, ch.MixerID)
-	if err != nil {
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
 		return fmt.Errorf("failed to delete mixer: %w", err)
 	}
+	if ch.FillerInputID != 0 {
+		err = mcr.brave.DeleteInput(ctx, ch.FillerInputID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete filler input: %w", err)
+		}
+	}
+	if ch.SlateInputID != 0 {
+		err = mcr.brave.DeleteInput(ctx, ch.SlateInputID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete slate input: %w", err)
+		}
+	}
 
 	_, err = mcr.db.ExecContext(ctx, `
 		UPDATE mcr.channels SET
 			status = 'off-air',
 			mixer_id = 0,
-			program_output_id = 0
+			filler_input_id = 0,
+			failover_playout_id = 0,
+			slate_input_id = 0
 		WHERE channel_id = $1;
 	`, ch.ID)
 	if err != nil {
 		return fmt.Errorf("failed to delete channel in store: %w", err)
 	}
+	mcr.recordAsRun(ctx, AsRunEntry{ChannelID: ch.ID, Type: AsRunOffAir})
+
+	// Once the channel is off-air, so the scheduler doesn't draw them again.
+	err = mcr.stopChannelGraphics(ctx, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to stop graphics: %w", err)
+	}
 
 	return nil
 }
@@ -146,17 +245,40 @@ func (mcr *MCR) NewChannel(ctx context.Context, ch EditChannel) (int, error) {
 	if ch.Height == 0 {
 		ch.Height = 1080
 	}
+	if ch.Transition == "" {
+		ch.Transition = TransitionCut
+	}
+	if ch.Failover == "" {
+		ch.Failover = FailoverContinuity
+	}
+	err := validateTransition(ch.Transition, ch.TransitionDuration, false)
+	if err != nil {
+		return 0, err
+	}
+	err = validateFailover(ch.Failover)
+	if err != nil {
+		return 0, err
+	}
 
 	channelID := 0
-	err := mcr.db.GetContext(ctx, &channelID, `
+	err = mcr.db.GetContext(ctx, &channelID, `
 		INSERT INTO mcr.channels (
 			status, title, url_name, res_width, res_height, mixer_id, program_input_id,
-			continuity_input_id, program_output_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING channel_id;`, "off-air", ch.Title, ch.URLName, ch.Width, ch.Height, 0, 0, 0, 0)
+			continuity_input_id, transition, transition_duration, audio_only, failover)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING channel_id;`, "off-air", ch.Title, ch.URLName, ch.Width, ch.Height, 0, 0, 0,
+		ch.Transition, ch.TransitionDuration, ch.AudioOnly, ch.Failover)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert channel: %w", err)
 	}
+	err = mcr.newLocalOutput(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+	err = mcr.newChannelGraphics(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
 
 	return channelID, nil
 }
@@ -186,8 +308,24 @@ func (mcr *MCR) UpdateChannel(ctx context.Context, channelID int, ch EditChannel
 	if ch.Height == 0 {
 		ch.Height = oldCh.Height
 	}
+	if ch.Transition == "" {
+		ch.Transition = oldCh.Transition
+		ch.TransitionDuration = oldCh.TransitionDuration
+	}
+	if ch.Failover == "" {
+		ch.Failover = oldCh.Failover
+	}
+	err = validateTransition(ch.Transition, ch.TransitionDuration, false)
+	if err != nil {
+		return err
+	}
+	err = validateFailover(ch.Failover)
+	if err != nil {
+		return err
+	}
 
-	if oldCh.Status == "on-air" && (ch.Width != oldCh.Width || ch.Height != oldCh.Height || ch.URLName != oldCh.URLName) {
+	if oldCh.Status == "on-air" && (ch.Width != oldCh.Width || ch.Height != oldCh.Height ||
+		ch.URLName != oldCh.URLName || ch.AudioOnly != oldCh.AudioOnly) {
 		// This requires us to restart brave with different parameters, so need to be off-air to perform.
 		return ErrChannelOnAir
 	}
@@ -197,16 +335,18 @@ func (mcr *MCR) UpdateChannel(ctx context.Context, channelID int, ch EditChannel
 			title = $1,
 			url_name = $2,
 			res_width = $3,
-			res_height = $4
-		WHERE channel_id = $5;`, ch.Title, ch.URLName, ch.Width, ch.Height, channelID)
+			res_height = $4,
+			transition = $5,
+			transition_duration = $6,
+			audio_only = $7,
+			failover = $8
+		WHERE channel_id = $9;`, ch.Title, ch.URLName, ch.Width, ch.Height, ch.Transition,
+		ch.TransitionDuration, ch.AudioOnly, ch.Failover, channelID)
 	if err != nil {
 		return fmt.Errorf("failed to update channel: %w", err)
 	}
 
-	err = mcr.refreshContinuityCard(ctx, channelID)
-	if err != nil {
-		return fmt.Errorf("failed to refresh continuity card: %w", err)
-	}
+	mcr.requestCardRefresh(channelID)
 
 	return nil
 }
@@ -216,7 +356,11 @@ func (mcr *MCR) GetChannel(ctx context.Context, channelID int) (Channel, error)
 	ch := Channel{}
 	err := mcr.db.GetContext(ctx, &ch, `
 		SELECT channel_id, status, title, url_name, res_width, res_height, mixer_id,
-					 program_input_id, continuity_input_id, program_output_id
+					 program_input_id, continuity_input_id,
+					 filler_order, filler_input_id, transition, transition_duration,
+					 audio_only, filler_volume AS "filler.volume",
+					 filler_gain AS "filler.gain", filler_muted AS "filler.muted",
+					 failover, failover_playout_id, slate_input_id
 		FROM mcr.channels
 		WHERE channel_id  = $1;`, channelID)
 	if err != nil {
@@ -232,12 +376,16 @@ func (mcr *MCR) GetChannel(ctx context.Context, channelID int) (Channel, error)
 func (mcr *MCR) ListChannels(ctx context.Context) ([]Channel, error) {
 	ch := []Channel{}
 	err := mcr.db.SelectContext(ctx, &ch, `
-		SELECT channel_id, title, mixer_id
-		FROM mcr.channels;
+		SELECT channel_id, status, title, url_name, res_width, res_height, mixer_id
+		FROM mcr.channels
+		ORDER BY channel_id;
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list of channels: %w", err)
 	}
+	for i := range ch {
+		ch[i].OutputURL = mcr.outputAddress.String() + "/" + ch[i].URLName
+	}
 	return ch, nil
 }
 
@@ -294,5 +442,29 @@ func (mcr *MCR) DeleteChannel(ctx context.Context, ch Channel) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete channel from store: %w", err)
 	}
+	err = deleteChannelAssets(ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel assets: %w", err)
+	}
 	return nil
 }
+
+// CountChannelsByStatus counts channels by their status.
+func (mcr *MCR) CountChannelsByStatus(ctx context.Context) (map[string]int, error) {
+	rows := []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}{}
+	err := mcr.db.SelectContext(ctx, &rows, `
+		SELECT status, COUNT(*) AS count
+		FROM mcr.channels
+		GROUP BY status;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count channels: %w", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,80 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSetChannelOnAir(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := context.Background()
+	ch := Channel{ID: 1, Status: "off-air", URLName: "one", Width: 1280, Height: 720}
+
+	mock.ExpectQuery("FROM mcr.channel_outputs WHERE channel_id = $1").
+		WithArgs(ch.ID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"channel_output_id", "channel_id", "output_type", "name", "url", "port", "brave_output_id",
+		}).AddRow(3, ch.ID, OutputLocal, "Local", "", 0, 0))
+	// Brave's first mixer and output are 1.
+	mock.ExpectExec("UPDATE mcr.channel_outputs SET brave_output_id = $1 WHERE channel_output_id = $2").
+		WithArgs(1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE mcr.channels SET status = 'on-air', mixer_id = $1 WHERE channel_id = $2").
+		WithArgs(1, ch.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAsRun(mock, ch.ID, AsRunOnAir)
+
+	err := mcr.SetChannelOnAir(ctx, ch)
+	if err != nil {
+		t.Fatalf("failed to set channel on-air: %v", err)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := b.GetMixer(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get mixer: %v", err)
+	}
+	if m.Width != ch.Width || m.Height != ch.Height {
+		t.Errorf("mixer is %dx%d, want %dx%d", m.Width, m.Height, ch.Width, ch.Height)
+	}
+	o, err := b.GetOutput(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get output: %v", err)
+	}
+	if o.Src != m.UID || o.Dst != "rtmp://stream.test/live/one" {
+		t.Errorf("output is of %q to %q, want of %q to the channel's url", o.Src, o.Dst, m.UID)
+	}
+	mcr.cardsMu.Lock()
+	requested := mcr.cards[ch.ID].requested
+	mcr.cardsMu.Unlock()
+	if requested.IsZero() {
+		t.Error("continuity card refresh wasn't requested")
+	}
+}
+
+func TestSetChannelOnAirWhenOnAir(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := context.Background()
+
+	err := mcr.SetChannelOnAir(ctx, Channel{ID: 1, Status: "on-air", Width: 1280, Height: 720})
+	if !errors.Is(err, ErrChannelOnAir) {
+		t.Fatalf("got %v, want %v", err, ErrChannelOnAir)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixers, err := b.ListMixers(ctx)
+	if err != nil {
+		t.Fatalf("failed to list mixers: %v", err)
+	}
+	if len(mixers) != 0 {
+		t.Errorf("%d mixers were created", len(mixers))
+	}
+}
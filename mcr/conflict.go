@@ -0,0 +1,160 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// ScheduleConflict is a playout scheduled at the same time as another on
+	// the same channel, the one starting last takes over the channel when
+	// both are due.
+	ScheduleConflict struct {
+		PlayoutID      int       `json:"playoutID"`
+		Title          string    `json:"title"`
+		OtherPlayoutID int       `json:"otherPlayoutID"`
+		OtherTitle     string    `json:"otherTitle"`
+		OverlapStart   time.Time `json:"overlapStart"`
+		OverlapEnd     time.Time `json:"overlapEnd"`
+		// Resolutions are schedules the playout could be moved to so it
+		// doesn't overlap the other.
+		Resolutions []ScheduleResolution `json:"resolutions"`
+	}
+	// ScheduleResolution is a suggested schedule for a conflicting playout.
+	ScheduleResolution struct {
+		Description    string    `json:"description"`
+		ScheduledStart time.Time `json:"scheduledStart"`
+		ScheduledEnd   time.Time `json:"scheduledEnd"`
+	}
+	// scheduledSlot is when a playout is scheduled.
+	scheduledSlot struct {
+		ID             int       `db:"playout_id"`
+		Title          string    `db:"title"`
+		ScheduledStart time.Time `db:"scheduled_start"`
+		ScheduledEnd   time.Time `db:"scheduled_end"`
+	}
+)
+
+var (
+	// ErrScheduleInvalid when a playout's scheduled start isn't before its
+	// scheduled end.
+	ErrScheduleInvalid = errors.New("scheduled start must be before scheduled end")
+	// ErrPlayoutConflict when a playout overlaps another on its channel and
+	// the conflict wasn't allowed.
+	ErrPlayoutConflict = errors.New("playout overlaps another on the channel")
+)
+
+// CheckSchedule checks a playout can be scheduled on its channel, playoutID is
+// 0 for a new playout. The schedule has to be valid, the channel not archived
+// and, unless AllowConflict is set, the playout can't overlap another
+// scheduled or live playout on the channel.
+func (mcr *MCR) CheckSchedule(ctx context.Context, playoutID int, po EditPlayout) error {
+	if !po.ScheduledStart.Before(po.ScheduledEnd) {
+		return ErrScheduleInvalid
+	}
+	ch, err := mcr.GetChannel(ctx, po.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	if ch.Status == "archived" {
+		return ErrChannelArchived
+	}
+	if po.AllowConflict {
+		return nil
+	}
+
+	slot := scheduledSlot{
+		ID:             playoutID,
+		Title:          po.Title,
+		ScheduledStart: po.ScheduledStart,
+		ScheduledEnd:   po.ScheduledEnd,
+	}
+	others := []scheduledSlot{}
+	err = mcr.db.SelectContext(ctx, &others, `
+		SELECT playout_id, title, scheduled_start, scheduled_end
+		FROM mcr.playouts
+		WHERE channel_id = $1
+		AND playout_id != $2
+		AND status IN ('scheduled', 'live')
+		AND scheduled_start < $4
+		AND scheduled_end > $3
+		ORDER BY scheduled_start ASC;`, ch.ID, playoutID, po.ScheduledStart, po.ScheduledEnd)
+	if err != nil {
+		return fmt.Errorf("failed to get overlapping playouts: %w", err)
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	c := newScheduleConflict(slot, others[0])
+	return fmt.Errorf("%w: %q from %s to %s", ErrPlayoutConflict, c.OtherTitle,
+		c.OverlapStart.Format(time.RFC3339), c.OverlapEnd.Format(time.RFC3339))
+}
+
+// ListScheduleConflicts lists the scheduled and live playouts on a channel
+// that overlap, each overlap once as the playout starting later.
+func (mcr *MCR) ListScheduleConflicts(ctx context.Context, channelID int) ([]ScheduleConflict, error) {
+	slots := []scheduledSlot{}
+	err := mcr.db.SelectContext(ctx, &slots, `
+		SELECT playout_id, title, scheduled_start, scheduled_end
+		FROM mcr.playouts
+		WHERE channel_id = $1
+		AND status IN ('scheduled', 'live')
+		ORDER BY
+			scheduled_start ASC,
+			scheduled_end ASC;`, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list playouts: %w", err)
+	}
+
+	conflicts := []ScheduleConflict{}
+	for i, slot := range slots {
+		for _, other := range slots[:i] {
+			if other.ScheduledEnd.After(slot.ScheduledStart) && other.ScheduledStart.Before(slot.ScheduledEnd) {
+				conflicts = append(conflicts, newScheduleConflict(slot, other))
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// newScheduleConflict describes a slot overlapping another, suggesting moving
+// the slot after the other, or trimming it to before or after the other.
+func newScheduleConflict(slot, other scheduledSlot) ScheduleConflict {
+	c := ScheduleConflict{
+		PlayoutID:      slot.ID,
+		Title:          slot.Title,
+		OtherPlayoutID: other.ID,
+		OtherTitle:     other.Title,
+		OverlapStart:   slot.ScheduledStart,
+		OverlapEnd:     slot.ScheduledEnd,
+	}
+	if other.ScheduledStart.After(c.OverlapStart) {
+		c.OverlapStart = other.ScheduledStart
+	}
+	if other.ScheduledEnd.Before(c.OverlapEnd) {
+		c.OverlapEnd = other.ScheduledEnd
+	}
+
+	duration := slot.ScheduledEnd.Sub(slot.ScheduledStart)
+	c.Resolutions = append(c.Resolutions, ScheduleResolution{
+		Description:    fmt.Sprintf("start when %q ends", other.Title),
+		ScheduledStart: other.ScheduledEnd,
+		ScheduledEnd:   other.ScheduledEnd.Add(duration),
+	})
+	if slot.ScheduledStart.Before(other.ScheduledStart) {
+		c.Resolutions = append(c.Resolutions, ScheduleResolution{
+			Description:    fmt.Sprintf("end when %q starts", other.Title),
+			ScheduledStart: slot.ScheduledStart,
+			ScheduledEnd:   other.ScheduledStart,
+		})
+	} else if slot.ScheduledEnd.After(other.ScheduledEnd) {
+		c.Resolutions = append(c.Resolutions, ScheduleResolution{
+			Description:    fmt.Sprintf("start late when %q ends, keeping the end", other.Title),
+			ScheduledStart: other.ScheduledEnd,
+			ScheduledEnd:   slot.ScheduledEnd,
+		})
+	}
+	return c
+}
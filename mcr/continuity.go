@@ -2,13 +2,19 @@ package mcr
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"log"
 	"net/url"
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/fogleman/gg"
 
+	"github.com/ystv/showtime/brave"
 	"github.com/ystv/showtime/ffmpeg"
 )
 
@@ -18,11 +24,13 @@ type (
 	// channelRundown is a basic summary of a channel.
 	channelRundown struct {
 		Title             string `db:"title"`
+		Status            string `db:"status"`
 		Width             int    `db:"res_width"`
 		Height            int    `db:"res_height"`
 		MixerID           int    `db:"mixer_id"`
 		ProgramInputID    int    `db:"program_input_id"`
 		ContinuityInputID int    `db:"continuity_input_id"`
+		AudioOnly         bool   `db:"audio_only"`
 		Playouts          []playoutInfo
 	}
 	// playoutInfo is a basic summary of a playout.
@@ -31,88 +39,216 @@ type (
 		ScheduledStart time.Time `db:"scheduled_start"`
 	}
 	newContinuityCardParams struct {
-		X               int
-		Y               int
-		BackgroundPath  string
-		DestinationPath string
-		Title           string
-		Message         string
-		Playouts        []playoutInfo
+		X              int
+		Y              int
+		BackgroundPath string
+		LogoPath       string
+		Title          string
+		Playouts       []playoutInfo
+		Template       CardTemplate
+		Now            time.Time
 	}
 )
 
-func (mcr *MCR) refreshContinuityCard(ctx context.Context, channelID int) error {
-	cr, err := mcr.getChannelRundown(ctx, channelID)
+// renderContinuityCard draws a channel's continuity card and encodes it as the
+// video its continuity input plays.
+func (mcr *MCR) renderContinuityCard(ctx context.Context, channelID int, cr channelRundown) error {
+	// The card's files are shared by everything refreshing it.
+	render := &mcr.cardRefreshFor(channelID).render
+	render.Lock()
+	defer render.Unlock()
+
+	t, err := mcr.GetCardTemplate(ctx, channelID)
 	if err != nil {
-		return fmt.Errorf("failed to get channel rundown: %w", err)
-	}
-
-	bgImgPath := fmt.Sprintf("assets/ch/%d-card-bg.jpg", channelID)
-	dstImgPath := fmt.Sprintf("assets/ch/%d-card-continuity.png", channelID)
-	err = newContinuityCard(newContinuityCardParams{
-		X:               cr.Width,
-		Y:               cr.Height,
-		BackgroundPath:  bgImgPath,
-		DestinationPath: dstImgPath,
-		Title:           cr.Title,
-		Playouts:        cr.Playouts,
+		return err
+	}
+
+	dstImgPath := path.Join(channelAssetsDir, fmt.Sprintf("%d-card-continuity.png", channelID))
+	im, err := newContinuityCard(newContinuityCardParams{
+		X:              cr.Width,
+		Y:              cr.Height,
+		BackgroundPath: cardBackgroundPath(channelID),
+		LogoPath:       cardLogoPath(channelID),
+		Title:          cr.Title,
+		Playouts:       cr.Playouts,
+		Template:       t,
+		Now:            time.Now(),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to generate card: %w", err)
+		// The holding slate stands in for a card that can't be drawn.
+		slatePath, _ := assetPath(channelID, AssetSlate)
+		slate, slateErr := gg.LoadImage(slatePath)
+		if slateErr != nil {
+			return fmt.Errorf("failed to generate card: %w", err)
+		}
+		log.Printf("channel %d using holding slate: failed to generate card: %v", channelID, err)
+		im = slate
+	}
+	err = gg.SavePNG(dstImgPath, im)
+	if err != nil {
+		return fmt.Errorf("failed to save png: %w", err)
 	}
 
-	dstVidPath := fmt.Sprintf("assets/ch/%d-card-continuity.mp4", channelID)
+	dstVidPath := continuityVideoPath(channelID)
 	err = ffmpeg.NewVideoFromSingleImage(ctx, dstImgPath, dstVidPath)
 	if err != nil {
 		return fmt.Errorf("failed to create video from card image: %w", err)
 	}
+	return nil
+}
 
-	cardURI := mcr.baseServeURL.ResolveReference(&url.URL{Path: dstVidPath})
-	i, err := mcr.brave.NewURIInput(ctx, cardURI.String(), true)
+// cardSwap is a continuity input swapped in for a channel's old one, see
+// swapContinuityInput.
+type cardSwap struct {
+	mixerID    int
+	inputID    int
+	oldInputID int
+	// inMixer is whether the new input was put in the mixer's program.
+	inMixer bool
+}
+
+// newContinuityInput creates an input of a channel's continuity card and waits
+// for Brave to play it, returning its ID. Nothing uses it until it's swapped
+// in, so the channel needn't be locked.
+func (mcr *MCR) newContinuityInput(ctx context.Context, channelID int) (int, error) {
+	// The card is silent.
+	i, err := mcr.brave.NewURIInput(ctx, mcr.continuityCardURI(channelID), true, 1)
 	if err != nil {
-		return fmt.Errorf("failed to create image input in brave: %w", err)
+		return 0, fmt.Errorf("failed to create image input in brave: %w", err)
 	}
 	err = mcr.brave.PlayInput(ctx, i.ID)
 	if err != nil {
-		return fmt.Errorf("failed to play input: %w", err)
+		mcr.discardInput(ctx, i.ID)
+		return 0, fmt.Errorf("failed to play input: %w", err)
+	}
+	err = waitForBrave(ctx, func() (bool, error) {
+		i, err = mcr.brave.GetInput(ctx, i.ID)
+		if err != nil {
+			return false, err
+		}
+		if i.Failed() {
+			return false, fmt.Errorf("input %d failed", i.ID)
+		}
+		return i.State == brave.StatePlaying, nil
+	})
+	if err != nil {
+		mcr.discardInput(ctx, i.ID)
+		return 0, fmt.Errorf("failed waiting for input to play: %w", err)
+	}
+	return i.ID, nil
+}
+
+// swapContinuityInput makes a playing input from newContinuityInput an on-air
+// channel's continuity input. The input is discarded and an empty swap
+// returned when the channel isn't on-air. The caller must hold the channel's
+// lock.
+//
+// When the channel is showing continuity the new input is put over the old one
+// whatever the channel's transition, so nothing shows between the cards. An
+// audio-only channel's new card is put over whatever is on. The old input is
+// left for finishCardSwap.
+func (mcr *MCR) swapContinuityInput(ctx context.Context, channelID int, inputID int) (cardSwap, error) {
+	cr, err := mcr.getChannelRundown(ctx, channelID)
+	if err != nil {
+		mcr.discardInput(ctx, inputID)
+		return cardSwap{}, fmt.Errorf("failed to get channel rundown: %w", err)
+	}
+	if cr.Status != "on-air" {
+		mcr.discardInput(ctx, inputID)
+		return cardSwap{}, nil
 	}
 
 	// If there is no currently an input or the continuity card is on, update
 	// channel's program.
-	if cr.ProgramInputID == 0 || cr.ProgramInputID == cr.ContinuityInputID {
-		time.Sleep(time.Millisecond * 2500)
-		err = mcr.setChannelProgram(ctx, channelID, i.ID)
+	s := cardSwap{mixerID: cr.MixerID, inputID: inputID, oldInputID: cr.ContinuityInputID}
+	switch {
+	case cr.ProgramInputID == 0 || cr.ProgramInputID == cr.ContinuityInputID:
+		err = mcr.setChannelProgram(ctx, channelID, inputID, transition{Type: transitionSeamless}, "continuity card refreshed")
 		if err != nil {
-			return fmt.Errorf("failed to set channel program: %w", err)
+			mcr.discardInput(ctx, inputID)
+			return cardSwap{}, fmt.Errorf("failed to set channel program: %w", err)
 		}
-		time.Sleep(time.Millisecond * 500)
-		err = mcr.brave.PauseInput(ctx, i.ID)
+		s.inMixer = true
+	case cr.AudioOnly:
+		// An audio-only channel's card is over whatever is on, the old card
+		// is taken out of the mixer when it is deleted.
+		err = mcr.brave.OverlayInputOnMixer(ctx, cr.MixerID, inputID)
 		if err != nil {
-			return fmt.Errorf("failed to pause input: %w", err)
+			mcr.discardInput(ctx, inputID)
+			return cardSwap{}, fmt.Errorf("failed to overlay input: %w", err)
 		}
+		s.inMixer = true
 	}
 
-	err = mcr.updateContinuityInput(ctx, channelID, i.ID)
+	err = mcr.updateContinuityInput(ctx, channelID, inputID)
 	if err != nil {
-		return fmt.Errorf("failed to update continuity input in store: %w", err)
+		return cardSwap{}, fmt.Errorf("failed to update continuity input in store: %w", err)
 	}
+	return s, nil
+}
 
-	if cr.ContinuityInputID != 0 {
-		// Delete the old continuity input
-		err = mcr.brave.DeleteInput(ctx, cr.ContinuityInputID)
+// finishCardSwap pauses a swapped in continuity input once it's in the
+// mixer's program, so the card holds still, and then deletes the old one. The
+// store no longer has the old input, so the channel needn't be locked.
+func (mcr *MCR) finishCardSwap(ctx context.Context, s cardSwap) error {
+	if s.inMixer {
+		err := waitForBrave(ctx, func() (bool, error) {
+			m, err := mcr.brave.GetMixer(ctx, s.mixerID)
+			if err != nil {
+				return false, err
+			}
+			return inProgram(m, s.inputID), nil
+		})
 		if err != nil {
-			return fmt.Errorf("failed to delete input in brave: %w", err)
+			return fmt.Errorf("failed waiting for mixer to cut: %w", err)
+		}
+		err = mcr.brave.PauseInput(ctx, s.inputID)
+		if err != nil {
+			return fmt.Errorf("failed to pause input: %w", err)
 		}
 	}
 
+	if s.oldInputID != 0 {
+		// Delete the old continuity input
+		err := mcr.brave.DeleteInput(ctx, s.oldInputID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete input in brave: %w", err)
+		}
+	}
 	return nil
 }
 
+// discardInput deletes an input that won't be used, the reconciler cleans it up
+// if it can't be.
+func (mcr *MCR) discardInput(ctx context.Context, inputID int) {
+	err := mcr.brave.DeleteInput(ctx, inputID)
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
+		log.Printf("failed to delete input %d: %v", inputID, err)
+	}
+}
+
+// continuityCardURI is where Brave pulls a channel's continuity card from.
+func (mcr *MCR) continuityCardURI(channelID int) string {
+	return mcr.baseServeURL.ResolveReference(&url.URL{Path: continuityVideoPath(channelID)}).String()
+}
+
+func continuityVideoPath(channelID int) string {
+	return path.Join(channelAssetsDir, fmt.Sprintf("%d-card-continuity.mp4", channelID))
+}
+
+func cardBackgroundPath(channelID int) string {
+	return path.Join(channelAssetsDir, fmt.Sprintf("%d-card-bg.jpg", channelID))
+}
+
+func cardLogoPath(channelID int) string {
+	return path.Join(channelAssetsDir, fmt.Sprintf("%d-card-logo.png", channelID))
+}
+
 func (mcr *MCR) getChannelRundown(ctx context.Context, channelID int) (channelRundown, error) {
 	cr := channelRundown{}
 	err := mcr.db.GetContext(ctx, &cr, `
-		SELECT title, res_width, res_height, mixer_id, program_input_id,
-		continuity_input_id
+		SELECT title, status, res_width, res_height, mixer_id, program_input_id,
+		continuity_input_id, audio_only
 		FROM mcr.channels
 		WHERE channel_id = $1;
 	`, channelID)
@@ -145,66 +281,168 @@ func (mcr *MCR) updateContinuityInput(ctx context.Context, channelID int, inputI
 	return err
 }
 
-func newContinuityCard(card newContinuityCardParams) error {
-	dc := gg.NewContext(card.X, card.Y)
-
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", 96); err != nil {
-		return fmt.Errorf("failed to load font face: %w", err)
+// newContinuityCard draws a channel's continuity card. Text too wide for the
+// card is wrapped or truncated, and upcoming playouts that don't fit above the
+// message are left off.
+func newContinuityCard(card newContinuityCardParams) (image.Image, error) {
+	t := card.Template
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
 	}
+	// Sizes are for 1080 lines.
+	scale := float64(card.Y) / 1080
+	x, y := float64(card.X), float64(card.Y)
+	margin := y / 24
+
+	dc := gg.NewContext(card.X, card.Y)
+	dc.SetHexColor(t.BackgroundColour)
+	dc.Clear()
 
 	im, err := gg.LoadImage(card.BackgroundPath)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to load background image: %w", err)
+			return nil, fmt.Errorf("failed to load background image: %w", err)
 		}
 	} else {
 		dc.DrawImage(im, 0, 0)
 	}
 
-	dc.SetRGB(1, 1, 1)
-	dc.DrawStringAnchored(card.Title+" - We're not on-air right now", float64(card.X)/2, float64(card.Y)/4, 0.5, 0.5)
+	if t.LogoPosition != LogoNone {
+		err = drawCardLogo(dc, card.LogoPath, t.LogoPosition, margin)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dc.SetHexColor(t.TextColour)
+	if err := dc.LoadFontFace(t.FontPath, float64(t.HeadingFontSize)*scale); err != nil {
+		return nil, fmt.Errorf("failed to load font face: %w", err)
+	}
+	heading := strings.ReplaceAll(t.Heading, "{channel}", card.Title)
+	drawCardLines(dc, wrapCardText(dc, heading, x-2*margin, 2), x/2, y/4)
 
-	if err := dc.LoadFontFace("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", 50); err != nil {
-		return fmt.Errorf("failed to load font face: %w", err)
+	if err := dc.LoadFontFace(t.FontPath, float64(t.BodyFontSize)*scale); err != nil {
+		return nil, fmt.Errorf("failed to load font face: %w", err)
 	}
+	lineHeight := lineSpacing*scale + dc.FontHeight()
 
 	if len(card.Playouts) != 0 {
-		dc.DrawStringAnchored("Upcoming content", float64(card.X)/2, float64(card.Y)/2-float64(card.Y)/8, 0.5, 0.5)
+		drawCardLines(dc, wrapCardText(dc, t.Upcoming, x-2*margin, 1), x/2, y/2-y/8)
 	} else {
-		dc.DrawStringAnchored("No content scheduled right now, check back soon!", float64(card.X)/2, float64(card.Y)/2+float64(card.Y)/8, 0.5, 0.5)
+		drawCardLines(dc, wrapCardText(dc, t.Empty, x-2*margin, 2), x/2, y/2+y/8)
 	}
 
-	yPos := float64(card.Y) / 2
-
-	for _, po := range card.Playouts {
-		playout := po.Title
-		dc.DrawStringAnchored(playout, float64(card.X)/4, yPos, 0.5, 0.5)
-		yPos += lineSpacing + dc.FontHeight()
+	// Entries stop before the message.
+	message := wrapCardText(dc, t.Message, x-2*margin, 2)
+	messageY := y/2 + y/3
+	maxY := messageY - float64(len(message))*lineHeight
+	columnWidth := x/2 - 2*margin
+	titleLines := 1
+	if t.TitleOverflow == TitleWrap {
+		titleLines = 2
 	}
+	yPos := y / 2
+	now := card.Now.In(loc)
+	for n, po := range card.Playouts {
+		if n == t.MaxEntries {
+			break
+		}
+		title := wrapCardText(dc, po.Title, columnWidth, titleLines)
+		if yPos+float64(len(title)-1)*lineHeight > maxY {
+			break
+		}
 
-	yPos = float64(card.Y) / 2
+		start := po.ScheduledStart.In(loc)
+		day := start.Format(t.DateFormat)
+		if dateEqual(start, now) {
+			day = t.Today
+		}
+		when := fitCardText(dc, day+" - "+start.Format(t.TimeFormat), columnWidth)
 
-	for _, po := range card.Playouts {
-		var playout string
-		if dateEqual(po.ScheduledStart, time.Now()) {
-			playout = po.ScheduledStart.Format("Today - 3:04PM")
-		} else {
-			playout = po.ScheduledStart.Format("2 January - 3:04PM")
+		for _, line := range title {
+			dc.DrawStringAnchored(line, x/4, yPos, 0.5, 0.5)
+			yPos += lineHeight
 		}
-		dc.DrawStringAnchored(playout, float64(card.X)/2+float64(card.X)/4, yPos, 0.5, 0.5)
-		yPos += lineSpacing + dc.FontHeight()
+		dc.DrawStringAnchored(when, x/2+x/4, yPos-float64(len(title))*lineHeight, 0.5, 0.5)
 	}
 
-	dc.DrawStringAnchored(card.Message, float64(card.X)/2, float64(card.Y)/2+float64(card.Y)/3, 0.5, 0.5)
+	drawCardLines(dc, message, x/2, messageY)
 
-	err = dc.SavePNG(card.DestinationPath)
+	return dc.Image(), nil
+}
+
+// drawCardLogo draws the channel's logo in a corner of the card, an eighth of
+// its height, if it has one.
+func drawCardLogo(dc *gg.Context, logoPath, position string, margin float64) error {
+	logo, err := gg.LoadImage(logoPath)
 	if err != nil {
-		return fmt.Errorf("failed to save png: %w", err)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to load logo image: %w", err)
 	}
+	b := logo.Bounds()
+	f := float64(dc.Height()) / 8 / float64(b.Dy())
+	w, h := float64(b.Dx())*f, float64(b.Dy())*f
 
+	lx, ly := margin, margin
+	if position == LogoTopRight || position == LogoBottomRight {
+		lx = float64(dc.Width()) - margin - w
+	}
+	if position == LogoBottomLeft || position == LogoBottomRight {
+		ly = float64(dc.Height()) - margin - h
+	}
+	dc.Push()
+	dc.Scale(f, f)
+	dc.DrawImage(logo, int(lx/f), int(ly/f))
+	dc.Pop()
 	return nil
 }
 
+// drawCardLines draws lines of text centred on a point.
+func drawCardLines(dc *gg.Context, lines []string, x, y float64) {
+	lineHeight := dc.FontHeight() * 1.2
+	y -= float64(len(lines)-1) * lineHeight / 2
+	for _, line := range lines {
+		dc.DrawStringAnchored(line, x, y, 0.5, 0.5)
+		y += lineHeight
+	}
+}
+
+// wrapCardText wraps text to a width, truncating the last of maxLines.
+func wrapCardText(dc *gg.Context, s string, width float64, maxLines int) []string {
+	if s == "" {
+		return nil
+	}
+	lines := dc.WordWrap(s, width)
+	if len(lines) > maxLines {
+		lines[maxLines-1] = strings.Join(lines[maxLines-1:], " ")
+		lines = lines[:maxLines]
+	}
+	for i := range lines {
+		// A single word can still be too wide.
+		lines[i] = fitCardText(dc, lines[i], width)
+	}
+	return lines
+}
+
+// fitCardText truncates text with an ellipsis so it is no wider than width.
+func fitCardText(dc *gg.Context, s string, width float64) string {
+	if w, _ := dc.MeasureString(s); w <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 {
+		r = r[:len(r)-1]
+		t := strings.TrimSpace(string(r)) + "…"
+		if w, _ := dc.MeasureString(t); w <= width {
+			return t
+		}
+	}
+	return ""
+}
+
 func dateEqual(dateA, dateB time.Time) bool {
 	yearA, monthA, dayA := dateA.Date()
 	yearB, monthB, dayB := dateB.Date()
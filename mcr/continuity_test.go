@@ -0,0 +1,124 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/ystv/showtime/brave"
+)
+
+// expectRundown expects a channel's rundown to be got for its card.
+func expectRundown(mock sqlmock.Sqlmock, channelID int, status string, mixerID, programID, continuityID int) {
+	mock.ExpectQuery("SELECT title, status, res_width, res_height, mixer_id, program_input_id").
+		WithArgs(channelID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"title", "status", "res_width", "res_height", "mixer_id", "program_input_id",
+			"continuity_input_id", "audio_only",
+		}).AddRow("One", status, 1280, 720, mixerID, programID, continuityID, false))
+	mock.ExpectQuery("SELECT title, scheduled_start FROM mcr.playouts").
+		WithArgs(channelID).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "scheduled_start"}))
+}
+
+func TestPlayContinuityCard(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := WithAsRunSource(context.Background(), AsRunSourceCardRenderer)
+	m, err := b.NewMixer(ctx, brave.NewMixerParams{Width: 1280, Height: 720})
+	if err != nil {
+		t.Fatalf("failed to create mixer: %v", err)
+	}
+	old, err := b.NewURIInput(ctx, mcr.continuityCardURI(1), true, 1)
+	if err != nil {
+		t.Fatalf("failed to create continuity input: %v", err)
+	}
+	err = b.CutMixerToInput(ctx, m.ID, old.ID)
+	if err != nil {
+		t.Fatalf("failed to cut to continuity: %v", err)
+	}
+	// Brave counts IDs up.
+	newID := old.ID + 1
+
+	expectRundown(mock, 1, "on-air", m.ID, old.ID, old.ID)
+	mock.ExpectQuery("SELECT mixer_id, program_input_id, continuity_input_id, transition").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"mixer_id", "program_input_id", "continuity_input_id", "transition",
+			"transition_duration", "audio_only",
+		}).AddRow(m.ID, old.ID, old.ID, TransitionCut, 0, false))
+	mock.ExpectExec("UPDATE mcr.channels SET program_input_id = $1 WHERE channel_id = $2").
+		WithArgs(newID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT playout_id, title, scheduled_start FROM mcr.playouts").
+		WithArgs(1, newID).
+		WillReturnRows(sqlmock.NewRows([]string{"playout_id", "title", "scheduled_start"}))
+	mock.ExpectQuery("SELECT filler_input_id FROM mcr.channels").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"filler_input_id"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO mcr.as_run").
+		WithArgs(1, AsRunCut, ProgramContinuity, newID, 0, "", nil, AsRunSourceCardRenderer,
+			"continuity card refreshed").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE mcr.channels SET continuity_input_id = $1 WHERE channel_id = $2").
+		WithArgs(newID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	inputID, err := mcr.playContinuityCard(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to play continuity card: %v", err)
+	}
+	if inputID != newID {
+		t.Errorf("got continuity input %d, want %d", inputID, newID)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The channel went straight from one card to the other, which holds
+	// still.
+	want := []string{braveUID("input", newID)}
+	if got := programOf(t, b, m.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("program is %v, want %v", got, want)
+	}
+	i, err := b.GetInput(ctx, newID)
+	if err != nil {
+		t.Fatalf("failed to get new continuity input: %v", err)
+	}
+	if i.State != brave.StatePaused || i.URI != mcr.continuityCardURI(1) {
+		t.Errorf("new continuity input is %s playing %q, want paused playing the card", i.State, i.URI)
+	}
+	_, err = b.GetInput(ctx, old.ID)
+	if !errors.Is(err, brave.ErrNotFound) {
+		t.Errorf("old continuity input wasn't deleted: %v", err)
+	}
+}
+
+func TestPlayContinuityCardOffAir(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := context.Background()
+	// The channel went off-air whilst its card was being drawn.
+	expectRundown(mock, 1, "off-air", 0, 0, 0)
+
+	inputID, err := mcr.playContinuityCard(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to play continuity card: %v", err)
+	}
+	if inputID != 0 {
+		t.Errorf("got continuity input %d, want 0", inputID)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := b.ListInputs(ctx)
+	if err != nil {
+		t.Fatalf("failed to list inputs: %v", err)
+	}
+	if len(inputs) != 0 {
+		t.Errorf("%d inputs were left in brave", len(inputs))
+	}
+}
@@ -0,0 +1,147 @@
+package mcr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+
+	"github.com/ystv/showtime/metrics"
+)
+
+type (
+	// ChannelEventType is the value of a channel_events record's event_type
+	// field. This type must stay in sync with the check in the database.
+	ChannelEventType        string
+	ChannelEventWithoutData struct {
+		ID   int              `db:"channel_event_id" json:"channelEventID"`
+		Type ChannelEventType `db:"event_type" json:"type"`
+		Time time.Time        `db:"event_time" json:"time"`
+	}
+	// ChannelEvent is a channel_events record.
+	ChannelEvent struct {
+		ChannelEventWithoutData
+		Data ChannelEventPayload `db:"event_data" json:"data"`
+	}
+)
+
+const (
+	// EventCardRefreshed is when a channel's continuity card is redrawn and
+	// its input swapped in Brave.
+	EventCardRefreshed ChannelEventType = "card-refreshed"
+	// EventCardFailed is when a channel's continuity card couldn't be
+	// refreshed.
+	EventCardFailed ChannelEventType = "card-failed"
+	// EventFailover is when a channel is cut away from a live playout whose
+	// source has failed.
+	EventFailover ChannelEventType = "failover"
+	// EventFailback is when a channel is cut back to a live playout whose
+	// source has recovered.
+	EventFailback ChannelEventType = "failback"
+)
+
+// ChannelEventPayload is the type of all channel event payloads, used only for
+// type checking.
+type ChannelEventPayload interface {
+	isChannelEventPayload()
+}
+
+// UnmarshalChannelEventPayload unmarshals a JSON event payload into the
+// appropriate type.
+func UnmarshalChannelEventPayload(typ ChannelEventType, raw json.RawMessage) (ChannelEventPayload, error) {
+	var data ChannelEventPayload
+	switch typ {
+	case EventCardRefreshed:
+		data = &EventCardRefreshedPayload{}
+	case EventCardFailed:
+		data = &EventCardFailedPayload{}
+	case EventFailover:
+		data = &EventFailoverPayload{}
+	case EventFailback:
+		data = &EventFailbackPayload{}
+	default:
+		return nil, fmt.Errorf("unknown event type: %s", typ)
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+type EventCardRefreshedPayload struct {
+	ContinuityInputID int `json:"continuityInputID"`
+}
+
+func (EventCardRefreshedPayload) isChannelEventPayload() {}
+
+type EventCardFailedPayload struct {
+	Err string `json:"err"`
+}
+
+func (EventCardFailedPayload) isChannelEventPayload() {}
+
+type EventFailoverPayload struct {
+	PlayoutID int `json:"playoutID"`
+	InputID   int `json:"inputID"`
+	// To is the channel's failover, continuity or slate.
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+func (EventFailoverPayload) isChannelEventPayload() {}
+
+type EventFailbackPayload struct {
+	PlayoutID int `json:"playoutID"`
+	InputID   int `json:"inputID"`
+}
+
+func (EventFailbackPayload) isChannelEventPayload() {}
+
+// ListChannelEvents lists a channel's events, oldest first.
+func (mcr *MCR) ListChannelEvents(ctx context.Context, channelID int) ([]ChannelEvent, error) {
+	// JSONB is scanned as text, otherwise it would be base64 encoded.
+	var evts []struct {
+		ChannelEventWithoutData
+		Data types.JSONText `db:"event_data"`
+	}
+	err := mcr.db.SelectContext(ctx, &evts, `
+		SELECT channel_event_id, event_type, event_data, event_time
+		FROM mcr.channel_events
+		WHERE channel_id = $1
+		ORDER BY event_time ASC;
+	`, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	result := make([]ChannelEvent, 0, len(evts))
+	for _, evt := range evts {
+		data, err := UnmarshalChannelEventPayload(evt.Type, json.RawMessage(evt.Data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+		}
+		result = append(result, ChannelEvent{
+			ChannelEventWithoutData: evt.ChannelEventWithoutData,
+			Data:                    data,
+		})
+	}
+	return result, nil
+}
+
+func (mcr *MCR) createChannelEvent(ctx context.Context, channelID int, typ ChannelEventType, payload ChannelEventPayload) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		INSERT INTO mcr.channel_events (channel_id, event_type, event_data)
+		VALUES ($1, $2, $3::jsonb);
+	`, channelID, typ, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to insert event: %w", err)
+	}
+	metrics.ChannelEvents.WithLabelValues(string(typ)).Inc()
+	return nil
+}
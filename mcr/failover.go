@@ -0,0 +1,278 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+)
+
+// EditFailover sets what a channel fails over to.
+type EditFailover struct {
+	// Failover is continuity or slate.
+	Failover string `json:"failover"`
+}
+
+// Channel failover targets.
+const (
+	// FailoverContinuity cuts to the channel's continuity card.
+	FailoverContinuity = "continuity"
+	// FailoverSlate cuts to the channel's holding slate, or its continuity
+	// card when it doesn't have one.
+	FailoverSlate = "slate"
+)
+
+// failoverRetryInterval is how often a failed over playout's source is played
+// again, so the channel can fail back when it has recovered.
+const failoverRetryInterval = 5 * time.Second
+
+// ErrFailoverInvalid when a channel's failover isn't to continuity or a slate.
+var ErrFailoverInvalid = errors.New("failover is invalid")
+
+func validateFailover(failover string) error {
+	switch failover {
+	case FailoverContinuity, FailoverSlate:
+		return nil
+	}
+	return ErrFailoverInvalid
+}
+
+// SetChannelFailover sets what a channel fails over to from its next failover.
+func (mcr *MCR) SetChannelFailover(ctx context.Context, channelID int, f EditFailover) error {
+	err := validateFailover(f.Failover)
+	if err != nil {
+		return err
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			failover = $1
+		WHERE channel_id = $2;`, f.Failover, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update failover: %w", err)
+	}
+	return nil
+}
+
+// scheduleFailover fails a channel over when its live playout's source has
+// failed for FailoverTimeout, or straight away when it has gone, and back when
+// it is playing again. Whilst failed over the source is played every
+// failoverRetryInterval.
+func (mcr *MCR) scheduleFailover(ctx context.Context, now time.Time, po scheduledPlayout, inputs map[int]brave.Input) error {
+	i, ok := inputs[po.BraveInputID]
+	failedOver := po.FailoverPlayoutID == po.ID
+	onProgram := po.ProgramInputID == po.BraveInputID
+	switch {
+	case failedOver && onProgram:
+		// The reconciler has already cut back to the playout.
+		mcr.clearFailing(po.ID)
+		return mcr.clearFailover(ctx, po.ChannelID, po.SlateInputID)
+	case failedOver && ok && i.State == brave.StatePlaying:
+		return mcr.failback(ctx, po)
+	case failedOver:
+		since, _ := mcr.failing(po.ID)
+		if !ok || now.Sub(since) < failoverRetryInterval {
+			// The reconciler recreates missing inputs.
+			return nil
+		}
+		mcr.setFailing(po.ID, now)
+		err := mcr.PlayPlayoutSource(ctx, po.Playout)
+		if err != nil {
+			return fmt.Errorf("failed to retry playout %d: %w", po.ID, err)
+		}
+		return nil
+	case !onProgram:
+		mcr.clearFailing(po.ID)
+		return nil
+	case !ok:
+		// There's nothing to wait for, the reconciler recreates the input
+		// for the channel to fail back to.
+		return mcr.failover(ctx, now, po, "input gone")
+	case !i.Failed():
+		mcr.clearFailing(po.ID)
+		return nil
+	}
+
+	since, ok := mcr.failing(po.ID)
+	if !ok {
+		log.Printf("channel %d playout %d %q input failed, failing over in %s", po.ChannelID, po.ID, po.Title, mcr.failoverTimeout)
+		mcr.setFailing(po.ID, now)
+		return nil
+	}
+	if now.Sub(since) < mcr.failoverTimeout {
+		return nil
+	}
+	return mcr.failover(ctx, now, po, fmt.Sprintf("input failed for %s", mcr.failoverTimeout))
+}
+
+// failover cuts a channel away from its live playout to its failover, telling
+// programLost why.
+func (mcr *MCR) failover(ctx context.Context, now time.Time, po scheduledPlayout, reason string) error {
+	to := FailoverContinuity
+	inputID := po.ContinuityInputID
+	slateInputID := 0
+	if po.Failover == FailoverSlate {
+		var err error
+		slateInputID, err = mcr.newSlateInput(ctx, po.ChannelID)
+		if err != nil {
+			log.Printf("channel %d failing over to continuity: %v", po.ChannelID, err)
+		} else {
+			to = FailoverSlate
+			inputID = slateInputID
+		}
+	}
+	if inputID == 0 {
+		return fmt.Errorf("channel %d has nothing to fail over to", po.ChannelID)
+	}
+
+	log.Printf("channel %d playout %d %q %s, failing over to %s", po.ChannelID, po.ID, po.Title, reason, to)
+	err := mcr.setChannelProgram(ctx, po.ChannelID, inputID, cut, "failover: "+reason)
+	if err != nil {
+		if slateInputID != 0 {
+			mcr.discardInput(ctx, slateInputID)
+		}
+		return fmt.Errorf("failed to fail over: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			failover_playout_id = $1,
+			slate_input_id = $2
+		WHERE channel_id = $3;`, po.ID, slateInputID, po.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to update failover in store: %w", err)
+	}
+	// From now it is when the source was last played again.
+	mcr.setFailing(po.ID, now)
+
+	err = mcr.createChannelEvent(ctx, po.ChannelID, EventFailover, EventFailoverPayload{
+		PlayoutID: po.ID,
+		InputID:   po.BraveInputID,
+		To:        to,
+		Reason:    reason,
+	})
+	if err != nil {
+		log.Printf("failed to log failover event: %v", err)
+	}
+	if mcr.programLost != nil {
+		ch, err := mcr.GetChannel(ctx, po.ChannelID)
+		if err != nil {
+			return err
+		}
+		mcr.programLost(ctx, ch, po.Playout, reason+", failed over to "+to)
+	}
+	return nil
+}
+
+// failback cuts a channel back to the live playout it failed over from.
+func (mcr *MCR) failback(ctx context.Context, po scheduledPlayout) error {
+	log.Printf("channel %d playout %d %q input recovered, failing back", po.ChannelID, po.ID, po.Title)
+	err := mcr.setChannelProgram(ctx, po.ChannelID, po.BraveInputID, cut, "failback: input recovered")
+	if err != nil {
+		return fmt.Errorf("failed to fail back: %w", err)
+	}
+	mcr.clearFailing(po.ID)
+	err = mcr.clearFailover(ctx, po.ChannelID, po.SlateInputID)
+	if err != nil {
+		return err
+	}
+
+	err = mcr.createChannelEvent(ctx, po.ChannelID, EventFailback, EventFailbackPayload{
+		PlayoutID: po.ID,
+		InputID:   po.BraveInputID,
+	})
+	if err != nil {
+		log.Printf("failed to log failback event: %v", err)
+	}
+	return nil
+}
+
+// endFailover clears a channel's failover when it is from a playout that is
+// ending, cutting to continuity when the slate is still on. It returns whether
+// the channel had failed over from the playout, so is already off it.
+func (mcr *MCR) endFailover(ctx context.Context, po Playout, reason string) (bool, error) {
+	mcr.clearFailing(po.ID)
+
+	ch := struct {
+		FailoverPlayoutID int `db:"failover_playout_id"`
+		ProgramInputID    int `db:"program_input_id"`
+		ContinuityInputID int `db:"continuity_input_id"`
+		SlateInputID      int `db:"slate_input_id"`
+	}{}
+	err := mcr.db.GetContext(ctx, &ch, `
+		SELECT failover_playout_id, program_input_id, continuity_input_id,
+					 slate_input_id
+		FROM mcr.channels
+		WHERE channel_id = $1;`, po.ChannelID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get failover: %w", err)
+	}
+	if ch.FailoverPlayoutID != po.ID {
+		return false, nil
+	}
+	if ch.ProgramInputID == po.BraveInputID {
+		// The reconciler has already cut back to the playout.
+		return false, mcr.clearFailover(ctx, po.ChannelID, ch.SlateInputID)
+	}
+	if ch.SlateInputID != 0 && ch.ProgramInputID == ch.SlateInputID {
+		err = mcr.setChannelProgram(ctx, po.ChannelID, ch.ContinuityInputID, cut, "playout ended: "+reason)
+		if err != nil {
+			return false, fmt.Errorf("failed to set channel program to continuity: %w", err)
+		}
+	}
+	return true, mcr.clearFailover(ctx, po.ChannelID, ch.SlateInputID)
+}
+
+// clearFailover deletes a channel's slate input and forgets its failover.
+func (mcr *MCR) clearFailover(ctx context.Context, channelID int, slateInputID int) error {
+	if slateInputID != 0 {
+		mcr.discardInput(ctx, slateInputID)
+	}
+	_, err := mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			failover_playout_id = 0,
+			slate_input_id = 0
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to clear failover in store: %w", err)
+	}
+	return nil
+}
+
+// newSlateInput creates an input of a channel's holding slate.
+func (mcr *MCR) newSlateInput(ctx context.Context, channelID int) (int, error) {
+	uri, err := mcr.assetURI(channelID, AssetSlate)
+	if err != nil {
+		return 0, err
+	}
+	if uri == "" {
+		return 0, errors.New("no holding slate")
+	}
+	i, err := mcr.brave.NewImageInput(ctx, uri)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create image input in brave: %w", err)
+	}
+	return i.ID, nil
+}
+
+// failing returns when a playout's source was seen failing, or last played
+// again whilst failed over.
+func (mcr *MCR) failing(playoutID int) (time.Time, bool) {
+	mcr.failingMu.Lock()
+	defer mcr.failingMu.Unlock()
+	since, ok := mcr.failingSince[playoutID]
+	return since, ok
+}
+
+func (mcr *MCR) setFailing(playoutID int, since time.Time) {
+	mcr.failingMu.Lock()
+	defer mcr.failingMu.Unlock()
+	mcr.failingSince[playoutID] = since
+}
+
+func (mcr *MCR) clearFailing(playoutID int) {
+	mcr.failingMu.Lock()
+	defer mcr.failingMu.Unlock()
+	delete(mcr.failingSince, playoutID)
+}
@@ -0,0 +1,314 @@
+package mcr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/metrics"
+)
+
+type (
+	// Filler is media played on a channel between its scheduled playouts.
+	Filler struct {
+		ID        int           `db:"filler_id" json:"fillerID"`
+		ChannelID int           `db:"channel_id" json:"channelID"`
+		Position  int           `db:"position" json:"position"`
+		MediaID   int           `db:"media_id" json:"mediaID"`
+		Title     string        `db:"title" json:"title"`
+		Duration  time.Duration `db:"duration" json:"duration"`
+	}
+	// EditFillers replaces a channel's filler playlist.
+	EditFillers struct {
+		// Order defaults to FillerOrdered.
+		Order    string `json:"order"`
+		MediaIDs []int  `json:"mediaIDs"`
+	}
+	// fillerChannel is what the scheduler needs to fill an on-air channel.
+	fillerChannel struct {
+		ID                int    `db:"channel_id"`
+		ProgramInputID    int    `db:"program_input_id"`
+		ContinuityInputID int    `db:"continuity_input_id"`
+		FillerOrder       string `db:"filler_order"`
+		FillerInputID     int    `db:"filler_input_id"`
+		LastFillerID      int    `db:"last_filler_id"`
+		// NextStart is the start of the next scheduled playout, if there
+		// is one.
+		NextStart   sql.NullTime `db:"next_start"`
+		PlayoutLive bool         `db:"playout_live"`
+		Audio       AudioLevel   `db:"filler"`
+	}
+)
+
+// Filler playlist orders.
+const (
+	// FillerOrdered plays fillers in turn.
+	FillerOrdered = "ordered"
+	// FillerShuffled plays fillers at random, not repeating the last one.
+	FillerShuffled = "shuffled"
+)
+
+// ErrFillerOrderInvalid when the filler order isn't ordered or shuffled.
+var ErrFillerOrderInvalid = errors.New("filler order is invalid")
+
+// ListChannelFillers returns a channel's filler playlist in order.
+func (mcr *MCR) ListChannelFillers(ctx context.Context, channelID int) ([]Filler, error) {
+	f := []Filler{}
+	err := mcr.db.SelectContext(ctx, &f, `
+		SELECT f.filler_id, f.channel_id, f.position, f.media_id, m.title, m.duration
+		FROM mcr.fillers f
+		INNER JOIN mcr.media m ON m.media_id = f.media_id
+		WHERE f.channel_id = $1
+		ORDER BY f.position;`, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fillers: %w", err)
+	}
+	return f, nil
+}
+
+// SetChannelFillers replaces a channel's filler playlist, an empty one leaves
+// the continuity card on between playouts.
+func (mcr *MCR) SetChannelFillers(ctx context.Context, channelID int, f EditFillers) error {
+	if f.Order == "" {
+		f.Order = FillerOrdered
+	}
+	if f.Order != FillerOrdered && f.Order != FillerShuffled {
+		return ErrFillerOrderInvalid
+	}
+	for _, mediaID := range f.MediaIDs {
+		_, err := mcr.GetMedia(ctx, mediaID)
+		if err != nil {
+			return fmt.Errorf("media %d: %w", mediaID, err)
+		}
+	}
+
+	_, err := mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels
+		SET filler_order = $1
+		WHERE channel_id = $2;`, f.Order, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update filler order: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		DELETE FROM mcr.fillers
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to delete fillers: %w", err)
+	}
+	for i, mediaID := range f.MediaIDs {
+		_, err = mcr.db.ExecContext(ctx, `
+			INSERT INTO mcr.fillers (channel_id, media_id, position)
+			VALUES ($1, $2, $3);`, channelID, mediaID, i)
+		if err != nil {
+			return fmt.Errorf("failed to insert filler: %w", err)
+		}
+	}
+	return nil
+}
+
+// scheduleFillers plays fillers on on-air channels that have a filler playlist
+// or are still playing a filler.
+func (mcr *MCR) scheduleFillers(ctx context.Context, now time.Time, getInputs func() (map[int]brave.Input, error)) error {
+	channels := []fillerChannel{}
+	err := mcr.db.SelectContext(ctx, &channels, `
+		SELECT c.channel_id, c.program_input_id, c.continuity_input_id,
+					 c.filler_order, c.filler_input_id, c.last_filler_id,
+					 c.filler_volume AS "filler.volume", c.filler_gain AS "filler.gain",
+					 c.filler_muted AS "filler.muted",
+					 (
+						 SELECT min(p.scheduled_start)
+						 FROM mcr.playouts p
+						 WHERE p.channel_id = c.channel_id
+						 AND p.status = 'scheduled'
+						 AND p.scheduled_end > $1
+					 ) AS next_start,
+					 EXISTS (
+						 SELECT 1
+						 FROM mcr.playouts p
+						 WHERE p.channel_id = c.channel_id
+						 AND p.status = 'live'
+					 ) AS playout_live
+		FROM mcr.channels c
+		WHERE c.status = 'on-air'
+		AND (
+			c.filler_input_id != 0
+			OR EXISTS (SELECT 1 FROM mcr.fillers f WHERE f.channel_id = c.channel_id)
+		)
+		ORDER BY c.channel_id;`, now)
+	if err != nil {
+		return fmt.Errorf("failed to get channels: %w", err)
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	inputs, err := getInputs()
+	if err != nil {
+		return err
+	}
+	channelIDs := make([]int, len(channels))
+	byID := make(map[int]fillerChannel, len(channels))
+	for i, ch := range channels {
+		channelIDs[i] = ch.ID
+		byID[ch.ID] = ch
+	}
+	mcr.eachChannel(channelIDs, func(channelID int) {
+		err := mcr.fillChannel(ctx, now, byID[channelID], inputs)
+		if err != nil {
+			log.Printf("failed to fill channel %d: %v", channelID, err)
+		}
+	})
+	return nil
+}
+
+// fillChannel looks after a channel's filler. A new filler is pre-rolled once
+// the continuity card has been on for the filler gap, and only if it will end
+// a filler gap before the next playout, otherwise the card stays on. The
+// channel is cut to the filler once it is playing and back to the card when it
+// ends. The caller must hold the channel's lock.
+func (mcr *MCR) fillChannel(ctx context.Context, now time.Time, ch fillerChannel, inputs map[int]brave.Input) error {
+	if ch.FillerInputID != 0 {
+		i, ok := inputs[ch.FillerInputID]
+		onAir := ch.ProgramInputID == ch.FillerInputID
+		var reason string
+		switch {
+		case !ok:
+			reason = "input gone"
+		case i.Ended():
+			reason = "input ended"
+		case i.Failed():
+			reason = "input failed"
+		case !onAir && ch.ProgramInputID != ch.ContinuityInputID:
+			// i.e. a playout has started.
+			reason = "program changed"
+		case !onAir && i.State == brave.StatePlaying:
+			log.Printf("channel %d cutting to filler", ch.ID)
+			return mcr.setChannelProgram(ctx, ch.ID, ch.FillerInputID, transition{}, "filler playing")
+		default:
+			return nil
+		}
+		return mcr.stopFiller(ctx, now, ch, onAir, reason)
+	}
+
+	if ch.PlayoutLive || ch.ContinuityInputID == 0 || ch.ProgramInputID != ch.ContinuityInputID {
+		return nil
+	}
+	mcr.stateMu.Lock()
+	cardSince := mcr.cardSince[ch.ID]
+	mcr.stateMu.Unlock()
+	if now.Before(cardSince.Add(mcr.fillerGap)) {
+		return nil
+	}
+
+	fillers, err := mcr.ListChannelFillers(ctx, ch.ID)
+	if err != nil {
+		return err
+	}
+	f, ok := mcr.pickFiller(now, ch, fillers)
+	if !ok {
+		return nil
+	}
+	m, err := mcr.GetMedia(ctx, f.MediaID)
+	if err != nil {
+		return fmt.Errorf("failed to get filler media: %w", err)
+	}
+	i, err := mcr.brave.NewURIInput(ctx, m.URI, false, ch.Audio.braveVolume())
+	if err != nil {
+		return fmt.Errorf("failed to create filler input: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			filler_input_id = $1,
+			last_filler_id = $2
+		WHERE channel_id = $3;`, i.ID, f.ID, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update filler in store: %w", err)
+	}
+	err = mcr.brave.PlayInput(ctx, i.ID)
+	if err != nil {
+		return fmt.Errorf("failed to play filler input: %w", err)
+	}
+	log.Printf("channel %d pre-rolling filler %d %q", ch.ID, f.ID, f.Title)
+	metrics.SchedulerActions.WithLabelValues(scheduleFiller).Inc()
+	return nil
+}
+
+// stopFiller removes a channel's filler, cutting back to the continuity card
+// if it is on-air.
+func (mcr *MCR) stopFiller(ctx context.Context, now time.Time, ch fillerChannel, onAir bool, reason string) error {
+	log.Printf("channel %d stopping filler: %s", ch.ID, reason)
+	if onAir {
+		// Only a filler that played to its end is transitioned away from.
+		t := cut
+		if reason == "input ended" {
+			t = transition{}
+		}
+		err := mcr.setChannelProgram(ctx, ch.ID, ch.ContinuityInputID, t, "filler stopped: "+reason)
+		if err != nil {
+			return fmt.Errorf("failed to cut to continuity: %w", err)
+		}
+	}
+	err := mcr.brave.DeleteInput(ctx, ch.FillerInputID)
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
+		return fmt.Errorf("failed to delete filler input: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels
+		SET filler_input_id = 0
+		WHERE channel_id = $1;`, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update filler in store: %w", err)
+	}
+	mcr.stateMu.Lock()
+	mcr.cardSince[ch.ID] = now
+	mcr.stateMu.Unlock()
+	return nil
+}
+
+// pickFiller chooses the next filler that fits before the next playout.
+func (mcr *MCR) pickFiller(now time.Time, ch fillerChannel, fillers []Filler) (Filler, bool) {
+	fits := func(f Filler) bool {
+		return !ch.NextStart.Valid || !now.Add(f.Duration+mcr.fillerGap).After(ch.NextStart.Time)
+	}
+
+	if ch.FillerOrder == FillerShuffled {
+		candidates := []Filler{}
+		for _, f := range fillers {
+			if fits(f) {
+				candidates = append(candidates, f)
+			}
+		}
+		if len(candidates) > 1 {
+			for i, f := range candidates {
+				if f.ID == ch.LastFillerID {
+					candidates = append(candidates[:i], candidates[i+1:]...)
+					break
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			return Filler{}, false
+		}
+		return candidates[rand.Intn(len(candidates))], true
+	}
+
+	// Carry on from the last filler, skipping ones that are too long.
+	start := 0
+	for i, f := range fillers {
+		if f.ID == ch.LastFillerID {
+			start = i + 1
+		}
+	}
+	for n := range fillers {
+		f := fillers[(start+n)%len(fillers)]
+		if fits(f) {
+			return f, true
+		}
+	}
+	return Filler{}, false
+}
@@ -0,0 +1,703 @@
+package mcr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+)
+
+type (
+	// ChannelGraphics are what is drawn over a channel's program whilst it is
+	// on-air, besides its lower thirds.
+	ChannelGraphics struct {
+		// BugVisible shows the channel's logo in the BugPosition corner, when
+		// it has one.
+		BugVisible  bool   `db:"bug_visible" json:"bugVisible" form:"bugVisible"`
+		BugPosition string `db:"bug_position" json:"bugPosition" form:"bugPosition"`
+		// ClockVisible shows the time in the ClockPosition corner.
+		ClockVisible  bool   `db:"clock_visible" json:"clockVisible" form:"clockVisible"`
+		ClockPosition string `db:"clock_position" json:"clockPosition" form:"clockPosition"`
+		// NextUp shows a strap NextUpLead before each playout starts, with
+		// {title} in NextUpText replaced with the playout's title.
+		NextUp     bool          `db:"next_up" json:"nextUp" form:"nextUp"`
+		NextUpLead time.Duration `db:"next_up_lead" json:"nextUpLead"`
+		NextUpText string        `db:"next_up_text" json:"nextUpText" form:"nextUpText"`
+	}
+	// LowerThird is a strap of text shown along the bottom of a channel,
+	// during a playout or on demand.
+	LowerThird struct {
+		ID        int `db:"lower_third_id" json:"lowerThirdID"`
+		ChannelID int `db:"channel_id" json:"channelID"`
+		// PlayoutID is the playout it is shown during, 0 when it is only
+		// shown on demand.
+		PlayoutID    int    `db:"playout_id" json:"playoutID,omitempty"`
+		PlayoutTitle string `db:"playout_title" json:"playoutTitle,omitempty"`
+		// Text is shown on the strap, the playout's title when it is empty.
+		Text string `db:"text" json:"text"`
+		// Offset is how long after the playout's scheduled start it is shown.
+		Offset time.Duration `db:"start_offset" json:"offset"`
+		// Duration is how long it is shown for, 0 until it is hidden or the
+		// playout ends.
+		Duration time.Duration `db:"duration" json:"duration"`
+		// ShownAt is when it was last shown on demand, nil when it hasn't
+		// been since it was hidden.
+		ShownAt *time.Time `db:"shown_at" json:"shownAt,omitempty"`
+		// Hidden stops it being shown during its playout, until it is next
+		// shown on demand.
+		Hidden bool `db:"hidden" json:"hidden"`
+		// Showing is whether it is due on the strap, the one due last is
+		// shown when there are several.
+		Showing bool `db:"-" json:"showing"`
+	}
+	// EditLowerThird creates a lower third.
+	EditLowerThird struct {
+		// PlayoutID is the channel's playout to show it during, 0 to only
+		// show it on demand.
+		PlayoutID int           `json:"playoutID" form:"playoutID"`
+		Text      string        `json:"text" form:"text"`
+		Offset    time.Duration `json:"offset"`
+		Duration  time.Duration `json:"duration"`
+	}
+
+	// lowerThirdState is a lower third and its playout, to tell when it is
+	// due.
+	lowerThirdState struct {
+		LowerThird
+		PlayoutStatus  string       `db:"playout_status"`
+		ScheduledStart sql.NullTime `db:"scheduled_start"`
+	}
+	// graphicsChannel is an on-air channel's graphics, the overlays drawing
+	// them and its next playout.
+	graphicsChannel struct {
+		ChannelGraphics
+		channelOverlays
+		ChannelID int          `db:"channel_id"`
+		MixerID   int          `db:"mixer_id"`
+		Width     int          `db:"res_width"`
+		Height    int          `db:"res_height"`
+		NextTitle string       `db:"next_title"`
+		NextStart sql.NullTime `db:"next_start"`
+	}
+	// channelOverlays are the Brave overlays drawing a channel's graphics, 0
+	// when they aren't drawn.
+	channelOverlays struct {
+		BugOverlayID   int `db:"bug_overlay_id"`
+		ClockOverlayID int `db:"clock_overlay_id"`
+		StrapOverlayID int `db:"strap_overlay_id"`
+	}
+	// shownGraphics is what a channel's overlays have been made to show.
+	shownGraphics struct {
+		mixerID int
+		// bug and clock are what their overlays were made from, empty when
+		// they aren't shown.
+		bug   string
+		clock string
+		// strap is the strap's text, empty when it is hidden.
+		strap string
+	}
+)
+
+// Text overlay font sizes, Brave scales text to the mixer's size.
+const (
+	clockFontSize = 18
+	strapFontSize = 24
+)
+
+var (
+	// ErrGraphicsInvalid when a channel's graphics or a lower third's timing
+	// are invalid.
+	ErrGraphicsInvalid = errors.New("graphics are invalid")
+	// ErrLowerThirdNotFound when a channel doesn't have the lower third.
+	ErrLowerThirdNotFound = errors.New("lower third not found")
+	// ErrLowerThirdTextEmpty when a lower third without a playout has no
+	// text.
+	ErrLowerThirdTextEmpty = errors.New("lower third text is empty")
+)
+
+// DefaultChannelGraphics are the graphics of a new channel, nothing is shown.
+func DefaultChannelGraphics() ChannelGraphics {
+	return ChannelGraphics{
+		BugPosition:   LogoTopRight,
+		ClockPosition: LogoTopLeft,
+		NextUpLead:    time.Minute,
+		NextUpText:    "Coming up next: {title}",
+	}
+}
+
+// withDefaults fills in the fields of a channel's graphics that can't be
+// empty.
+func (g ChannelGraphics) withDefaults() ChannelGraphics {
+	d := DefaultChannelGraphics()
+	if g.BugPosition == "" {
+		g.BugPosition = d.BugPosition
+	}
+	if g.ClockPosition == "" {
+		g.ClockPosition = d.ClockPosition
+	}
+	if g.NextUpLead == 0 {
+		g.NextUpLead = d.NextUpLead
+	}
+	if g.NextUpText == "" {
+		g.NextUpText = d.NextUpText
+	}
+	return g
+}
+
+// validate checks a channel's graphics can be drawn.
+func (g ChannelGraphics) validate() error {
+	for _, position := range []string{g.BugPosition, g.ClockPosition} {
+		switch position {
+		case LogoTopLeft, LogoTopRight, LogoBottomLeft, LogoBottomRight:
+		default:
+			return fmt.Errorf("%w: position %q isn't a corner", ErrGraphicsInvalid, position)
+		}
+	}
+	if g.NextUpLead < time.Second || g.NextUpLead > time.Hour {
+		return fmt.Errorf("%w: next up lead %s isn't between 1s and 1h", ErrGraphicsInvalid, g.NextUpLead)
+	}
+	return nil
+}
+
+// GetChannelGraphics returns a channel's graphics.
+func (mcr *MCR) GetChannelGraphics(ctx context.Context, channelID int) (ChannelGraphics, error) {
+	g := ChannelGraphics{}
+	err := mcr.db.GetContext(ctx, &g, `
+		SELECT bug_visible, bug_position, clock_visible, clock_position, next_up,
+					 next_up_lead, next_up_text
+		FROM mcr.channel_graphics
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return ChannelGraphics{}, fmt.Errorf("failed to get graphics: %w", err)
+	}
+	return g, nil
+}
+
+// SetChannelGraphics replaces a channel's graphics, empty fields that have to
+// be set use the defaults. An on-air channel's overlays are changed by the
+// scheduler on its next run.
+func (mcr *MCR) SetChannelGraphics(ctx context.Context, channelID int, g ChannelGraphics) error {
+	g = g.withDefaults()
+	err := g.validate()
+	if err != nil {
+		return err
+	}
+
+	ch, err := mcr.GetChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channel_graphics SET
+			bug_visible = $1,
+			bug_position = $2,
+			clock_visible = $3,
+			clock_position = $4,
+			next_up = $5,
+			next_up_lead = $6,
+			next_up_text = $7
+		WHERE channel_id = $8;`, g.BugVisible, g.BugPosition, g.ClockVisible,
+		g.ClockPosition, g.NextUp, g.NextUpLead, g.NextUpText, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update graphics: %w", err)
+	}
+	return nil
+}
+
+// newChannelGraphics adds the default graphics to a new channel.
+func (mcr *MCR) newChannelGraphics(ctx context.Context, channelID int) error {
+	_, err := mcr.db.ExecContext(ctx, `
+		INSERT INTO mcr.channel_graphics (channel_id)
+		VALUES ($1);`, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to insert graphics: %w", err)
+	}
+	return nil
+}
+
+// ListLowerThirds lists a channel's lower thirds, with whether they are due
+// on the strap.
+func (mcr *MCR) ListLowerThirds(ctx context.Context, ch Channel) ([]LowerThird, error) {
+	states := []lowerThirdState{}
+	err := mcr.db.SelectContext(ctx, &states, `
+		SELECT l.lower_third_id, l.channel_id, COALESCE(l.playout_id, 0) AS playout_id,
+					 COALESCE(p.title, '') AS playout_title, l.text, l.start_offset,
+					 l.duration, l.shown_at, l.hidden,
+					 COALESCE(p.status, '') AS playout_status, p.scheduled_start
+		FROM mcr.lower_thirds l
+		LEFT JOIN mcr.playouts p ON p.playout_id = l.playout_id
+		WHERE l.channel_id = $1
+		ORDER BY p.scheduled_start NULLS FIRST, l.start_offset, l.lower_third_id;`, ch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lower thirds: %w", err)
+	}
+	now := time.Now()
+	lowerThirds := make([]LowerThird, 0, len(states))
+	for _, l := range states {
+		_, due := l.due(now)
+		l.Showing = ch.Status == "on-air" && due
+		lowerThirds = append(lowerThirds, l.LowerThird)
+	}
+	return lowerThirds, nil
+}
+
+// NewLowerThird adds a lower third to a channel.
+func (mcr *MCR) NewLowerThird(ctx context.Context, ch Channel, l EditLowerThird) (int, error) {
+	if ch.Status == "archived" {
+		return 0, ErrChannelArchived
+	}
+	if l.Offset < 0 || l.Duration < 0 {
+		return 0, fmt.Errorf("%w: offset and duration can't be negative", ErrGraphicsInvalid)
+	}
+	var playoutID sql.NullInt64
+	if l.PlayoutID != 0 {
+		po, err := mcr.GetPlayout(ctx, l.PlayoutID)
+		if err != nil {
+			return 0, err
+		}
+		if po.ChannelID != ch.ID {
+			return 0, ErrPlayoutNotFound
+		}
+		playoutID = sql.NullInt64{Int64: int64(po.ID), Valid: true}
+	} else if strings.TrimSpace(l.Text) == "" {
+		return 0, ErrLowerThirdTextEmpty
+	}
+
+	lowerThirdID := 0
+	err := mcr.db.GetContext(ctx, &lowerThirdID, `
+		INSERT INTO mcr.lower_thirds (channel_id, playout_id, text, start_offset, duration)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING lower_third_id;`, ch.ID, playoutID, l.Text, l.Offset, l.Duration)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert lower third: %w", err)
+	}
+	return lowerThirdID, nil
+}
+
+// DeleteLowerThird removes a lower third from a channel, it is taken off the
+// strap by the scheduler.
+func (mcr *MCR) DeleteLowerThird(ctx context.Context, ch Channel, lowerThirdID int) error {
+	return mcr.updateLowerThird(ctx, ch, lowerThirdID, `
+		DELETE FROM mcr.lower_thirds
+		WHERE lower_third_id = $1
+		AND channel_id = $2;`)
+}
+
+// ShowLowerThird puts a lower third on an on-air channel's strap for its
+// duration, or until it is hidden.
+func (mcr *MCR) ShowLowerThird(ctx context.Context, ch Channel, lowerThirdID int) error {
+	if ch.Status != "on-air" {
+		return ErrChannelOffAir
+	}
+	return mcr.updateLowerThird(ctx, ch, lowerThirdID, `
+		UPDATE mcr.lower_thirds SET
+			shown_at = NOW(),
+			hidden = false
+		WHERE lower_third_id = $1
+		AND channel_id = $2;`)
+}
+
+// HideLowerThird takes a lower third off a channel's strap, it isn't shown
+// again during its playout unless it is shown on demand.
+func (mcr *MCR) HideLowerThird(ctx context.Context, ch Channel, lowerThirdID int) error {
+	return mcr.updateLowerThird(ctx, ch, lowerThirdID, `
+		UPDATE mcr.lower_thirds SET
+			shown_at = NULL,
+			hidden = true
+		WHERE lower_third_id = $1
+		AND channel_id = $2;`)
+}
+
+// updateLowerThird runs a query changing a channel's lower third, taking the
+// lower third and channel IDs.
+func (mcr *MCR) updateLowerThird(ctx context.Context, ch Channel, lowerThirdID int, query string) error {
+	res, err := mcr.db.ExecContext(ctx, query, lowerThirdID, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update lower third: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check lower third: %w", err)
+	}
+	if n == 0 {
+		return ErrLowerThirdNotFound
+	}
+	return nil
+}
+
+// due checks whether a lower third is due on the strap, returning since when.
+// Shown on demand it is due for its duration, otherwise it is due for its
+// duration from its offset whilst its playout is live.
+func (l lowerThirdState) due(now time.Time) (time.Time, bool) {
+	if l.ShownAt != nil && (l.Duration == 0 || now.Before(l.ShownAt.Add(l.Duration))) {
+		return *l.ShownAt, true
+	}
+	if l.Hidden || l.PlayoutStatus != "live" || !l.ScheduledStart.Valid {
+		return time.Time{}, false
+	}
+	start := l.ScheduledStart.Time.Add(l.Offset)
+	if now.Before(start) || (l.Duration != 0 && !now.Before(start.Add(l.Duration))) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// strapText is what a lower third shows.
+func (l LowerThird) strapText() string {
+	if l.Text != "" {
+		return l.Text
+	}
+	return l.PlayoutTitle
+}
+
+// scheduleGraphics draws the graphics of on-air channels. Their straps show
+// the lower third due last, or what is coming up next when none are.
+func (mcr *MCR) scheduleGraphics(ctx context.Context, now time.Time) error {
+	channels := []graphicsChannel{}
+	err := mcr.db.SelectContext(ctx, &channels, `
+		SELECT c.channel_id, c.mixer_id, c.res_width, c.res_height,
+					 g.bug_visible, g.bug_position, g.clock_visible, g.clock_position,
+					 g.next_up, g.next_up_lead, g.next_up_text, g.bug_overlay_id,
+					 g.clock_overlay_id, g.strap_overlay_id,
+					 COALESCE(n.title, '') AS next_title, n.scheduled_start AS next_start
+		FROM mcr.channels c
+		INNER JOIN mcr.channel_graphics g ON g.channel_id = c.channel_id
+		LEFT JOIN LATERAL (
+			SELECT p.title, p.scheduled_start
+			FROM mcr.playouts p
+			WHERE p.channel_id = c.channel_id
+			AND p.status = 'scheduled'
+			AND p.scheduled_start > $1
+			ORDER BY p.scheduled_start
+			LIMIT 1
+		) n ON true
+		WHERE c.status = 'on-air'
+		ORDER BY c.channel_id;`, now)
+	if err != nil {
+		return fmt.Errorf("failed to get channels: %w", err)
+	}
+	onAir := make(map[int]bool, len(channels))
+	for _, ch := range channels {
+		onAir[ch.ChannelID] = true
+	}
+	mcr.stateMu.Lock()
+	for channelID := range mcr.graphics {
+		if !onAir[channelID] {
+			delete(mcr.graphics, channelID)
+		}
+	}
+	mcr.stateMu.Unlock()
+	if len(channels) == 0 {
+		return nil
+	}
+
+	states := []lowerThirdState{}
+	err = mcr.db.SelectContext(ctx, &states, `
+		SELECT l.lower_third_id, l.channel_id, COALESCE(l.playout_id, 0) AS playout_id,
+					 COALESCE(p.title, '') AS playout_title, l.text, l.start_offset,
+					 l.duration, l.shown_at, l.hidden,
+					 COALESCE(p.status, '') AS playout_status, p.scheduled_start
+		FROM mcr.lower_thirds l
+		INNER JOIN mcr.channels c ON c.channel_id = l.channel_id
+		LEFT JOIN mcr.playouts p ON p.playout_id = l.playout_id
+		WHERE c.status = 'on-air'
+		AND (l.shown_at IS NOT NULL OR p.status = 'live');`)
+	if err != nil {
+		return fmt.Errorf("failed to get lower thirds: %w", err)
+	}
+	straps := map[int]string{}
+	dueSince := map[int]time.Time{}
+	for _, l := range states {
+		since, ok := l.due(now)
+		if ok && !since.Before(dueSince[l.ChannelID]) {
+			straps[l.ChannelID] = l.strapText()
+			dueSince[l.ChannelID] = since
+		}
+	}
+
+	channelIDs := make([]int, len(channels))
+	byID := make(map[int]graphicsChannel, len(channels))
+	for i, ch := range channels {
+		channelIDs[i] = ch.ChannelID
+		byID[ch.ChannelID] = ch
+	}
+	mcr.eachChannel(channelIDs, func(channelID int) {
+		ch := byID[channelID]
+		strap, ok := straps[ch.ChannelID]
+		if !ok && ch.NextUp && ch.NextStart.Valid && !now.Before(ch.NextStart.Time.Add(-ch.NextUpLead)) {
+			strap = strings.ReplaceAll(ch.NextUpText, "{title}", ch.NextTitle)
+		}
+		err := mcr.drawGraphics(ctx, ch, strap)
+		if err != nil {
+			log.Printf("failed to draw channel %d graphics: %v", ch.ChannelID, err)
+		}
+	})
+	return nil
+}
+
+// drawGraphics makes a channel's overlays show its graphics and strap, only
+// changing the overlays that need to. The caller must hold the channel's lock.
+//
+// Overlays that weren't drawn since ShowTime! started or the channel's mixer
+// was replaced are replaced, it isn't known what they show.
+func (mcr *MCR) drawGraphics(ctx context.Context, ch graphicsChannel, strap string) error {
+	overlays := ch.channelOverlays
+	mcr.stateMu.Lock()
+	shown, ok := mcr.graphics[ch.ChannelID]
+	mcr.stateMu.Unlock()
+	if !ok || shown.mixerID != ch.MixerID {
+		shown = shownGraphics{mixerID: ch.MixerID}
+		err := mcr.deleteOverlays(ctx, &overlays)
+		if err != nil {
+			return mcr.storeOverlays(ctx, ch, overlays, err)
+		}
+	}
+	err := mcr.drawOverlays(ctx, ch, strap, &overlays, &shown)
+	mcr.setShownGraphics(ch.ChannelID, &shown)
+	return mcr.storeOverlays(ctx, ch, overlays, err)
+}
+
+// drawOverlays changes the overlays that don't show what they should,
+// updating the overlays and what they show as it goes.
+func (mcr *MCR) drawOverlays(ctx context.Context, ch graphicsChannel, strap string, overlays *channelOverlays, shown *shownGraphics) error {
+	m := brave.Mixer{ID: ch.MixerID}
+
+	bug := ""
+	bugURI := ""
+	if ch.BugVisible {
+		var err error
+		bugURI, err = mcr.assetURI(ch.ChannelID, AssetLogo)
+		if err != nil {
+			return err
+		}
+		if bugURI != "" {
+			bug = ch.BugPosition + " " + bugURI
+		}
+	}
+	if bug != shown.bug {
+		var o *brave.Overlay
+		if bug != "" {
+			bo, err := bugOverlay(ch, bugURI)
+			if err != nil {
+				return err
+			}
+			o = &bo
+		}
+		err := mcr.replaceOverlay(ctx, m, &overlays.BugOverlayID, o)
+		if err != nil {
+			return fmt.Errorf("failed to draw bug: %w", err)
+		}
+		shown.bug = bug
+	}
+
+	clock := ""
+	if ch.ClockVisible {
+		clock = ch.ClockPosition
+	}
+	if clock != shown.clock {
+		var o *brave.Overlay
+		if clock != "" {
+			v, h := overlayAlignment(clock)
+			o = &brave.Overlay{
+				Type:       brave.OverlayClock,
+				Visible:    true,
+				FontSize:   clockFontSize,
+				VAlignment: v,
+				HAlignment: h,
+			}
+		}
+		err := mcr.replaceOverlay(ctx, m, &overlays.ClockOverlayID, o)
+		if err != nil {
+			return fmt.Errorf("failed to draw clock: %w", err)
+		}
+		shown.clock = clock
+	}
+
+	if strap == shown.strap {
+		return nil
+	}
+	switch {
+	case overlays.StrapOverlayID == 0 && strap == "":
+	case overlays.StrapOverlayID == 0:
+		o, err := mcr.brave.NewOverlay(ctx, m, brave.Overlay{
+			Type:             brave.OverlayText,
+			Visible:          true,
+			Text:             strap,
+			FontSize:         strapFontSize,
+			VAlignment:       "bottom",
+			HAlignment:       "left",
+			ShadedBackground: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to draw strap: %w", err)
+		}
+		overlays.StrapOverlayID = o.ID
+	default:
+		visible := strap != ""
+		u := brave.UpdateOverlay{Visible: &visible}
+		if visible {
+			u.Text = &strap
+		}
+		err := mcr.brave.UpdateOverlay(ctx, overlays.StrapOverlayID, u)
+		if err != nil {
+			return fmt.Errorf("failed to draw strap: %w", err)
+		}
+	}
+	shown.strap = strap
+	return nil
+}
+
+// replaceOverlay deletes an overlay and creates another in its place, or
+// leaves it deleted when the new overlay is nil.
+func (mcr *MCR) replaceOverlay(ctx context.Context, m brave.Mixer, overlayID *int, o *brave.Overlay) error {
+	if *overlayID != 0 {
+		err := mcr.brave.DeleteOverlay(ctx, *overlayID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete overlay: %w", err)
+		}
+		*overlayID = 0
+	}
+	if o == nil {
+		return nil
+	}
+	created, err := mcr.brave.NewOverlay(ctx, m, *o)
+	if err != nil {
+		return fmt.Errorf("failed to create overlay: %w", err)
+	}
+	*overlayID = created.ID
+	return nil
+}
+
+// deleteOverlays deletes a channel's overlays from Brave, clearing their IDs
+// as it goes.
+func (mcr *MCR) deleteOverlays(ctx context.Context, overlays *channelOverlays) error {
+	for _, id := range []*int{&overlays.BugOverlayID, &overlays.ClockOverlayID, &overlays.StrapOverlayID} {
+		if *id == 0 {
+			continue
+		}
+		err := mcr.brave.DeleteOverlay(ctx, *id)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete overlay %d: %w", *id, err)
+		}
+		*id = 0
+	}
+	return nil
+}
+
+// storeOverlays stores a channel's overlay IDs when they have changed,
+// returning drawErr unless storing them fails. What the overlays show is
+// forgotten when they can't be stored, so they are all replaced next time.
+func (mcr *MCR) storeOverlays(ctx context.Context, ch graphicsChannel, overlays channelOverlays, drawErr error) error {
+	if overlays == ch.channelOverlays {
+		return drawErr
+	}
+	err := mcr.storeOverlayIDs(ctx, ch.ChannelID, overlays)
+	if err != nil {
+		mcr.setShownGraphics(ch.ChannelID, nil)
+		return err
+	}
+	return drawErr
+}
+
+// setShownGraphics sets what a channel's overlays show, forgetting it when
+// shown is nil so they are all replaced next time.
+func (mcr *MCR) setShownGraphics(channelID int, shown *shownGraphics) {
+	mcr.stateMu.Lock()
+	defer mcr.stateMu.Unlock()
+	if shown == nil {
+		delete(mcr.graphics, channelID)
+		return
+	}
+	mcr.graphics[channelID] = *shown
+}
+
+func (mcr *MCR) storeOverlayIDs(ctx context.Context, channelID int, overlays channelOverlays) error {
+	_, err := mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channel_graphics SET
+			bug_overlay_id = $1,
+			clock_overlay_id = $2,
+			strap_overlay_id = $3
+		WHERE channel_id = $4;`, overlays.BugOverlayID, overlays.ClockOverlayID,
+		overlays.StrapOverlayID, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update overlays in store: %w", err)
+	}
+	return nil
+}
+
+// stopChannelGraphics deletes a channel's overlays from Brave, for when it
+// has gone off-air. The caller must hold the channel's lock.
+func (mcr *MCR) stopChannelGraphics(ctx context.Context, channelID int) error {
+	overlays := channelOverlays{}
+	err := mcr.db.GetContext(ctx, &overlays, `
+		SELECT bug_overlay_id, clock_overlay_id, strap_overlay_id
+		FROM mcr.channel_graphics
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to get overlays: %w", err)
+	}
+	mcr.setShownGraphics(channelID, nil)
+	err = mcr.deleteOverlays(ctx, &overlays)
+	storeErr := mcr.storeOverlayIDs(ctx, channelID, overlays)
+	if err != nil {
+		return err
+	}
+	return storeErr
+}
+
+// bugOverlay is the image overlay of a channel's logo in a corner, a tenth of
+// the channel's height.
+func bugOverlay(ch graphicsChannel, uri string) (brave.Overlay, error) {
+	f, err := os.Open(cardLogoPath(ch.ChannelID))
+	if err != nil {
+		return brave.Overlay{}, fmt.Errorf("failed to open logo: %w", err)
+	}
+	defer f.Close()
+	conf, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return brave.Overlay{}, fmt.Errorf("failed to decode logo: %w", err)
+	}
+	if conf.Width == 0 || conf.Height == 0 {
+		return brave.Overlay{}, errors.New("logo is empty")
+	}
+
+	h := ch.Height / 10
+	w := conf.Width * h / conf.Height
+	margin := ch.Height / 24
+	x, y := margin, margin
+	if ch.BugPosition == LogoTopRight || ch.BugPosition == LogoBottomRight {
+		x = ch.Width - margin - w
+	}
+	if ch.BugPosition == LogoBottomLeft || ch.BugPosition == LogoBottomRight {
+		y = ch.Height - margin - h
+	}
+	return brave.Overlay{
+		Type:    brave.OverlayImage,
+		Visible: true,
+		URI:     uri,
+		Width:   w,
+		Height:  h,
+		XPos:    x,
+		YPos:    y,
+	}, nil
+}
+
+// overlayAlignment is how a text overlay is aligned to be in a corner.
+func overlayAlignment(position string) (valignment, halignment string) {
+	valignment, halignment = "top", "left"
+	if position == LogoBottomLeft || position == LogoBottomRight {
+		valignment = "bottom"
+	}
+	if position == LogoTopRight || position == LogoBottomRight {
+		halignment = "right"
+	}
+	return valignment, halignment
+}
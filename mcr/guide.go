@@ -0,0 +1,97 @@
+package mcr
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type (
+	// Guide is a channel's public schedule, for programme guides.
+	Guide struct {
+		Channel    GuideChannel `json:"channel"`
+		Programmes []Programme  `json:"programmes"`
+	}
+	// GuideChannel is the public part of a channel.
+	GuideChannel struct {
+		ID      int    `db:"channel_id" json:"channelID"`
+		Status  string `db:"status" json:"status"`
+		URLName string `db:"url_name" json:"urlName"`
+		Title   string `db:"title" json:"title"`
+	}
+	// Programme is a public playout in a channel's guide.
+	Programme struct {
+		PlayoutID   int       `db:"playout_id" json:"playoutID"`
+		Title       string    `db:"title" json:"title"`
+		Description string    `db:"description" json:"description"`
+		Start       time.Time `db:"scheduled_start" json:"start"`
+		End         time.Time `db:"scheduled_end" json:"end"`
+		// Status is scheduled, live or stream-ended.
+		Status string `db:"status" json:"status"`
+		// Live is whether the programme is airing on the channel now.
+		Live bool `db:"live" json:"live"`
+	}
+	// NowNext is what's airing on a channel and what's on after it, either
+	// can be nil.
+	NowNext struct {
+		Channel GuideChannel `json:"channel"`
+		Now     *Programme   `json:"now"`
+		Next    *Programme   `json:"next"`
+	}
+)
+
+// guideHistory is how long ended programmes stay in the guide.
+const guideHistory = 24 * time.Hour
+
+// GetGuide returns a channel's public programmes that haven't ended or ended
+// recently, in schedule order.
+func (mcr *MCR) GetGuide(ctx context.Context, channelID int) (Guide, error) {
+	g := Guide{}
+	err := mcr.db.GetContext(ctx, &g.Channel, `
+		SELECT channel_id, status, url_name, title
+		FROM mcr.channels
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return Guide{}, fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	g.Programmes = []Programme{}
+	err = mcr.db.SelectContext(ctx, &g.Programmes, `
+		SELECT p.playout_id, p.title, p.description, p.scheduled_start,
+					 p.scheduled_end, p.status,
+					 p.status = 'live' AND c.status = 'on-air' AS live
+		FROM mcr.playouts p
+		INNER JOIN mcr.channels c ON c.channel_id = p.channel_id
+		WHERE p.channel_id = $1
+		AND p.visibility = 'public'
+		AND (p.status = 'live' OR p.scheduled_end > $2)
+		ORDER BY
+			p.scheduled_start ASC,
+			p.scheduled_end ASC;`, channelID, time.Now().Add(-guideHistory))
+	if err != nil {
+		return Guide{}, fmt.Errorf("failed to list programmes: %w", err)
+	}
+	return g, nil
+}
+
+// GetNowNext returns what's airing on a channel and the next programme that
+// hasn't aired. Nothing is airing whilst the channel is on continuity or a
+// filler.
+func (mcr *MCR) GetNowNext(ctx context.Context, channelID int) (NowNext, error) {
+	g, err := mcr.GetGuide(ctx, channelID)
+	if err != nil {
+		return NowNext{}, err
+	}
+	nn := NowNext{Channel: g.Channel}
+	now := time.Now()
+	for i := range g.Programmes {
+		p := &g.Programmes[i]
+		switch {
+		case p.Live:
+			nn.Now = p
+		case nn.Next == nil && p.Status == "scheduled" && p.End.After(now):
+			nn.Next = p
+		}
+	}
+	return nn, nil
+}
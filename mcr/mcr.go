@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -13,15 +16,61 @@ import (
 type (
 	// MCR manages multiple channels.
 	MCR struct {
-		baseServeURL  *url.URL
-		outputAddress *url.URL
-		db            *sqlx.DB
-		brave         *brave.Braver
+		baseServeURL    *url.URL
+		outputAddress   *url.URL
+		db              *sqlx.DB
+		brave           *brave.Braver
+		preRoll         time.Duration
+		softEndLimit    time.Duration
+		fillerGap       time.Duration
+		failoverTimeout time.Duration
+		// reconcileMu stops reconciliations overlapping.
+		reconcileMu sync.Mutex
+		// orphans are the Brave objects the last reconciliation found nothing
+		// referencing.
+		orphans map[string]bool
+		// channelLocks stop a channel being changed by two things at once,
+		// see lockChannel.
+		channelsMu   sync.Mutex
+		channelLocks map[int]*sync.Mutex
+		// stateMu guards cardSince and graphics, which channels change at
+		// the same time.
+		stateMu sync.Mutex
+		// cardSince is when each channel went back to its continuity card
+		// after a filler.
+		cardSince map[int]time.Time
+		// graphics are what each on-air channel's overlays show.
+		graphics map[int]shownGraphics
+		// failingSince is when each live playout's source was seen failing,
+		// or last played again whilst failed over. Playouts are ended whilst
+		// other channels are scheduled, so it has its own lock.
+		failingMu    sync.Mutex
+		failingSince map[int]time.Time
+		// cards are each channel's continuity card refreshes, cardsWake
+		// tells RunCardRenderer one has been asked for.
+		cardsMu     sync.Mutex
+		cards       map[int]*cardRefresh
+		cardsWake   chan struct{}
+		programLost ProgramLostFunc
+		// scheduleWake tells RunScheduler to schedule straight away.
+		scheduleWake chan struct{}
 	}
 	// Config to configure Brave.
 	Config struct {
 		BaseServeURL  string
 		OutputAddress string
+		// PreRoll is how long before a playout starts its source is played,
+		// so it is ready to cut to. Defaults to 5s.
+		PreRoll time.Duration
+		// SoftEndLimit is how long a soft ended playout can overrun its
+		// scheduled end by, defaults to 30m.
+		SoftEndLimit time.Duration
+		// FillerGap is how long the continuity card is shown between fillers
+		// and before a playout, defaults to 10s.
+		FillerGap time.Duration
+		// FailoverTimeout is how long a live playout's source can fail for
+		// before its channel fails over, defaults to 5s.
+		FailoverTimeout time.Duration
 	}
 )
 
@@ -46,10 +95,93 @@ func NewMCR(c *Config, db *sqlx.DB, brave *brave.Braver) (*MCR, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid output url: %w", err)
 	}
+	preRoll := c.PreRoll
+	if preRoll == 0 {
+		preRoll = 5 * time.Second
+	}
+	softEndLimit := c.SoftEndLimit
+	if softEndLimit == 0 {
+		softEndLimit = 30 * time.Minute
+	}
+	fillerGap := c.FillerGap
+	if fillerGap == 0 {
+		fillerGap = 10 * time.Second
+	}
+	failoverTimeout := c.FailoverTimeout
+	if failoverTimeout == 0 {
+		failoverTimeout = 5 * time.Second
+	}
 	return &MCR{
-		baseServeURL:  baseServe,
-		outputAddress: output,
-		db:            db,
-		brave:         brave,
+		baseServeURL:    baseServe,
+		outputAddress:   output,
+		preRoll:         preRoll,
+		softEndLimit:    softEndLimit,
+		fillerGap:       fillerGap,
+		failoverTimeout: failoverTimeout,
+		db:              db,
+		brave:           brave,
+		orphans:         map[string]bool{},
+		channelLocks:    map[int]*sync.Mutex{},
+		cardSince:       map[int]time.Time{},
+		graphics:        map[int]shownGraphics{},
+		failingSince:    map[int]time.Time{},
+		cards:           map[int]*cardRefresh{},
+		cardsWake:       make(chan struct{}, 1),
+		scheduleWake:    make(chan struct{}, 1),
 	}, nil
 }
+
+// lockChannel stops anything else changing a channel until the returned
+// unlock is called. It is held across the channel's transitions, so Brave
+// and the store agree on the channel whenever it isn't held.
+//
+// Reconcile holds reconcileMu and then every channel's lock, moving a playout
+// holds both of its channels' locks, everything else holds one channel's lock
+// at a time. Channels are locked in order of ID so they can't deadlock.
+func (mcr *MCR) lockChannel(channelID int) func() {
+	mcr.channelsMu.Lock()
+	l, ok := mcr.channelLocks[channelID]
+	if !ok {
+		l = &sync.Mutex{}
+		mcr.channelLocks[channelID] = l
+	}
+	mcr.channelsMu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// lockChannels locks each of the channels in order of ID, see lockChannel. A
+// channel given more than once is only locked once.
+func (mcr *MCR) lockChannels(channelIDs ...int) func() {
+	ids := append([]int{}, channelIDs...)
+	sort.Ints(ids)
+	unlocks := []func(){}
+	for i, channelID := range ids {
+		if i > 0 && channelID == ids[i-1] {
+			continue
+		}
+		unlocks = append(unlocks, mcr.lockChannel(channelID))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
+
+// eachChannel calls f for each channel at the same time, holding the
+// channel's lock, so one channel's transitions don't hold up the others. It
+// returns once they have all returned.
+func (mcr *MCR) eachChannel(channelIDs []int, f func(channelID int)) {
+	done := sync.WaitGroup{}
+	for _, channelID := range channelIDs {
+		done.Add(1)
+		go func(channelID int) {
+			defer done.Done()
+			unlock := mcr.lockChannel(channelID)
+			defer unlock()
+			f(channelID)
+		}(channelID)
+	}
+	done.Wait()
+}
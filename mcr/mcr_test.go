@@ -0,0 +1,72 @@
+package mcr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/brave/bravesim"
+)
+
+// containsQuery matches a query that contains the expected SQL, ignoring how
+// either is spaced, so expectations only need the distinctive part.
+var containsQuery = sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+	expected := strings.Join(strings.Fields(expectedSQL), " ")
+	actual := strings.Join(strings.Fields(actualSQL), " ")
+	if !strings.Contains(actual, expected) {
+		return fmt.Errorf("query %q doesn't contain %q", actual, expected)
+	}
+	return nil
+})
+
+// newTestMCR creates an MCR with a mocked store and an empty simulated Brave.
+func newTestMCR(t *testing.T) (*MCR, sqlmock.Sqlmock, *brave.Braver) {
+	t.Helper()
+	addr, err := bravesim.New().Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start brave simulator: %v", err)
+	}
+	b, err := brave.New(brave.Config{Endpoint: addr})
+	if err != nil {
+		t.Fatalf("failed to create brave client: %v", err)
+	}
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(containsQuery))
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	mcr, err := NewMCR(&Config{
+		BaseServeURL:  "http://showtime.test",
+		OutputAddress: "rtmp://stream.test/live",
+	}, sqlx.NewDb(db, "postgres"), b)
+	if err != nil {
+		t.Fatalf("failed to create mcr: %v", err)
+	}
+	return mcr, mock, b
+}
+
+// expectAsRun expects an entry of a type to be added to a channel's as-run
+// log.
+func expectAsRun(mock sqlmock.Sqlmock, channelID int, typ AsRunType) {
+	mock.ExpectExec("INSERT INTO mcr.as_run").
+		WithArgs(channelID, typ, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// programOf lists the inputs in a mixer's program.
+func programOf(t *testing.T, b *brave.Braver, mixerID int) []string {
+	t.Helper()
+	m, err := b.GetMixer(context.Background(), mixerID)
+	if err != nil {
+		t.Fatalf("failed to get mixer: %v", err)
+	}
+	return m.Program()
+}
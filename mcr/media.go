@@ -0,0 +1,262 @@
+package mcr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ystv/showtime/ffmpeg"
+)
+
+type (
+	// Media is a pre-recorded file in the media library, which playouts can
+	// use as their source.
+	Media struct {
+		ID    int    `db:"media_id" json:"mediaID"`
+		Title string `db:"title" json:"title"`
+		// FileName is the name of the file that was uploaded.
+		FileName   string        `db:"file_name" json:"fileName"`
+		SizeBytes  int64         `db:"size_bytes" json:"sizeBytes"`
+		Duration   time.Duration `db:"duration" json:"duration"`
+		VideoCodec string        `db:"video_codec" json:"videoCodec"`
+		AudioCodec string        `db:"audio_codec" json:"audioCodec"`
+		Width      int           `db:"res_width" json:"width"`
+		Height     int           `db:"res_height" json:"height"`
+		Thumbnail  bool          `db:"thumbnail" json:"-"`
+		CreatedAt  time.Time     `db:"created_at" json:"createdAt"`
+		// URI is where Brave plays the media from.
+		URI string `db:"-" json:"uri"`
+		// ThumbnailURI is empty when the media has no video.
+		ThumbnailURI string `db:"-" json:"thumbnailURI"`
+	}
+	// NewMediaParams is a file being uploaded to the media library.
+	NewMediaParams struct {
+		Title    string
+		FileName string
+		Body     io.Reader
+	}
+)
+
+// mediaDir is where media library files are stored, it is served under
+// baseServeURL.
+const mediaDir = "assets/media"
+
+var (
+	// ErrMediaNotFound when media cannot be found.
+	ErrMediaNotFound = errors.New("media not found")
+	// ErrMediaNotPlayable when an uploaded file has no audio or video that
+	// can be played.
+	ErrMediaNotPlayable = errors.New("media is not playable")
+	// ErrMediaInUse when media is the source of a playout that hasn't ended,
+	// or is a channel's filler.
+	ErrMediaInUse = errors.New("media is in use")
+)
+
+// NewMedia adds a file to the media library. It is probed to check it can be
+// played and find its duration, and a thumbnail is made when it has video.
+func (mcr *MCR) NewMedia(ctx context.Context, p NewMediaParams) (Media, error) {
+	if p.Title == "" {
+		return Media{}, ErrTitleEmpty
+	}
+
+	err := os.MkdirAll(mediaDir, 0o755)
+	if err != nil {
+		return Media{}, fmt.Errorf("failed to create media directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(mediaDir, "upload-*")
+	if err != nil {
+		return Media{}, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, p.Body)
+	if err != nil {
+		tmp.Close()
+		return Media{}, fmt.Errorf("failed to write file: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return Media{}, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	probe, err := ffmpeg.NewProbe(ctx, tmp.Name())
+	if err != nil {
+		if errors.Is(err, ffmpeg.ErrNotPlayable) {
+			return Media{}, fmt.Errorf("%w: %v", ErrMediaNotPlayable, err)
+		}
+		return Media{}, fmt.Errorf("failed to probe file: %w", err)
+	}
+
+	m := Media{
+		Title:      p.Title,
+		FileName:   filepath.Base(p.FileName),
+		SizeBytes:  size,
+		Duration:   probe.Duration,
+		VideoCodec: probe.VideoCodec,
+		AudioCodec: probe.AudioCodec,
+		Width:      probe.Width,
+		Height:     probe.Height,
+		Thumbnail:  probe.VideoCodec != "",
+	}
+	err = mcr.db.GetContext(ctx, &m.ID, `
+		INSERT INTO mcr.media (
+			title, file_name, size_bytes, duration, video_codec, audio_codec,
+			res_width, res_height, thumbnail
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING media_id;`,
+		m.Title, m.FileName, m.SizeBytes, m.Duration, m.VideoCodec, m.AudioCodec,
+		m.Width, m.Height, m.Thumbnail)
+	if err != nil {
+		return Media{}, fmt.Errorf("failed to insert media: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), mediaPath(m))
+	if err != nil {
+		_, _ = mcr.db.ExecContext(ctx, `DELETE FROM mcr.media WHERE media_id = $1;`, m.ID)
+		return Media{}, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if m.Thumbnail {
+		// A frame a little way in, the start is often black.
+		err = ffmpeg.NewThumbnail(ctx, mediaPath(m), thumbnailPath(m), m.Duration/10)
+		if err != nil {
+			// The media still plays without one.
+			log.Printf("failed to create thumbnail of media %d: %v", m.ID, err)
+			m.Thumbnail = false
+			_, err = mcr.db.ExecContext(ctx, `
+				UPDATE mcr.media
+				SET thumbnail = false
+				WHERE media_id = $1;`, m.ID)
+			if err != nil {
+				return Media{}, fmt.Errorf("failed to update media: %w", err)
+			}
+		}
+	}
+
+	return mcr.GetMedia(ctx, m.ID)
+}
+
+// GetMedia returns media from the library.
+func (mcr *MCR) GetMedia(ctx context.Context, mediaID int) (Media, error) {
+	m := Media{}
+	err := mcr.db.GetContext(ctx, &m, `
+		SELECT media_id, title, file_name, size_bytes, duration, video_codec,
+					 audio_codec, res_width, res_height, thumbnail, created_at
+		FROM mcr.media
+		WHERE media_id = $1;`, mediaID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Media{}, ErrMediaNotFound
+		}
+		return Media{}, fmt.Errorf("failed to get media: %w", err)
+	}
+	mcr.setMediaURIs(&m)
+	return m, nil
+}
+
+// ListMedia lists the media library, newest first.
+func (mcr *MCR) ListMedia(ctx context.Context) ([]Media, error) {
+	m := []Media{}
+	err := mcr.db.SelectContext(ctx, &m, `
+		SELECT media_id, title, file_name, size_bytes, duration, video_codec,
+					 audio_codec, res_width, res_height, thumbnail, created_at
+		FROM mcr.media
+		ORDER BY created_at DESC;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	for i := range m {
+		mcr.setMediaURIs(&m[i])
+	}
+	return m, nil
+}
+
+// DeleteMedia removes media from the library, as long as no playout that
+// hasn't ended or filler is using it.
+func (mcr *MCR) DeleteMedia(ctx context.Context, mediaID int) error {
+	m, err := mcr.GetMedia(ctx, mediaID)
+	if err != nil {
+		return err
+	}
+
+	inUse := false
+	err = mcr.db.GetContext(ctx, &inUse, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM mcr.playouts
+			WHERE media_id = $1
+			AND status IN ('scheduled', 'live')
+		) OR EXISTS (
+			SELECT 1
+			FROM mcr.fillers
+			WHERE media_id = $1
+		);`, m.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check media use: %w", err)
+	}
+	if inUse {
+		return ErrMediaInUse
+	}
+
+	_, err = mcr.db.ExecContext(ctx, `
+		DELETE FROM mcr.media
+		WHERE media_id = $1;`, m.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete media from store: %w", err)
+	}
+
+	err = os.Remove(mediaPath(m))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if m.Thumbnail {
+		err = os.Remove(thumbnailPath(m))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete thumbnail: %w", err)
+		}
+	}
+	return nil
+}
+
+func (mcr *MCR) setMediaURIs(m *Media) {
+	m.URI = mcr.baseServeURL.ResolveReference(&url.URL{Path: mediaPath(*m)}).String()
+	if m.Thumbnail {
+		m.ThumbnailURI = mcr.baseServeURL.ResolveReference(&url.URL{Path: thumbnailPath(*m)}).String()
+	}
+}
+
+// mediaPath is where media is stored, named by its ID so uploads can't
+// clash or escape the media directory. The extension is kept since it helps
+// players to work out the format.
+func mediaPath(m Media) string {
+	return path.Join(mediaDir, fmt.Sprintf("%d%s", m.ID, mediaExt(m.FileName)))
+}
+
+func thumbnailPath(m Media) string {
+	return path.Join(mediaDir, fmt.Sprintf("%d-thumb.jpg", m.ID))
+}
+
+// mediaExt returns a file name's extension if it is safe to use in a path.
+func mediaExt(fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, r := range strings.TrimPrefix(ext, ".") {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// PrettyDuration formats the duration to the nearest second.
+func (m Media) PrettyDuration() string {
+	return m.Duration.Round(time.Second).String()
+}
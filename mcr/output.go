@@ -0,0 +1,391 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ystv/showtime/brave"
+)
+
+type (
+	// ChannelOutput is a destination a channel's program is sent to whilst
+	// it is on-air.
+	ChannelOutput struct {
+		ID        int `db:"channel_output_id" json:"channelOutputID"`
+		ChannelID int `db:"channel_id" json:"channelID"`
+		// Type is OutputLocal, OutputRTMP or OutputTCP.
+		Type string `db:"output_type" json:"type"`
+		Name string `db:"name" json:"name"`
+		// URL is where an RTMP output is sent, it is only shown masked in
+		// Destination since it usually has a stream key.
+		URL string `db:"url" json:"-"`
+		// Port is what a TCP output is served on.
+		Port          int `db:"port" json:"port,omitempty"`
+		BraveOutputID int `db:"brave_output_id" json:"braveOutputID"`
+		// Destination is where the output goes, safe to display.
+		Destination string `db:"-" json:"destination"`
+		// Status is the output's state in Brave, OutputOffAir when the
+		// channel isn't on-air and OutputMissing when Brave doesn't have it.
+		Status string `db:"-" json:"status"`
+	}
+	// EditChannelOutput adds an output to a channel.
+	EditChannelOutput struct {
+		// Type is OutputRTMP or OutputTCP, a channel's local output is made
+		// with it.
+		Type string `json:"type" form:"type"`
+		Name string `json:"name" form:"name"`
+		// URL is required for an RTMP output.
+		URL string `json:"url" form:"url"`
+		// Port is required for a TCP output.
+		Port int `json:"port" form:"port"`
+	}
+)
+
+// Output types.
+const (
+	// OutputLocal is the channel's RTMP output to the output address, which
+	// the rest of ShowTime! reads the channel from.
+	OutputLocal = "local"
+	// OutputRTMP is sent to an RTMP server.
+	OutputRTMP = "rtmp"
+	// OutputTCP is served by Brave on a TCP port.
+	OutputTCP = "tcp"
+)
+
+// Output statuses other than Brave's states.
+const (
+	OutputOffAir  = "off-air"
+	OutputMissing = "missing"
+	// OutputUnknown is when Brave couldn't be asked.
+	OutputUnknown = "unknown"
+)
+
+var (
+	// ErrOutputNotFound when a channel doesn't have the output.
+	ErrOutputNotFound = errors.New("output not found")
+	// ErrOutputTypeInvalid when an output added isn't rtmp or tcp.
+	ErrOutputTypeInvalid = errors.New("output type is invalid")
+	// ErrOutputNameEmpty when an output's name is empty.
+	ErrOutputNameEmpty = errors.New("output name is empty")
+	// ErrOutputURLInvalid when an RTMP output's URL isn't rtmp or rtmps.
+	ErrOutputURLInvalid = errors.New("output url is invalid")
+	// ErrOutputPortInvalid when a TCP output's port isn't a valid port.
+	ErrOutputPortInvalid = errors.New("output port is invalid")
+	// ErrOutputPortInUse when another TCP output already uses the port.
+	ErrOutputPortInUse = errors.New("output port is in use")
+	// ErrOutputLocal when removing a channel's local output.
+	ErrOutputLocal = errors.New("local output cannot be removed")
+)
+
+// ListChannelOutputs lists a channel's outputs with their status.
+//
+// Brave being unavailable doesn't fail the list, the outputs are listed with
+// an unknown status instead.
+func (mcr *MCR) ListChannelOutputs(ctx context.Context, ch Channel) ([]ChannelOutput, error) {
+	outputs, err := mcr.listChannelOutputs(ctx, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var braveOutputs []brave.Output
+	if ch.Status == "on-air" {
+		braveOutputs, err = mcr.brave.ListOutputs(ctx)
+		if err != nil {
+			log.Printf("failed to list channel %d brave outputs: %v", ch.ID, err)
+		}
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		o.Destination = mcr.outputDestination(ch, *o)
+		if o.Type == OutputRTMP {
+			o.Destination = maskOutputURL(o.Destination)
+		}
+		switch {
+		case ch.Status != "on-air":
+			o.Status = OutputOffAir
+		case err != nil:
+			o.Status = OutputUnknown
+		default:
+			o.Status = OutputMissing
+			for _, bo := range braveOutputs {
+				if bo.ID == o.BraveOutputID {
+					o.Status = strings.ToLower(string(bo.State))
+				}
+			}
+		}
+	}
+	return outputs, nil
+}
+
+func (mcr *MCR) listChannelOutputs(ctx context.Context, channelID int) ([]ChannelOutput, error) {
+	outputs := []ChannelOutput{}
+	err := mcr.db.SelectContext(ctx, &outputs, `
+		SELECT channel_output_id, channel_id, output_type, name, url, port, brave_output_id
+		FROM mcr.channel_outputs
+		WHERE channel_id = $1
+		ORDER BY channel_output_id;`, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// NewChannelOutput adds an output to a channel, which starts straight away
+// when the channel is on-air.
+func (mcr *MCR) NewChannelOutput(ctx context.Context, ch Channel, o EditChannelOutput) (int, error) {
+	if ch.Status == "archived" {
+		return 0, ErrChannelArchived
+	}
+	if o.Name == "" {
+		return 0, ErrOutputNameEmpty
+	}
+	co := ChannelOutput{
+		ChannelID: ch.ID,
+		Type:      o.Type,
+		Name:      o.Name,
+	}
+	switch o.Type {
+	case OutputRTMP:
+		u, err := url.Parse(o.URL)
+		if err != nil || (u.Scheme != "rtmp" && u.Scheme != "rtmps") || u.Host == "" {
+			return 0, ErrOutputURLInvalid
+		}
+		co.URL = o.URL
+	case OutputTCP:
+		if o.Port < 1 || o.Port > 65535 {
+			return 0, ErrOutputPortInvalid
+		}
+		inUse := false
+		err := mcr.db.GetContext(ctx, &inUse, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM mcr.channel_outputs
+				WHERE output_type = 'tcp'
+				AND port = $1
+			);`, o.Port)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check port: %w", err)
+		}
+		if inUse {
+			return 0, ErrOutputPortInUse
+		}
+		co.Port = o.Port
+	default:
+		return 0, ErrOutputTypeInvalid
+	}
+
+	unlock := mcr.lockChannel(ch.ID)
+	defer unlock()
+	// The channel may have gone on or off-air since it was got.
+	ch, err := mcr.GetChannel(ctx, ch.ID)
+	if err != nil {
+		return 0, err
+	}
+	if ch.Status == "on-air" {
+		m, err := mcr.brave.GetMixer(ctx, ch.MixerID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get mixer: %w", err)
+		}
+		bo, err := mcr.newBraveOutput(ctx, m, ch, co)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create output: %w", err)
+		}
+		co.BraveOutputID = bo.ID
+	}
+
+	err = mcr.db.GetContext(ctx, &co.ID, `
+		INSERT INTO mcr.channel_outputs (
+			channel_id, output_type, name, url, port, brave_output_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING channel_output_id;`, co.ChannelID, co.Type, co.Name, co.URL, co.Port,
+		co.BraveOutputID)
+	if err != nil {
+		if co.BraveOutputID != 0 {
+			_ = mcr.brave.DeleteOutput(ctx, co.BraveOutputID)
+		}
+		return 0, fmt.Errorf("failed to insert output: %w", err)
+	}
+	return co.ID, nil
+}
+
+// DeleteChannelOutput removes an output from a channel, stopping it when the
+// channel is on-air. The local output can't be removed.
+func (mcr *MCR) DeleteChannelOutput(ctx context.Context, ch Channel, outputID int) error {
+	unlock := mcr.lockChannel(ch.ID)
+	defer unlock()
+
+	outputs, err := mcr.listChannelOutputs(ctx, ch.ID)
+	if err != nil {
+		return err
+	}
+	var co *ChannelOutput
+	for i := range outputs {
+		if outputs[i].ID == outputID {
+			co = &outputs[i]
+		}
+	}
+	if co == nil {
+		return ErrOutputNotFound
+	}
+	if co.Type == OutputLocal {
+		return ErrOutputLocal
+	}
+
+	if co.BraveOutputID != 0 {
+		err = mcr.brave.DeleteOutput(ctx, co.BraveOutputID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete output: %w", err)
+		}
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		DELETE FROM mcr.channel_outputs
+		WHERE channel_output_id = $1;`, co.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete output from store: %w", err)
+	}
+	return nil
+}
+
+// newLocalOutput adds the local output to a new channel.
+func (mcr *MCR) newLocalOutput(ctx context.Context, channelID int) error {
+	_, err := mcr.db.ExecContext(ctx, `
+		INSERT INTO mcr.channel_outputs (channel_id, output_type, name)
+		VALUES ($1, $2, $3);`, channelID, OutputLocal, "Local")
+	if err != nil {
+		return fmt.Errorf("failed to insert local output: %w", err)
+	}
+	return nil
+}
+
+// startChannelOutputs creates a channel's outputs of its mixer in Brave,
+// storing their IDs once they have all been created.
+func (mcr *MCR) startChannelOutputs(ctx context.Context, m brave.Mixer, ch Channel) error {
+	outputs, err := mcr.listChannelOutputs(ctx, ch.ID)
+	if err != nil {
+		return err
+	}
+	for i := range outputs {
+		bo, err := mcr.newBraveOutput(ctx, m, ch, outputs[i])
+		if err != nil {
+			return fmt.Errorf("failed to create %s output: %w", outputs[i].Name, err)
+		}
+		outputs[i].BraveOutputID = bo.ID
+	}
+	for _, o := range outputs {
+		err = mcr.storeOutputID(ctx, o)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopChannelOutputs deletes a channel's outputs from Brave.
+func (mcr *MCR) stopChannelOutputs(ctx context.Context, channelID int) error {
+	outputs, err := mcr.listChannelOutputs(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	for _, o := range outputs {
+		if o.BraveOutputID == 0 {
+			continue
+		}
+		err = mcr.brave.DeleteOutput(ctx, o.BraveOutputID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete %s output: %w", o.Name, err)
+		}
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channel_outputs SET
+			brave_output_id = 0
+		WHERE channel_id = $1;`, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update outputs in store: %w", err)
+	}
+	return nil
+}
+
+func (mcr *MCR) storeOutputID(ctx context.Context, o ChannelOutput) error {
+	_, err := mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channel_outputs SET
+			brave_output_id = $1
+		WHERE channel_output_id = $2;`, o.BraveOutputID, o.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update output in store: %w", err)
+	}
+	return nil
+}
+
+// newBraveOutput creates an output of a channel's mixer in Brave.
+func (mcr *MCR) newBraveOutput(ctx context.Context, m brave.Mixer, ch Channel, o ChannelOutput) (brave.Output, error) {
+	if o.Type == OutputTCP {
+		return mcr.brave.NewTCPOutput(ctx, m, o.Port)
+	}
+	return mcr.brave.NewRTMPOutput(ctx, m, mcr.outputDestination(ch, o))
+}
+
+// outputDestination is where an output goes, including any stream key.
+func (mcr *MCR) outputDestination(ch Channel, o ChannelOutput) string {
+	switch o.Type {
+	case OutputLocal:
+		return mcr.outputAddress.String() + "/" + ch.URLName
+	case OutputTCP:
+		return mcr.brave.TCPOutputURI(o.Port)
+	}
+	return o.URL
+}
+
+// isBraveOutput checks a Brave output is a channel's output of its mixer,
+// Brave reuses IDs after restarting.
+func (mcr *MCR) isBraveOutput(bo brave.Output, m brave.Mixer, ch Channel, o ChannelOutput) bool {
+	return bo.Src == braveUID("mixer", m.ID) && mcr.hasOutputDestination(bo, ch, o)
+}
+
+// hasOutputDestination checks a Brave output goes where a channel's output
+// does, whatever its source.
+func (mcr *MCR) hasOutputDestination(bo brave.Output, ch Channel, o ChannelOutput) bool {
+	if o.Type == OutputTCP {
+		// Brave doesn't always report where a TCP output is served.
+		return bo.Type == OutputTCP &&
+			(bo.Dst == "" || strings.HasSuffix(bo.Dst, ":"+strconv.Itoa(o.Port)))
+	}
+	return bo.Dst == mcr.outputDestination(ch, o)
+}
+
+// maskOutputURL hides the credentials and stream key of an RTMP URL so it is
+// safe to display, only the start of the key is kept to tell keys apart.
+func maskOutputURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return maskSecret(s)
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	u.RawQuery = ""
+	i := strings.LastIndex(u.Path, "/")
+	if i != -1 && i != len(u.Path)-1 {
+		u.Path = u.Path[:i+1] + maskSecret(u.Path[i+1:])
+	}
+	// Avoid the mask being escaped.
+	u.RawPath = ""
+	masked, err := url.PathUnescape(u.String())
+	if err != nil {
+		return u.String()
+	}
+	return masked
+}
+
+func maskSecret(s string) string {
+	const shown = 4
+	if len(s) <= shown {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:shown] + strings.Repeat("*", len(s)-shown)
+}
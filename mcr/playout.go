@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/ystv/showtime/brave"
 )
 
 type (
@@ -22,29 +24,90 @@ type (
 		ScheduledStart time.Time `db:"scheduled_start" json:"scheduledStart"`
 		ScheduledEnd   time.Time `db:"scheduled_end" json:"scheduledEnd"`
 		Visibility     string    `db:"visibility" json:"visibility"`
+		EndMode        string    `db:"end_mode" json:"endMode"`
+		// MediaID is the source's media in the library, 0 when it isn't.
+		MediaID int `db:"media_id" json:"mediaID"`
+		// StartTransition and EndTransition are how the channel changes to
+		// and away from the playout, the channel's transition when they are
+		// empty. Only the start transition is used when the playout follows
+		// on from another.
+		StartTransition         string        `db:"start_transition" json:"startTransition"`
+		StartTransitionDuration time.Duration `db:"start_transition_duration" json:"startTransitionDuration"`
+		EndTransition           string        `db:"end_transition" json:"endTransition"`
+		EndTransitionDuration   time.Duration `db:"end_transition_duration" json:"endTransitionDuration"`
+		AudioLevel              `json:"audio"`
 	}
 	// EditPlayout creates or updates a playout on a given channel.
 	EditPlayout struct {
-		ChannelID      int       `json:"channelID" form:"channelID"`
+		ChannelID int `json:"channelID" form:"channelID"`
+		// SrcType defaults to SourceURI.
+		SrcType string `json:"srcType" form:"srcType"`
+		// MediaID sets the source to media from the library, used instead of
+		// SrcURI. ScheduledEnd defaults to the end of the media.
+		MediaID        int       `json:"mediaID" form:"mediaID"`
 		SrcURI         string    `json:"srcURI" form:"srcURI"`
 		Title          string    `json:"title" form:"title"`
 		Description    string    `json:"description" form:"description"`
 		ScheduledStart time.Time `json:"scheduledStart" form:"scheduledStart"`
 		ScheduledEnd   time.Time `json:"scheduledEnd" form:"scheduledEnd"`
 		Visibility     string    `json:"visibility" form:"visibility"`
+		// EndMode defaults to EndHard.
+		EndMode string `json:"endMode" form:"endMode"`
+		// AllowConflict schedules the playout even when it overlaps another
+		// on the channel, the one starting last is played.
+		AllowConflict bool `json:"allowConflict" form:"allowConflict"`
+		// StartTransition and EndTransition default to the channel's
+		// transition, when updating they are left as they are.
+		StartTransition         string        `json:"startTransition" form:"startTransition"`
+		StartTransitionDuration time.Duration `json:"startTransitionDuration"`
+		EndTransition           string        `json:"endTransition" form:"endTransition"`
+		EndTransitionDuration   time.Duration `json:"endTransitionDuration"`
+		// Audio defaults to DefaultAudioLevel, when updating it is left as it
+		// is.
+		Audio *AudioLevel `json:"audio"`
 	}
 )
 
+// Playout source types.
+const (
+	// SourceURI playouts are played by the scheduler.
+	SourceURI = "uri"
+	// SourceLivestream playouts are of a livestream, they are started and
+	// ended with it.
+	SourceLivestream = "livestream"
+)
+
+// Playout end modes, for when the source is still playing at the scheduled end.
+const (
+	// EndHard cuts to continuity at the scheduled end.
+	EndHard = "hard"
+	// EndSoft lets the source finish, up to the soft end limit.
+	EndSoft = "soft"
+)
+
 var (
 	// ErrPlayoutNotFound when a playout cannot be found.
 	ErrPlayoutNotFound = errors.New("playout not found")
 	// ErrSourceOnAir when a source is currently live, it cannot be removed.
 	ErrSourceOnAir = errors.New("cannot remove source that is on air")
+	// ErrSrcTypeInvalid when the source type isn't uri or livestream.
+	ErrSrcTypeInvalid = errors.New("source type is invalid")
+	// ErrEndModeInvalid when the end mode isn't hard or soft.
+	ErrEndModeInvalid = errors.New("end mode is invalid")
 )
 
 // StartPlayout triggers a playout to be played on a channel.
 func (mcr *MCR) StartPlayout(ctx context.Context, po Playout) error {
-	err := mcr.setChannelProgram(ctx, po.ChannelID, po.BraveInputID)
+	unlock := mcr.lockChannel(po.ChannelID)
+	defer unlock()
+	return mcr.startPlayout(ctx, po)
+}
+
+// startPlayout cuts a playout's channel to it, the caller must hold the
+// channel's lock.
+func (mcr *MCR) startPlayout(ctx context.Context, po Playout) error {
+	t := transition{Type: po.StartTransition, Duration: po.StartTransitionDuration}
+	err := mcr.setChannelProgram(ctx, po.ChannelID, po.BraveInputID, t, "playout started")
 	if err != nil {
 		return fmt.Errorf("failed to cut ch \"%d\" to input \"%d\": %w", po.ChannelID, po.BraveInputID, err)
 	}
@@ -57,35 +120,50 @@ func (mcr *MCR) StartPlayout(ctx context.Context, po Playout) error {
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	mcr.recordPlayoutAsRun(ctx, AsRunPlayoutStart, po, po.ScheduledStart, "")
 
-	err = mcr.refreshContinuityCard(ctx, po.ChannelID)
-	if err != nil {
-		return fmt.Errorf("failed to refresh continuity card: %w", err)
-	}
+	mcr.requestCardRefresh(po.ChannelID)
 
 	return nil
 }
 
 // EndPlayout triggers a playout to stopped being played on a channel.
 func (mcr *MCR) EndPlayout(ctx context.Context, po Playout) error {
-	// TODO: Re-approach this.
+	unlock := mcr.lockChannel(po.ChannelID)
+	defer unlock()
+	return mcr.endPlayout(ctx, po, true, "ended")
+}
 
-	continuityInputID := 0
-	err := mcr.db.GetContext(ctx, &continuityInputID, `
-		SELECT continuity_input_id
-		FROM mcr.channels
-		WHERE channel_id = $1;`, po.ChannelID)
+// endPlayout stops a playout, cutting its channel to continuity unless the
+// channel has already been cut to the next playout or failed over. The reason
+// is recorded in the as-run log. The caller must hold the channel's lock.
+func (mcr *MCR) endPlayout(ctx context.Context, po Playout, toContinuity bool, reason string) error {
+	failedOver, err := mcr.endFailover(ctx, po, reason)
 	if err != nil {
-		return fmt.Errorf("failed to get continuity input id: %w", err)
-	}
+		return err
+	}
+	if toContinuity && !failedOver {
+		continuityInputID := 0
+		err := mcr.db.GetContext(ctx, &continuityInputID, `
+			SELECT continuity_input_id
+			FROM mcr.channels
+			WHERE channel_id = $1;`, po.ChannelID)
+		if err != nil {
+			return fmt.Errorf("failed to get continuity input id: %w", err)
+		}
 
-	err = mcr.setChannelProgram(ctx, po.ChannelID, continuityInputID)
-	if err != nil {
-		return fmt.Errorf("failed to set channel program to continuity: %w", err)
+		t := transition{Type: po.EndTransition, Duration: po.EndTransitionDuration}
+		if reason == "input gone" {
+			t = cut
+		}
+		err = mcr.setChannelProgram(ctx, po.ChannelID, continuityInputID, t, "playout ended: "+reason)
+		if err != nil {
+			return fmt.Errorf("failed to set channel program to continuity: %w", err)
+		}
 	}
 
 	err = mcr.brave.DeleteInput(ctx, po.BraveInputID)
-	if err != nil {
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
 		return fmt.Errorf("failed to delete input: %w", err)
 	}
 
@@ -99,6 +177,7 @@ func (mcr *MCR) EndPlayout(ctx context.Context, po Playout) error {
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	mcr.recordPlayoutAsRun(ctx, AsRunPlayoutEnd, po, po.ScheduledEnd, reason)
 	return nil
 }
 
@@ -119,6 +198,10 @@ func (mcr *MCR) NewPlayout(ctx context.Context, po EditPlayout) (int, error) {
 	if po.ChannelID == 0 {
 		return 0, ErrChannelIDInvalid
 	}
+	err := mcr.setPlayoutMedia(ctx, &po)
+	if err != nil {
+		return 0, err
+	}
 	if po.SrcURI == "" {
 		return 0, ErrSrcURIEmpty
 	}
@@ -128,8 +211,34 @@ func (mcr *MCR) NewPlayout(ctx context.Context, po EditPlayout) (int, error) {
 	if po.Visibility == "" {
 		return 0, ErrVisibilityEmpty
 	}
+	if po.SrcType == "" {
+		po.SrcType = SourceURI
+	}
+	if po.EndMode == "" {
+		po.EndMode = EndHard
+	}
+	err = validatePlayoutModes(po)
+	if err != nil {
+		return 0, err
+	}
+	err = validatePlayoutTransitions(po)
+	if err != nil {
+		return 0, err
+	}
+	if po.Audio == nil {
+		a := DefaultAudioLevel()
+		po.Audio = &a
+	}
+	err = po.Audio.validate()
+	if err != nil {
+		return 0, err
+	}
+	err = mcr.CheckSchedule(ctx, 0, po)
+	if err != nil {
+		return 0, err
+	}
 
-	input, err := mcr.brave.NewURIInput(ctx, po.SrcURI, false)
+	input, err := mcr.brave.NewURIInput(ctx, po.SrcURI, false, po.Audio.braveVolume())
 	if err != nil {
 		return 0, fmt.Errorf("failed to create uri input: %w", err)
 	}
@@ -138,20 +247,22 @@ func (mcr *MCR) NewPlayout(ctx context.Context, po EditPlayout) (int, error) {
 	err = mcr.db.GetContext(ctx, &playoutID, `
 		INSERT INTO mcr.playouts (
 			brave_input_id, channel_id, source_type, source_uri, status, title,
-			description, scheduled_start, scheduled_end, visibility
+			description, scheduled_start, scheduled_end, visibility, end_mode,
+			media_id, start_transition, start_transition_duration, end_transition,
+			end_transition_duration, volume, gain, muted
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+						$18, $19)
 		RETURNING playout_id;`,
-		input.ID, po.ChannelID, "uri", po.SrcURI, "scheduled", po.Title,
-		po.Description, po.ScheduledStart, po.ScheduledEnd, po.Visibility)
+		input.ID, po.ChannelID, po.SrcType, po.SrcURI, "scheduled", po.Title,
+		po.Description, po.ScheduledStart, po.ScheduledEnd, po.Visibility, po.EndMode,
+		po.MediaID, po.StartTransition, po.StartTransitionDuration, po.EndTransition,
+		po.EndTransitionDuration, po.Audio.Volume, po.Audio.Gain, po.Audio.Muted)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert playout: %w", err)
 	}
 
-	err = mcr.refreshContinuityCard(ctx, po.ChannelID)
-	if err != nil {
-		return 0, fmt.Errorf("failed to refresh continuity card: %w", err)
-	}
+	mcr.requestCardRefresh(po.ChannelID)
 
 	return playoutID, nil
 }
@@ -162,7 +273,9 @@ func (mcr *MCR) GetPlayout(ctx context.Context, playoutID int) (Playout, error)
 	err := mcr.db.GetContext(ctx, &po, `
 		SELECT
 			playout_id, brave_input_id, channel_id, source_type, source_uri, status,
-			title, description, scheduled_start, scheduled_end, visibility
+			title, description, scheduled_start, scheduled_end, visibility, end_mode,
+			media_id, start_transition, start_transition_duration, end_transition,
+			end_transition_duration, volume, gain, muted
 		FROM mcr.playouts
 		WHERE playout_id  = $1;`, playoutID)
 	if err != nil {
@@ -180,7 +293,9 @@ func (mcr *MCR) GetPlayoutsForChannel(ctx context.Context, ch Channel) ([]Playou
 	err := mcr.db.SelectContext(ctx, &po, `
 		SELECT
 			playout_id, brave_input_id, channel_id, source_type, source_uri, status,
-			title, description, scheduled_start, scheduled_end, visibility
+			title, description, scheduled_start, scheduled_end, visibility, end_mode,
+			media_id, start_transition, start_transition_duration, end_transition,
+			end_transition_duration, volume, gain, muted
 		FROM mcr.playouts
 		WHERE channel_id  = $1
 		ORDER BY
@@ -211,9 +326,67 @@ func (mcr *MCR) UpdatePlayout(ctx context.Context, playoutID int, po EditPlayout
 		po.ChannelID = oldPo.ChannelID
 	}
 
+	// Moving a playout changes both of its channels.
+	unlock := mcr.lockChannels(oldPo.ChannelID, po.ChannelID)
+	defer unlock()
+	// It may have changed whilst waiting.
+	oldPo, err = mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing playout: %w", err)
+	}
+	// Its channel would be left showing an input that's gone.
+	if po.ChannelID != oldPo.ChannelID && oldPo.Status == "live" {
+		return ErrSourceOnAir
+	}
+
+	err = mcr.setPlayoutMedia(ctx, &po)
+	if err != nil {
+		return err
+	}
+	if po.MediaID == 0 && (po.SrcURI == "" || po.SrcURI == oldPo.SrcURI) {
+		po.MediaID = oldPo.MediaID
+	}
 	if po.SrcURI == "" {
 		po.SrcURI = oldPo.SrcURI
 	}
+	if po.SrcType == "" {
+		po.SrcType = oldPo.SrcType
+	}
+	if po.EndMode == "" {
+		po.EndMode = oldPo.EndMode
+	}
+	if po.StartTransition == "" {
+		po.StartTransition = oldPo.StartTransition
+		po.StartTransitionDuration = oldPo.StartTransitionDuration
+	}
+	if po.EndTransition == "" {
+		po.EndTransition = oldPo.EndTransition
+		po.EndTransitionDuration = oldPo.EndTransitionDuration
+	}
+	err = validatePlayoutModes(po)
+	if err != nil {
+		return err
+	}
+	err = validatePlayoutTransitions(po)
+	if err != nil {
+		return err
+	}
+	if po.Audio == nil {
+		po.Audio = &oldPo.AudioLevel
+	}
+	err = po.Audio.validate()
+	if err != nil {
+		return err
+	}
+	// Playouts are only checked when they move, so other edits don't fail on
+	// conflicts that were allowed.
+	if po.ChannelID != oldPo.ChannelID || !po.ScheduledStart.Equal(oldPo.ScheduledStart) ||
+		!po.ScheduledEnd.Equal(oldPo.ScheduledEnd) {
+		err = mcr.CheckSchedule(ctx, playoutID, po)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Check if we need to upate the playout's input
 	var inputID int
@@ -223,16 +396,22 @@ func (mcr *MCR) UpdatePlayout(ctx context.Context, playoutID int, po EditPlayout
 			return ErrSourceOnAir
 		}
 		err = mcr.brave.DeleteInput(ctx, oldPo.BraveInputID)
-		if err != nil {
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
 			return fmt.Errorf("failed to delete input: %w", err)
 		}
-		i, err := mcr.brave.NewURIInput(ctx, po.SrcURI, false)
+		i, err := mcr.brave.NewURIInput(ctx, po.SrcURI, false, po.Audio.braveVolume())
 		if err != nil {
 			return fmt.Errorf("failed to create new input: %w", err)
 		}
 		inputID = i.ID
 	} else {
 		inputID = oldPo.BraveInputID
+		if *po.Audio != oldPo.AudioLevel {
+			err = mcr.setInputAudio(ctx, inputID, *po.Audio)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	_, err = mcr.db.ExecContext(ctx, `
@@ -245,28 +424,51 @@ func (mcr *MCR) UpdatePlayout(ctx context.Context, playoutID int, po EditPlayout
 			description = $6,
 			scheduled_start = $7,
 			scheduled_end = $8,
-			visibility = $9
-		WHERE playout_id = $10;`,
-		inputID, po.ChannelID, "uri", po.SrcURI, po.Title, po.Description,
-		po.ScheduledStart, po.ScheduledEnd, po.Visibility, playoutID)
+			visibility = $9,
+			end_mode = $10,
+			media_id = $11,
+			start_transition = $12,
+			start_transition_duration = $13,
+			end_transition = $14,
+			end_transition_duration = $15,
+			volume = $16,
+			gain = $17,
+			muted = $18
+		WHERE playout_id = $19;`,
+		inputID, po.ChannelID, po.SrcType, po.SrcURI, po.Title, po.Description,
+		po.ScheduledStart, po.ScheduledEnd, po.Visibility, po.EndMode, po.MediaID,
+		po.StartTransition, po.StartTransitionDuration, po.EndTransition,
+		po.EndTransitionDuration, po.Audio.Volume, po.Audio.Gain, po.Audio.Muted, playoutID)
 	if err != nil {
 		return fmt.Errorf("failed to update playout: %w", err)
 	}
 
-	err = mcr.refreshContinuityCard(ctx, po.ChannelID)
-	if err != nil {
-		return fmt.Errorf("failed to refresh continuity card: %w", err)
+	mcr.requestCardRefresh(po.ChannelID)
+
+	// The playout has moved so needs to be removed from the old channel's card.
+	if po.ChannelID != oldPo.ChannelID {
+		mcr.requestCardRefresh(oldPo.ChannelID)
 	}
 
 	return nil
 }
 
-// DeletePlayout removes a playout.
+// DeletePlayout removes a playout, it can't be removed whilst it's live.
 func (mcr *MCR) DeletePlayout(ctx context.Context, playoutID int) error {
 	po, err := mcr.GetPlayout(ctx, playoutID)
 	if err != nil {
 		return fmt.Errorf("failed to get playout: %w", err)
 	}
+	unlock := mcr.lockChannel(po.ChannelID)
+	defer unlock()
+	// It may have changed whilst waiting.
+	po, err = mcr.GetPlayout(ctx, playoutID)
+	if err != nil {
+		return fmt.Errorf("failed to get playout: %w", err)
+	}
+	if po.Status == "live" {
+		return ErrSourceOnAir
+	}
 
 	_, err = mcr.db.ExecContext(ctx, `
 		DELETE FROM mcr.playouts
@@ -276,24 +478,53 @@ func (mcr *MCR) DeletePlayout(ctx context.Context, playoutID int) error {
 	}
 
 	err = mcr.brave.DeleteInput(ctx, po.BraveInputID)
-	if err != nil {
+	if err != nil && !errors.Is(err, brave.ErrNotFound) {
 		return fmt.Errorf("failed to delete input from brave: %w", err)
 	}
 
-	ch, err := mcr.GetChannel(ctx, po.ChannelID)
+	mcr.requestCardRefresh(po.ChannelID)
+	return nil
+}
+
+// setPlayoutMedia sets the source of a playout using media from the library.
+func (mcr *MCR) setPlayoutMedia(ctx context.Context, po *EditPlayout) error {
+	if po.MediaID == 0 {
+		return nil
+	}
+	m, err := mcr.GetMedia(ctx, po.MediaID)
 	if err != nil {
-		return fmt.Errorf("failed to get channel: %w", err)
+		return err
+	}
+	po.SrcURI = m.URI
+	if po.ScheduledEnd.IsZero() {
+		// Rounded up so the end of the media isn't cut off.
+		po.ScheduledEnd = po.ScheduledStart.Add(m.Duration.Truncate(time.Second) + time.Second)
 	}
+	return nil
+}
 
-	if ch.Status == "on-air" {
-		err = mcr.refreshContinuityCard(ctx, po.ChannelID)
-		if err != nil {
-			return fmt.Errorf("failed to refresh continuity card: %w", err)
-		}
+func validatePlayoutModes(po EditPlayout) error {
+	switch po.SrcType {
+	case SourceURI, SourceLivestream:
+	default:
+		return ErrSrcTypeInvalid
+	}
+	switch po.EndMode {
+	case EndHard, EndSoft:
+	default:
+		return ErrEndModeInvalid
 	}
 	return nil
 }
 
+func validatePlayoutTransitions(po EditPlayout) error {
+	err := validateTransition(po.StartTransition, po.StartTransitionDuration, true)
+	if err != nil {
+		return err
+	}
+	return validateTransition(po.EndTransition, po.EndTransitionDuration, true)
+}
+
 // PrettyDateTime formats dates to a more readable string.
 func (po *Playout) PrettyDateTime(ts time.Time) string {
 	if ts.After(time.Now().Add(time.Hour * 24)) {
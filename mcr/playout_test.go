@@ -0,0 +1,195 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/ystv/showtime/brave"
+)
+
+// startPlayoutFixture is an on-air channel showing its continuity card in
+// the simulated Brave, with a playout's source pre-rolled.
+type startPlayoutFixture struct {
+	mixer      brave.Mixer
+	continuity brave.Input
+	po         Playout
+}
+
+func newStartPlayoutFixture(t *testing.T, b *brave.Braver) startPlayoutFixture {
+	t.Helper()
+	ctx := context.Background()
+	m, err := b.NewMixer(ctx, brave.NewMixerParams{Width: 1280, Height: 720})
+	if err != nil {
+		t.Fatalf("failed to create mixer: %v", err)
+	}
+	card, err := b.NewURIInput(ctx, "http://showtime.test/card.mp4", true, 1)
+	if err != nil {
+		t.Fatalf("failed to create continuity input: %v", err)
+	}
+	err = b.CutMixerToInput(ctx, m.ID, card.ID)
+	if err != nil {
+		t.Fatalf("failed to cut to continuity: %v", err)
+	}
+	i, err := b.NewURIInput(ctx, "http://showtime.test/programme.mp4", false, 0.5)
+	if err != nil {
+		t.Fatalf("failed to create playout input: %v", err)
+	}
+	return startPlayoutFixture{
+		mixer:      m,
+		continuity: card,
+		po: Playout{
+			ID:             5,
+			ChannelID:      1,
+			BraveInputID:   i.ID,
+			Title:          "Programme",
+			ScheduledStart: time.Now(),
+		},
+	}
+}
+
+// expect expects the playout to be started with a channel transition.
+func (f startPlayoutFixture) expect(mock sqlmock.Sqlmock, t transition) {
+	mock.ExpectQuery("SELECT mixer_id, program_input_id, continuity_input_id, transition").
+		WithArgs(f.po.ChannelID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"mixer_id", "program_input_id", "continuity_input_id", "transition",
+			"transition_duration", "audio_only",
+		}).AddRow(f.mixer.ID, f.continuity.ID, f.continuity.ID, t.Type, t.Duration, false))
+	mock.ExpectExec("UPDATE mcr.channels SET program_input_id = $1 WHERE channel_id = $2").
+		WithArgs(f.po.BraveInputID, f.po.ChannelID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT playout_id, title, scheduled_start FROM mcr.playouts").
+		WithArgs(f.po.ChannelID, f.po.BraveInputID).
+		WillReturnRows(sqlmock.NewRows([]string{"playout_id", "title", "scheduled_start"}).
+			AddRow(f.po.ID, f.po.Title, f.po.ScheduledStart))
+	mock.ExpectExec("INSERT INTO mcr.as_run").
+		WithArgs(f.po.ChannelID, AsRunCut, ProgramPlayout, f.po.BraveInputID, f.po.ID,
+			f.po.Title, sqlmock.AnyArg(), AsRunSourceScheduler, "playout started").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE mcr.playouts SET status = 'live' WHERE playout_id = $1").
+		WithArgs(f.po.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAsRun(mock, f.po.ChannelID, AsRunPlayoutStart)
+}
+
+func TestStartPlayout(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := WithAsRunSource(context.Background(), AsRunSourceScheduler)
+	f := newStartPlayoutFixture(t, b)
+	f.expect(mock, cut)
+
+	err := mcr.StartPlayout(ctx, f.po)
+	if err != nil {
+		t.Fatalf("failed to start playout: %v", err)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{braveUID("input", f.po.BraveInputID)}
+	if got := programOf(t, b, f.mixer.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("program is %v, want %v", got, want)
+	}
+}
+
+func TestStartPlayoutCrossfade(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := WithAsRunSource(context.Background(), AsRunSourceScheduler)
+	f := newStartPlayoutFixture(t, b)
+	d := 3 * transitionStep
+	f.expect(mock, transition{Type: TransitionCrossfade, Duration: d})
+
+	start := time.Now()
+	err := mcr.StartPlayout(ctx, f.po)
+	if err != nil {
+		t.Fatalf("failed to start playout: %v", err)
+	}
+	if took := time.Since(start); took < d {
+		t.Errorf("crossfade took %s, want at least %s", took, d)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{braveUID("input", f.po.BraveInputID)}
+	if got := programOf(t, b, f.mixer.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("program is %v, want %v", got, want)
+	}
+	// Both sources are back at their levels once their sound has crossed.
+	for id, want := range map[int]float64{f.continuity.ID: 1, f.po.BraveInputID: 0.5} {
+		i, err := b.GetInput(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to get input %d: %v", id, err)
+		}
+		if i.Volume != want {
+			t.Errorf("input %d volume is %v, want %v", id, i.Volume, want)
+		}
+	}
+}
+
+func TestStartPlayoutWaitsForChannel(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := WithAsRunSource(context.Background(), AsRunSourceScheduler)
+	f := newStartPlayoutFixture(t, b)
+	f.expect(mock, cut)
+
+	// As if the scheduler were part way through a transition.
+	unlock := mcr.lockChannel(f.po.ChannelID)
+	started := make(chan error)
+	go func() {
+		started <- mcr.StartPlayout(ctx, f.po)
+	}()
+	select {
+	case err := <-started:
+		t.Fatalf("playout started whilst the channel was locked: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	want := []string{braveUID("input", f.continuity.ID)}
+	if got := programOf(t, b, f.mixer.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("program is %v whilst locked, want %v", got, want)
+	}
+
+	unlock()
+	err := <-started
+	if err != nil {
+		t.Fatalf("failed to start playout: %v", err)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeletePlayoutWhenLive(t *testing.T) {
+	mcr, mock, b := newTestMCR(t)
+	ctx := context.Background()
+	f := newStartPlayoutFixture(t, b)
+	po := f.po
+	po.Status = "live"
+	// Once to find its channel and again with the channel locked.
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT playout_id, brave_input_id, channel_id").
+			WithArgs(po.ID).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"playout_id", "brave_input_id", "channel_id", "status", "title",
+			}).AddRow(po.ID, po.BraveInputID, po.ChannelID, po.Status, po.Title))
+	}
+
+	err := mcr.DeletePlayout(ctx, po.ID)
+	if !errors.Is(err, ErrSourceOnAir) {
+		t.Fatalf("got %v, want %v", err, ErrSourceOnAir)
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.GetInput(ctx, po.BraveInputID)
+	if err != nil {
+		t.Errorf("live playout's input was deleted: %v", err)
+	}
+}
@@ -0,0 +1,679 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/metrics"
+)
+
+type (
+	// ReconcileReport is what a reconciliation changed.
+	ReconcileReport struct {
+		Changes []ReconcileChange `json:"changes"`
+		// Errors are channels and playouts that couldn't be reconciled, they
+		// are tried again on the next reconciliation.
+		Errors []string `json:"errors"`
+	}
+	// ReconcileChange is a change made to a Brave object or a stored ID.
+	ReconcileChange struct {
+		Action    string `json:"action"`
+		Object    string `json:"object"`
+		BraveID   int    `json:"braveID"`
+		ChannelID int    `json:"channelID,omitempty"`
+		PlayoutID int    `json:"playoutID,omitempty"`
+		Reason    string `json:"reason"`
+	}
+	// braveState is the objects that currently exist in Brave.
+	braveState struct {
+		mixers   map[int]brave.Mixer
+		inputs   map[int]brave.Input
+		outputs  map[int]brave.Output
+		overlays map[int]brave.Overlay
+	}
+	// reconcilePlayout is the part of a playout the reconciler needs.
+	reconcilePlayout struct {
+		ID           int    `db:"playout_id"`
+		ChannelID    int    `db:"channel_id"`
+		BraveInputID int    `db:"brave_input_id"`
+		SrcURI       string `db:"source_uri"`
+		Status       string `db:"status"`
+		AudioLevel
+	}
+	// reconcileOverlays are the overlays drawing a channel's graphics.
+	reconcileOverlays struct {
+		ChannelID int `db:"channel_id"`
+		channelOverlays
+	}
+)
+
+// Reconcile actions.
+const (
+	// ReconcileCreated is a Brave object created to replace a missing one.
+	ReconcileCreated = "created"
+	// ReconcileDeleted is a Brave object deleted since nothing referenced it.
+	ReconcileDeleted = "deleted"
+	// ReconcileCut is a channel cut back to its program.
+	ReconcileCut = "cut"
+	// ReconcilePlayed is a live playout's input that had stopped playing.
+	ReconcilePlayed = "played"
+	// ReconcileCleared is a stored ID cleared since it pointed at nothing.
+	ReconcileCleared = "cleared"
+)
+
+// Reconcile makes Brave match the store, for when Brave has restarted or
+// objects have been changed behind ShowTime!'s back.
+//
+// On-air channels get any missing mixer or outputs rebuilt and are cut back to
+// their live playout or continuity when the mixer isn't showing their program.
+// A missing continuity card is redrawn by RunCardRenderer once the channels
+// are unlocked. Scheduled and live playouts get missing inputs
+// recreated, and live ones that have stopped are played again. The new IDs are
+// stored, and IDs of other channels that point at nothing are cleared. The IDs
+// of overlays that are missing or not on their channel's mixer are cleared for
+// the scheduler to draw them again. Brave objects that nothing references are
+// deleted once two reconciliations in a row have found them, so objects in
+// the middle of being set up aren't removed.
+func (mcr *MCR) Reconcile(ctx context.Context) (ReconcileReport, error) {
+	mcr.reconcileMu.Lock()
+	defer mcr.reconcileMu.Unlock()
+
+	// Every channel is locked before anything is read, so none changes part
+	// way through.
+	locked := []int{}
+	err := mcr.db.SelectContext(ctx, &locked, `
+		SELECT channel_id
+		FROM mcr.channels
+		ORDER BY channel_id;`)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get channel ids: %w", err)
+	}
+	isLocked := make(map[int]bool, len(locked))
+	for _, channelID := range locked {
+		unlock := mcr.lockChannel(channelID)
+		defer unlock()
+		isLocked[channelID] = true
+	}
+
+	state, err := mcr.getBraveState(ctx)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get brave state: %w", err)
+	}
+
+	playouts := []reconcilePlayout{}
+	err = mcr.db.SelectContext(ctx, &playouts, `
+		SELECT playout_id, channel_id, brave_input_id, source_uri, status, volume,
+					 gain, muted
+		FROM mcr.playouts
+		ORDER BY playout_id;`)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get playouts: %w", err)
+	}
+	channels := []Channel{}
+	err = mcr.db.SelectContext(ctx, &channels, `
+		SELECT channel_id, status, title, url_name, res_width, res_height, mixer_id,
+					 program_input_id, continuity_input_id,
+					 filler_order, filler_input_id, audio_only, slate_input_id
+		FROM mcr.channels
+		ORDER BY channel_id;`)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get channels: %w", err)
+	}
+	outputs := []ChannelOutput{}
+	err = mcr.db.SelectContext(ctx, &outputs, `
+		SELECT channel_output_id, channel_id, output_type, name, url, port, brave_output_id
+		FROM mcr.channel_outputs
+		ORDER BY channel_output_id;`)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get outputs: %w", err)
+	}
+	channelOutputs := map[int][]ChannelOutput{}
+	for _, o := range outputs {
+		channelOutputs[o.ChannelID] = append(channelOutputs[o.ChannelID], o)
+	}
+	graphics := []reconcileOverlays{}
+	err = mcr.db.SelectContext(ctx, &graphics, `
+		SELECT channel_id, bug_overlay_id, clock_overlay_id, strap_overlay_id
+		FROM mcr.channel_graphics;`)
+	if err != nil {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+		return ReconcileReport{}, fmt.Errorf("failed to get overlays: %w", err)
+	}
+	overlays := make(map[int]channelOverlays, len(graphics))
+	for _, g := range graphics {
+		overlays[g.ChannelID] = g.channelOverlays
+	}
+
+	r := ReconcileReport{
+		Changes: []ReconcileChange{},
+		Errors:  []string{},
+	}
+
+	// Playouts go first so channels can be cut back to their live playout.
+	liveInputs := map[int]int{}
+	for i := range playouts {
+		po := &playouts[i]
+		if (po.Status != "scheduled" && po.Status != "live") || !isLocked[po.ChannelID] {
+			continue
+		}
+		err = mcr.reconcilePlayout(ctx, state, po, &r)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("playout %d: %v", po.ID, err))
+			continue
+		}
+		if po.Status == "live" {
+			liveInputs[po.ChannelID] = po.BraveInputID
+		}
+	}
+
+	for i := range channels {
+		ch := &channels[i]
+		if !isLocked[ch.ID] {
+			// Created since the channels were locked, it's left until next
+			// time.
+			continue
+		}
+		o := overlays[ch.ID]
+		err = mcr.reconcileChannel(ctx, state, ch, channelOutputs[ch.ID], &o, liveInputs[ch.ID], &r)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("channel %d: %v", ch.ID, err))
+		}
+		overlays[ch.ID] = o
+	}
+
+	mcr.deleteOrphans(ctx, state, channels, channelOutputs, overlays, playouts, &r)
+
+	if len(r.Errors) > 0 {
+		metrics.ReconcileRuns.WithLabelValues("failed").Inc()
+	} else {
+		metrics.ReconcileRuns.WithLabelValues("ok").Inc()
+	}
+	if len(r.Changes) > 0 || len(r.Errors) > 0 {
+		log.Printf("reconciled brave: %d changes, %d errors", len(r.Changes), len(r.Errors))
+		for _, e := range r.Errors {
+			log.Printf("reconcile: %s", e)
+		}
+	}
+	return r, nil
+}
+
+// RunReconciler reconciles straight away and then every interval until the
+// context is done. It only reconciles once when the interval isn't positive.
+func (mcr *MCR) RunReconciler(ctx context.Context, interval time.Duration) {
+	ctx = WithAsRunSource(ctx, AsRunSourceReconciler)
+	for {
+		_, err := mcr.Reconcile(ctx)
+		if err != nil {
+			log.Printf("failed to reconcile brave: %v", err)
+		}
+		if interval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (mcr *MCR) getBraveState(ctx context.Context) (braveState, error) {
+	all, err := mcr.brave.GetAll(ctx)
+	if err != nil {
+		return braveState{}, err
+	}
+
+	state := braveState{
+		mixers:   make(map[int]brave.Mixer, len(all.Mixers)),
+		inputs:   make(map[int]brave.Input, len(all.Inputs)),
+		outputs:  make(map[int]brave.Output, len(all.Outputs)),
+		overlays: make(map[int]brave.Overlay, len(all.Overlays)),
+	}
+	for _, m := range all.Mixers {
+		state.mixers[m.ID] = m
+	}
+	for _, i := range all.Inputs {
+		state.inputs[i.ID] = i
+	}
+	for _, o := range all.Outputs {
+		state.outputs[o.ID] = o
+	}
+	for _, o := range all.Overlays {
+		state.overlays[o.ID] = o
+	}
+	return state, nil
+}
+
+// inProgram checks an input is part of a mixer's program.
+func inProgram(m brave.Mixer, inputID int) bool {
+	for _, uid := range m.Program() {
+		if uid == braveUID("input", inputID) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcilePlayout recreates a playout's input when it is missing. Brave
+// reuses IDs after restarting, so the input must also have the playout's
+// source.
+func (mcr *MCR) reconcilePlayout(ctx context.Context, state braveState, po *reconcilePlayout, r *ReconcileReport) error {
+	i, ok := state.inputs[po.BraveInputID]
+	if ok && i.URI == po.SrcURI {
+		return nil
+	}
+
+	i, err := mcr.brave.NewURIInput(ctx, po.SrcURI, false, po.braveVolume())
+	if err != nil {
+		return fmt.Errorf("failed to create uri input: %w", err)
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.playouts
+		SET brave_input_id = $1
+		WHERE playout_id = $2;
+	`, i.ID, po.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update input in store: %w", err)
+	}
+	r.record(ReconcileChange{
+		Action:    ReconcileCreated,
+		Object:    "input",
+		BraveID:   i.ID,
+		ChannelID: po.ChannelID,
+		PlayoutID: po.ID,
+		Reason:    fmt.Sprintf("playout input %d missing", po.BraveInputID),
+	})
+	po.BraveInputID = i.ID
+	return nil
+}
+
+// reconcileChannel rebuilds what an on-air channel needs in Brave, or clears
+// the IDs of any other channel that point at nothing. The channel's outputs
+// and overlays are updated with any new or cleared IDs.
+func (mcr *MCR) reconcileChannel(ctx context.Context, state braveState, ch *Channel, outputs []ChannelOutput, overlays *channelOverlays, liveInputID int, r *ReconcileReport) error {
+	if ch.Status != "on-air" {
+		err := mcr.reconcileOverlays(ctx, state, ch, overlays, r)
+		if err != nil {
+			return err
+		}
+		return mcr.clearMissingIDs(ctx, state, ch, outputs, r)
+	}
+
+	var err error
+	m, ok := state.mixers[ch.MixerID]
+	if !ok {
+		m, err = mcr.brave.NewMixer(ctx, brave.NewMixerParams{
+			Width:  ch.Width,
+			Height: ch.Height,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create mixer: %w", err)
+		}
+		r.record(ReconcileChange{
+			Action:    ReconcileCreated,
+			Object:    "mixer",
+			BraveID:   m.ID,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("mixer %d missing", ch.MixerID),
+		})
+		ch.MixerID = m.ID
+	}
+
+	for i := range outputs {
+		err = mcr.reconcileOutput(ctx, state, m, ch, &outputs[i], r)
+		if err != nil {
+			return err
+		}
+	}
+	err = mcr.reconcileOverlays(ctx, state, ch, overlays, r)
+	if err != nil {
+		return err
+	}
+
+	// The mixer knows what it is actually showing, which is gone when it has
+	// been rebuilt.
+	continuityOK := mcr.isContinuityInput(state, ch)
+	_, programOK := state.inputs[ch.ProgramInputID]
+	programOK = programOK && inProgram(m, ch.ProgramInputID)
+	if ch.AudioOnly && continuityOK && !inProgram(m, ch.ContinuityInputID) {
+		// An audio-only channel's card is its picture, whatever is on.
+		programOK = false
+	}
+	needsCut := !programOK || (ch.ProgramInputID == ch.ContinuityInputID && !continuityOK)
+	if needsCut && liveInputID == 0 && !continuityOK {
+		// Left for refreshing the continuity card to cut to.
+		ch.ProgramInputID = 0
+	}
+	if !continuityOK {
+		if ch.ContinuityInputID != 0 {
+			r.record(ReconcileChange{
+				Action:    ReconcileCleared,
+				Object:    "input",
+				BraveID:   ch.ContinuityInputID,
+				ChannelID: ch.ID,
+				Reason:    fmt.Sprintf("continuity input %d missing", ch.ContinuityInputID),
+			})
+		}
+		ch.ContinuityInputID = 0
+	}
+	if _, ok := state.inputs[ch.FillerInputID]; ch.FillerInputID != 0 && !ok {
+		// The scheduler picks another filler.
+		r.record(ReconcileChange{
+			Action:    ReconcileCleared,
+			Object:    "input",
+			BraveID:   ch.FillerInputID,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("filler input %d missing", ch.FillerInputID),
+		})
+		ch.FillerInputID = 0
+	}
+	if _, ok := state.inputs[ch.SlateInputID]; ch.SlateInputID != 0 && !ok {
+		// The scheduler fails over again if the playout is still failing.
+		r.record(ReconcileChange{
+			Action:    ReconcileCleared,
+			Object:    "input",
+			BraveID:   ch.SlateInputID,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("slate input %d missing", ch.SlateInputID),
+		})
+		ch.SlateInputID = 0
+	}
+	err = mcr.storeChannelIDs(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	if needsCut && (liveInputID != 0 || continuityOK) {
+		reason := "cut to continuity"
+		inputID := ch.ContinuityInputID
+		if liveInputID != 0 {
+			reason = "cut to live playout"
+			inputID = liveInputID
+			err = mcr.brave.PlayInput(ctx, inputID)
+			if err != nil {
+				return fmt.Errorf("failed to play live playout input: %w", err)
+			}
+		}
+		err = mcr.setChannelProgram(ctx, ch.ID, inputID, cut, "reconciled: "+reason)
+		if err != nil {
+			return fmt.Errorf("failed to set channel program: %w", err)
+		}
+		r.record(ReconcileChange{
+			Action:    ReconcileCut,
+			Object:    "mixer",
+			BraveID:   ch.MixerID,
+			ChannelID: ch.ID,
+			Reason:    reason,
+		})
+		ch.ProgramInputID = inputID
+	}
+
+	if i, ok := state.inputs[liveInputID]; ok && !needsCut && i.State != brave.StatePlaying {
+		err = mcr.brave.PlayInput(ctx, liveInputID)
+		if err != nil {
+			return fmt.Errorf("failed to play live playout input: %w", err)
+		}
+		r.record(ReconcileChange{
+			Action:    ReconcilePlayed,
+			Object:    "input",
+			BraveID:   liveInputID,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("live playout input %s", strings.ToLower(string(i.State))),
+		})
+	}
+
+	if !continuityOK {
+		// Drawing the card takes too long to hold every channel for, the card
+		// renderer swaps it in once the channels are unlocked.
+		mcr.requestCardRefresh(ch.ID)
+	}
+	return nil
+}
+
+// reconcileOutput recreates a channel's output when it is missing or isn't of
+// the channel's mixer to the output's destination.
+func (mcr *MCR) reconcileOutput(ctx context.Context, state braveState, m brave.Mixer, ch *Channel, o *ChannelOutput, r *ReconcileReport) error {
+	bo, ok := state.outputs[o.BraveOutputID]
+	if ok && mcr.isBraveOutput(bo, m, *ch, *o) {
+		return nil
+	}
+	if ok && mcr.hasOutputDestination(bo, *ch, *o) {
+		// The channel's old output, it would fight the new one for the
+		// destination until it was deleted as an orphan.
+		err := mcr.brave.DeleteOutput(ctx, bo.ID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to delete old output: %w", err)
+		}
+		delete(state.outputs, bo.ID)
+		r.record(ReconcileChange{
+			Action:    ReconcileDeleted,
+			Object:    "output",
+			BraveID:   bo.ID,
+			ChannelID: ch.ID,
+			Reason:    "replaced by a new output",
+		})
+	}
+	reason := fmt.Sprintf("%s output %d missing", o.Name, o.BraveOutputID)
+	if ok {
+		reason = fmt.Sprintf("%s output %d not from mixer %d", o.Name, o.BraveOutputID, m.ID)
+	}
+	bo, err := mcr.newBraveOutput(ctx, m, *ch, *o)
+	if err != nil {
+		return fmt.Errorf("failed to create %s output: %w", o.Name, err)
+	}
+	r.record(ReconcileChange{
+		Action:    ReconcileCreated,
+		Object:    "output",
+		BraveID:   bo.ID,
+		ChannelID: ch.ID,
+		Reason:    reason,
+	})
+	o.BraveOutputID = bo.ID
+	return mcr.storeOutputID(ctx, *o)
+}
+
+// reconcileOverlays clears the IDs of a channel's overlays that are missing,
+// or aren't of their type on its mixer when it is on-air. What the channel's
+// overlays show is forgotten, so the scheduler replaces them all.
+func (mcr *MCR) reconcileOverlays(ctx context.Context, state braveState, ch *Channel, overlays *channelOverlays, r *ReconcileReport) error {
+	cleared := *overlays
+	for _, overlay := range []struct {
+		name string
+		typ  string
+		id   *int
+	}{
+		{"bug", brave.OverlayImage, &cleared.BugOverlayID},
+		{"clock", brave.OverlayClock, &cleared.ClockOverlayID},
+		{"strap", brave.OverlayText, &cleared.StrapOverlayID},
+	} {
+		if *overlay.id == 0 {
+			continue
+		}
+		o, ok := state.overlays[*overlay.id]
+		reason := fmt.Sprintf("%s overlay %d missing", overlay.name, *overlay.id)
+		if ok {
+			if ch.Status != "on-air" || (o.Type == overlay.typ && o.Src == braveUID("mixer", ch.MixerID)) {
+				continue
+			}
+			reason = fmt.Sprintf("%s overlay %d not on mixer %d", overlay.name, *overlay.id, ch.MixerID)
+		}
+		r.record(ReconcileChange{
+			Action:    ReconcileCleared,
+			Object:    "overlay",
+			BraveID:   *overlay.id,
+			ChannelID: ch.ID,
+			Reason:    reason,
+		})
+		*overlay.id = 0
+	}
+	if cleared == *overlays {
+		return nil
+	}
+	mcr.setShownGraphics(ch.ID, nil)
+	*overlays = cleared
+	return mcr.storeOverlayIDs(ctx, ch.ID, cleared)
+}
+
+// clearMissingIDs clears the IDs of a channel that isn't on-air which point at
+// nothing, there is nothing to rebuild.
+func (mcr *MCR) clearMissingIDs(ctx context.Context, state braveState, ch *Channel, outputs []ChannelOutput, r *ReconcileReport) error {
+	cleared := false
+	clearID := func(object string, id *int) {
+		r.record(ReconcileChange{
+			Action:    ReconcileCleared,
+			Object:    object,
+			BraveID:   *id,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("%s %d missing", object, *id),
+		})
+		*id = 0
+		cleared = true
+	}
+	if _, ok := state.mixers[ch.MixerID]; ch.MixerID != 0 && !ok {
+		clearID("mixer", &ch.MixerID)
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		if _, ok := state.outputs[o.BraveOutputID]; o.BraveOutputID == 0 || ok {
+			continue
+		}
+		r.record(ReconcileChange{
+			Action:    ReconcileCleared,
+			Object:    "output",
+			BraveID:   o.BraveOutputID,
+			ChannelID: ch.ID,
+			Reason:    fmt.Sprintf("%s output %d missing", o.Name, o.BraveOutputID),
+		})
+		o.BraveOutputID = 0
+		err := mcr.storeOutputID(ctx, *o)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := state.inputs[ch.ProgramInputID]; ch.ProgramInputID != 0 && !ok {
+		clearID("input", &ch.ProgramInputID)
+	}
+	if ch.ContinuityInputID != 0 && !mcr.isContinuityInput(state, ch) {
+		clearID("input", &ch.ContinuityInputID)
+	}
+	if _, ok := state.inputs[ch.FillerInputID]; ch.FillerInputID != 0 && !ok {
+		clearID("input", &ch.FillerInputID)
+	}
+	if _, ok := state.inputs[ch.SlateInputID]; ch.SlateInputID != 0 && !ok {
+		clearID("input", &ch.SlateInputID)
+	}
+	if !cleared {
+		return nil
+	}
+	return mcr.storeChannelIDs(ctx, ch)
+}
+
+// isContinuityInput checks a channel's continuity input exists and is its
+// continuity card, Brave reuses IDs after restarting.
+func (mcr *MCR) isContinuityInput(state braveState, ch *Channel) bool {
+	i, ok := state.inputs[ch.ContinuityInputID]
+	return ok && i.URI == mcr.continuityCardURI(ch.ID)
+}
+
+func (mcr *MCR) storeChannelIDs(ctx context.Context, ch *Channel) error {
+	_, err := mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			mixer_id = $1,
+			program_input_id = $2,
+			continuity_input_id = $3,
+			filler_input_id = $4,
+			slate_input_id = $5
+		WHERE channel_id = $6;
+	`, ch.MixerID, ch.ProgramInputID, ch.ContinuityInputID, ch.FillerInputID, ch.SlateInputID, ch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update channel in store: %w", err)
+	}
+	return nil
+}
+
+// deleteOrphans deletes the Brave objects that nothing references and were
+// also orphaned on the last reconciliation, remembering the rest for the next.
+func (mcr *MCR) deleteOrphans(ctx context.Context, state braveState, channels []Channel, outputs map[int][]ChannelOutput, overlays map[int]channelOverlays, playouts []reconcilePlayout, r *ReconcileReport) {
+	referenced := map[string]bool{}
+	for _, ch := range channels {
+		referenced[braveUID("mixer", ch.MixerID)] = true
+		for _, o := range outputs[ch.ID] {
+			referenced[braveUID("output", o.BraveOutputID)] = true
+		}
+		referenced[braveUID("overlay", overlays[ch.ID].BugOverlayID)] = true
+		referenced[braveUID("overlay", overlays[ch.ID].ClockOverlayID)] = true
+		referenced[braveUID("overlay", overlays[ch.ID].StrapOverlayID)] = true
+		referenced[braveUID("input", ch.ProgramInputID)] = true
+		referenced[braveUID("input", ch.ContinuityInputID)] = true
+		referenced[braveUID("input", ch.FillerInputID)] = true
+		referenced[braveUID("input", ch.SlateInputID)] = true
+	}
+	for _, po := range playouts {
+		referenced[braveUID("input", po.BraveInputID)] = true
+	}
+
+	orphans := map[string]bool{}
+	deleteOrphan := func(object string, id int, del func(context.Context, int) error) {
+		uid := braveUID(object, id)
+		if referenced[uid] {
+			return
+		}
+		if !mcr.orphans[uid] {
+			orphans[uid] = true
+			return
+		}
+		err := del(ctx, id)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			orphans[uid] = true
+			r.Errors = append(r.Errors, fmt.Sprintf("%s: failed to delete: %v", uid, err))
+			return
+		}
+		r.record(ReconcileChange{
+			Action:  ReconcileDeleted,
+			Object:  object,
+			BraveID: id,
+			Reason:  "not referenced by any channel or playout",
+		})
+	}
+	// Outputs and overlays then mixers so nothing is left sourced from a
+	// deleted object.
+	for id := range state.overlays {
+		deleteOrphan("overlay", id, mcr.brave.DeleteOverlay)
+	}
+	for id := range state.outputs {
+		deleteOrphan("output", id, mcr.brave.DeleteOutput)
+	}
+	for id := range state.mixers {
+		deleteOrphan("mixer", id, mcr.brave.DeleteMixer)
+	}
+	for id := range state.inputs {
+		deleteOrphan("input", id, mcr.brave.DeleteInput)
+	}
+	mcr.orphans = orphans
+}
+
+// braveUID is how Brave identifies an object, i.e. "mixer1".
+func braveUID(object string, id int) string {
+	return fmt.Sprintf("%s%d", object, id)
+}
+
+// record adds a change to the report, logging it.
+func (r *ReconcileReport) record(c ReconcileChange) {
+	r.Changes = append(r.Changes, c)
+	metrics.ReconcileChanges.WithLabelValues(c.Object, c.Action).Inc()
+	if c.ChannelID != 0 {
+		log.Printf("reconcile: channel %d: %s %s %d: %s", c.ChannelID, c.Action, c.Object, c.BraveID, c.Reason)
+		return
+	}
+	log.Printf("reconcile: %s %s %d: %s", c.Action, c.Object, c.BraveID, c.Reason)
+}
@@ -0,0 +1,287 @@
+package mcr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+	"github.com/ystv/showtime/metrics"
+)
+
+// scheduledPlayout is a playout and the program and failover of its on-air
+// channel.
+type scheduledPlayout struct {
+	Playout
+	ProgramInputID    int    `db:"program_input_id"`
+	ContinuityInputID int    `db:"continuity_input_id"`
+	Failover          string `db:"failover"`
+	FailoverPlayoutID int    `db:"failover_playout_id"`
+	SlateInputID      int    `db:"slate_input_id"`
+}
+
+// schedulerInterval is how often the scheduler checks on-air channels.
+const schedulerInterval = time.Second
+
+// Scheduler actions, counted by metrics.SchedulerActions.
+const (
+	schedulePreRoll = "preroll"
+	scheduleStart   = "start"
+	scheduleEnd     = "end"
+	scheduleFiller  = "filler"
+)
+
+// RunScheduler takes on-air channels to their scheduled playouts until the
+// context is done.
+//
+// A playout's source is played PreRoll before its scheduled start, so the
+// channel can cut straight to it when it starts, ending the playout before it.
+// At the scheduled end a hard end playout is cut back to continuity, a soft
+// end one is left to finish until its source ends, the next playout starts or
+// it overruns by SoftEndLimit. Playouts of livestreams are started and ended
+// with their livestream, scheduled playouts wait for a live one to end.
+//
+// A live playout whose source fails is failed over from, see
+// scheduleFailover. Between playouts a channel's fillers are played, see
+// fillChannel. A channel's graphics are drawn over whatever is on, see
+// scheduleGraphics. Channels change source with their transition, or a
+// playout's own, which is waited for. WatchBrave wakes it when an input is
+// lost.
+func (mcr *MCR) RunScheduler(ctx context.Context) {
+	ctx = WithAsRunSource(ctx, AsRunSourceScheduler)
+	t := time.NewTicker(schedulerInterval)
+	defer t.Stop()
+	for {
+		err := mcr.schedule(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to schedule playouts: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-mcr.scheduleWake:
+		case <-t.C:
+		}
+	}
+}
+
+// schedule does whatever is due on each on-air channel.
+//
+// Channels are scheduled at the same time, each holding only its own lock.
+func (mcr *MCR) schedule(ctx context.Context, now time.Time) error {
+	playouts := []scheduledPlayout{}
+	err := mcr.db.SelectContext(ctx, &playouts, `
+		SELECT p.playout_id, p.channel_id, p.brave_input_id, p.source_type,
+					 p.source_uri, p.status, p.title, p.description, p.scheduled_start,
+					 p.scheduled_end, p.visibility, p.end_mode, p.media_id,
+					 p.start_transition, p.start_transition_duration, p.end_transition,
+					 p.end_transition_duration, c.program_input_id, c.continuity_input_id,
+					 c.failover, c.failover_playout_id, c.slate_input_id
+		FROM mcr.playouts p
+		INNER JOIN mcr.channels c ON c.channel_id = p.channel_id
+		WHERE c.status = 'on-air'
+		AND (
+			p.status = 'live'
+			OR (
+				p.status = 'scheduled'
+				AND p.source_type != 'livestream'
+				AND p.scheduled_start <= $1
+				AND p.scheduled_end > $2
+			)
+		)
+		ORDER BY p.channel_id, p.scheduled_start;`, now.Add(mcr.preRoll), now)
+	if err != nil {
+		return fmt.Errorf("failed to get playouts: %w", err)
+	}
+
+	// Inputs are only listed when a channel has something to do.
+	var state map[int]brave.Input
+	getInputs := func() (map[int]brave.Input, error) {
+		if state != nil {
+			return state, nil
+		}
+		inputs, err := mcr.brave.ListInputs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list inputs: %w", err)
+		}
+		state = make(map[int]brave.Input, len(inputs))
+		for _, i := range inputs {
+			state[i.ID] = i
+		}
+		return state, nil
+	}
+
+	// Failures are only tracked for playouts that are still live.
+	live := make(map[int]bool, len(playouts))
+	for _, po := range playouts {
+		if po.Status == "live" {
+			live[po.ID] = true
+		}
+	}
+	mcr.failingMu.Lock()
+	for playoutID := range mcr.failingSince {
+		if !live[playoutID] {
+			delete(mcr.failingSince, playoutID)
+		}
+	}
+	mcr.failingMu.Unlock()
+
+	if len(playouts) > 0 {
+		inputs, err := getInputs()
+		if err != nil {
+			return err
+		}
+		channelIDs := []int{}
+		channelPlayouts := map[int][]scheduledPlayout{}
+		for _, po := range playouts {
+			if _, ok := channelPlayouts[po.ChannelID]; !ok {
+				channelIDs = append(channelIDs, po.ChannelID)
+			}
+			channelPlayouts[po.ChannelID] = append(channelPlayouts[po.ChannelID], po)
+		}
+		mcr.eachChannel(channelIDs, func(channelID int) {
+			err := mcr.scheduleChannel(ctx, now, channelPlayouts[channelID], inputs)
+			if err != nil {
+				log.Printf("failed to schedule channel %d: %v", channelID, err)
+			}
+		})
+	}
+
+	// After the playouts, so fillers see the channels they have started.
+	err = mcr.scheduleFillers(ctx, now, getInputs)
+	if err != nil {
+		return err
+	}
+	return mcr.scheduleGraphics(ctx, now)
+}
+
+// scheduleChannel pre-rolls, starts and ends a channel's playouts, which are
+// in schedule order. The caller must hold the channel's lock.
+func (mcr *MCR) scheduleChannel(ctx context.Context, now time.Time, playouts []scheduledPlayout, inputs map[int]brave.Input) error {
+	var (
+		live        []scheduledPlayout
+		due         *scheduledPlayout
+		pending     []scheduledPlayout
+		livestreams []scheduledPlayout
+	)
+	for i := range playouts {
+		po := playouts[i]
+		switch {
+		case po.Status == "live" && po.SrcType == SourceLivestream:
+			livestreams = append(livestreams, po)
+		case po.Status == "live":
+			live = append(live, po)
+		case !po.ScheduledStart.After(now):
+			// When playouts overlap the one starting last wins.
+			due = &playouts[i]
+		default:
+			pending = append(pending, po)
+		}
+	}
+
+	// A playout that started before a live one has been overtaken by it, it
+	// isn't cut back to.
+	if due != nil && len(live) > 0 && !due.ScheduledStart.After(live[len(live)-1].ScheduledStart) {
+		due = nil
+	}
+	if due != nil && len(livestreams) == 0 {
+		err := mcr.preRollPlayout(ctx, due.Playout, inputs)
+		if err != nil {
+			// The live playouts are still ended and failed over from, the
+			// due one is tried again on the next tick.
+			log.Printf("channel %d not starting playout %d: %v", due.ChannelID, due.ID, err)
+			due = nil
+		}
+	}
+	if due != nil && len(livestreams) == 0 {
+		log.Printf("channel %d starting playout %d %q", due.ChannelID, due.ID, due.Title)
+		err := mcr.startPlayout(ctx, due.Playout)
+		if err != nil {
+			return fmt.Errorf("failed to start playout %d: %w", due.ID, err)
+		}
+		metrics.SchedulerActions.WithLabelValues(scheduleStart).Inc()
+
+		// The channel has already cut away from the previous playouts.
+		for _, po := range live {
+			log.Printf("channel %d ending playout %d %q: next playout started", po.ChannelID, po.ID, po.Title)
+			err = mcr.endPlayout(ctx, po.Playout, false, "next playout started")
+			if err != nil {
+				return fmt.Errorf("failed to end playout %d: %w", po.ID, err)
+			}
+			metrics.SchedulerActions.WithLabelValues(scheduleEnd).Inc()
+		}
+		live = nil
+	}
+
+	var playing []scheduledPlayout
+	for _, po := range live {
+		reason := mcr.endReason(now, po.Playout, inputs)
+		if reason == "" {
+			playing = append(playing, po)
+			continue
+		}
+		log.Printf("channel %d ending playout %d %q: %s", po.ChannelID, po.ID, po.Title, reason)
+		// Only cut to continuity when the channel is still showing the
+		// playout, a livestream could have been started over it.
+		err := mcr.endPlayout(ctx, po.Playout, po.ProgramInputID == po.BraveInputID, reason)
+		if err != nil {
+			return fmt.Errorf("failed to end playout %d: %w", po.ID, err)
+		}
+		metrics.SchedulerActions.WithLabelValues(scheduleEnd).Inc()
+	}
+
+	// Livestreams only end with their livestream, but their source can still
+	// fail.
+	for _, po := range append(livestreams, playing...) {
+		err := mcr.scheduleFailover(ctx, now, po, inputs)
+		if err != nil {
+			return fmt.Errorf("failed to check playout %d failover: %w", po.ID, err)
+		}
+	}
+
+	for _, po := range pending {
+		err := mcr.preRollPlayout(ctx, po.Playout, inputs)
+		if err != nil {
+			log.Printf("channel %d not pre-rolling: %v", po.ChannelID, err)
+		}
+	}
+	return nil
+}
+
+// preRollPlayout plays a playout's source if it isn't already playing.
+func (mcr *MCR) preRollPlayout(ctx context.Context, po Playout, inputs map[int]brave.Input) error {
+	i, ok := inputs[po.BraveInputID]
+	if !ok {
+		// The reconciler recreates missing inputs.
+		return fmt.Errorf("playout %d input %d: %w", po.ID, po.BraveInputID, brave.ErrNotFound)
+	}
+	if i.DesiredState == brave.StatePlaying || i.State == brave.StatePlaying {
+		return nil
+	}
+	err := mcr.PlayPlayoutSource(ctx, po)
+	if err != nil {
+		return fmt.Errorf("failed to pre-roll playout %d: %w", po.ID, err)
+	}
+	metrics.SchedulerActions.WithLabelValues(schedulePreRoll).Inc()
+	return nil
+}
+
+// endReason says why a live playout should end now, or is empty when it
+// shouldn't. A failed source is failed over from instead.
+func (mcr *MCR) endReason(now time.Time, po Playout, inputs map[int]brave.Input) string {
+	i, ok := inputs[po.BraveInputID]
+	switch {
+	case !ok:
+		return "input gone"
+	case i.Ended():
+		return "input ended"
+	case now.Before(po.ScheduledEnd):
+		return ""
+	case po.EndMode != EndSoft:
+		return "scheduled end"
+	case !now.Before(po.ScheduledEnd.Add(mcr.softEndLimit)):
+		return "soft end limit reached"
+	}
+	return ""
+}
@@ -0,0 +1,415 @@
+package mcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/showtime/brave"
+)
+
+type (
+	// EditTransition sets how a channel changes source.
+	EditTransition struct {
+		// Transition is cut, crossfade or dip.
+		Transition string `json:"transition"`
+		// Duration is how long a crossfade or dip takes, defaults to 1s.
+		Duration time.Duration `json:"duration"`
+	}
+	// transition is how a channel's program changes from one source to
+	// another.
+	transition struct {
+		// Type is empty for the channel's transition.
+		Type     string
+		Duration time.Duration
+	}
+)
+
+// Transition types.
+//
+// Brave can't blend pictures, so transitions are made by putting sources over
+// each other, taking them out of the mix to show the mixer's black background
+// and fading their sound. Only the sound changes gradually, the picture always
+// cuts.
+const (
+	// TransitionCut changes source straight away.
+	TransitionCut = "cut"
+	// TransitionCrossfade cuts the picture by putting the incoming source
+	// over the outgoing one, then crossfades their sound before the
+	// outgoing one is taken out.
+	TransitionCrossfade = "crossfade"
+	// TransitionDip fades the outgoing source's sound out, cuts to black and
+	// holds, then cuts to the incoming source and fades its sound up, each
+	// taking a third of the duration.
+	TransitionDip = "dip"
+	// transitionSeamless puts the incoming source over the outgoing one until
+	// Brave has it in the program, so nothing shows between them. Continuity
+	// cards are swapped with it.
+	transitionSeamless = "seamless"
+)
+
+const (
+	// defaultTransitionDuration is how long a crossfade or dip without a
+	// duration takes.
+	defaultTransitionDuration = time.Second
+	// maxTransitionDuration limits transitions, the scheduler waits for them.
+	maxTransitionDuration = 5 * time.Second
+	// transitionStep is how often sound is faded.
+	transitionStep = 50 * time.Millisecond
+)
+
+// ErrTransitionInvalid when a transition isn't cut, crossfade or dip, or its
+// duration isn't between 0 and 5s.
+var ErrTransitionInvalid = errors.New("transition is invalid")
+
+// cut changes a channel's program straight away, whatever its transition.
+var cut = transition{Type: TransitionCut}
+
+// validateTransition checks a channel's or playout's transition, an empty
+// type is allowed for playouts, which use their channel's.
+func validateTransition(t string, d time.Duration, allowEmpty bool) error {
+	switch t {
+	case TransitionCut, TransitionCrossfade, TransitionDip:
+	case "":
+		if !allowEmpty {
+			return ErrTransitionInvalid
+		}
+	default:
+		return ErrTransitionInvalid
+	}
+	if d < 0 || d > maxTransitionDuration {
+		return ErrTransitionInvalid
+	}
+	return nil
+}
+
+// SetChannelTransition sets how a channel changes source, from its next
+// change.
+func (mcr *MCR) SetChannelTransition(ctx context.Context, channelID int, t EditTransition) error {
+	err := validateTransition(t.Transition, t.Duration, false)
+	if err != nil {
+		return err
+	}
+	_, err = mcr.db.ExecContext(ctx, `
+		UPDATE mcr.channels SET
+			transition = $1,
+			transition_duration = $2
+		WHERE channel_id = $3;`, t.Transition, t.Duration, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to update transition: %w", err)
+	}
+	return nil
+}
+
+// transitionMixer changes a mixer's program from one input to another,
+// returning once it has. A transition from an input that isn't in the program
+// is a cut.
+func (mcr *MCR) transitionMixer(ctx context.Context, mixerID, fromID, toID int, t transition) error {
+	t, err := mcr.checkTransition(ctx, mixerID, fromID, toID, t)
+	if err != nil {
+		return err
+	}
+	if t.Type == TransitionCrossfade || t.Type == TransitionDip {
+		vols, err := mcr.inputVolumes(ctx)
+		if err != nil {
+			return err
+		}
+		defer mcr.restoreVolumes(ctx, vols, fromID, toID)
+
+		if t.Type == TransitionCrossfade {
+			err = mcr.brave.SetInputVolume(ctx, toID, 0)
+			if err != nil {
+				return fmt.Errorf("failed to mute input: %w", err)
+			}
+			err = mcr.brave.OverlayInputOnMixer(ctx, mixerID, toID)
+			if err != nil {
+				return fmt.Errorf("failed to overlay input: %w", err)
+			}
+			err = mcr.crossfade(ctx, t.Duration, fromID, toID, vols)
+			if err != nil {
+				return err
+			}
+		} else {
+			third := t.Duration / 3
+			err = mcr.fadeOut(ctx, third, fromID, vols[fromID])
+			if err != nil {
+				return err
+			}
+			err = mcr.brave.RemoveInputFromMixer(ctx, mixerID, fromID)
+			if err != nil {
+				return fmt.Errorf("failed to remove input: %w", err)
+			}
+			err = holdTransition(ctx, third)
+			if err != nil {
+				return err
+			}
+			err = mcr.brave.SetInputVolume(ctx, toID, 0)
+			if err != nil {
+				return fmt.Errorf("failed to mute input: %w", err)
+			}
+			err = mcr.brave.CutMixerToInput(ctx, mixerID, toID)
+			if err != nil {
+				return fmt.Errorf("failed to cut mixer to input: %w", err)
+			}
+			err = mcr.fadeIn(ctx, third, toID, vols[toID])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.Type == transitionSeamless {
+		err = mcr.brave.OverlayInputOnMixer(ctx, mixerID, toID)
+		if err != nil {
+			return fmt.Errorf("failed to overlay input: %w", err)
+		}
+		err = waitForBrave(ctx, func() (bool, error) {
+			m, err := mcr.brave.GetMixer(ctx, mixerID)
+			if err != nil {
+				return false, err
+			}
+			return inProgram(m, toID), nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed waiting for mixer to overlay: %w", err)
+		}
+	}
+
+	// Takes the outgoing input out of the program.
+	err = mcr.brave.CutMixerToInput(ctx, mixerID, toID)
+	if err != nil {
+		return fmt.Errorf("failed to cut mixer to input: %w", err)
+	}
+	return nil
+}
+
+// transitionUnderCard changes an audio-only channel's program from one input
+// to another, keeping its continuity card over them so only their sound
+// changes. A dip is to silence rather than black.
+//
+// Brave puts a source added to a mixer on top, so the card is added again
+// after the incoming input, whose picture can show for a moment.
+func (mcr *MCR) transitionUnderCard(ctx context.Context, mixerID, cardID, fromID, toID int, t transition) error {
+	t, err := mcr.checkTransition(ctx, mixerID, fromID, toID, t)
+	if err != nil {
+		return err
+	}
+	var vols map[int]float64
+	if t.Type == TransitionCrossfade || t.Type == TransitionDip {
+		vols, err = mcr.inputVolumes(ctx)
+		if err != nil {
+			return err
+		}
+		defer mcr.restoreVolumes(ctx, vols, fromID, toID)
+	}
+
+	// The card is silent, so it is faded like any other input but never
+	// taken out.
+	removeFrom := func() error {
+		if fromID == 0 || fromID == toID || fromID == cardID {
+			return nil
+		}
+		err := mcr.brave.RemoveInputFromMixer(ctx, mixerID, fromID)
+		if err != nil && !errors.Is(err, brave.ErrNotFound) {
+			return fmt.Errorf("failed to remove input: %w", err)
+		}
+		return nil
+	}
+
+	if t.Type == TransitionDip {
+		third := t.Duration / 3
+		err = mcr.fadeOut(ctx, third, fromID, vols[fromID])
+		if err != nil {
+			return err
+		}
+		err = removeFrom()
+		if err != nil {
+			return err
+		}
+		err = holdTransition(ctx, third)
+		if err != nil {
+			return err
+		}
+		err = mcr.brave.SetInputVolume(ctx, toID, 0)
+		if err != nil {
+			return fmt.Errorf("failed to mute input: %w", err)
+		}
+		err = mcr.overlayUnderCard(ctx, mixerID, cardID, toID)
+		if err != nil {
+			return err
+		}
+		return mcr.fadeIn(ctx, third, toID, vols[toID])
+	}
+
+	if t.Type == TransitionCrossfade {
+		err = mcr.brave.SetInputVolume(ctx, toID, 0)
+		if err != nil {
+			return fmt.Errorf("failed to mute input: %w", err)
+		}
+	}
+	err = mcr.overlayUnderCard(ctx, mixerID, cardID, toID)
+	if err != nil {
+		return err
+	}
+	if t.Type == TransitionCrossfade {
+		err = mcr.crossfade(ctx, t.Duration, fromID, toID, vols)
+		if err != nil {
+			return err
+		}
+	}
+	return removeFrom()
+}
+
+// overlayUnderCard adds an input to an audio-only channel's program, then its
+// continuity card over it.
+func (mcr *MCR) overlayUnderCard(ctx context.Context, mixerID, cardID, inputID int) error {
+	if inputID != cardID {
+		err := mcr.brave.OverlayInputOnMixer(ctx, mixerID, inputID)
+		if err != nil {
+			return fmt.Errorf("failed to overlay input: %w", err)
+		}
+	}
+	if cardID == 0 {
+		// Refreshing the card adds it.
+		return nil
+	}
+	err := mcr.brave.OverlayInputOnMixer(ctx, mixerID, cardID)
+	if err != nil {
+		return fmt.Errorf("failed to overlay continuity card: %w", err)
+	}
+	return nil
+}
+
+// cutUnderCard cuts an audio-only channel's program to an input with its
+// continuity card over it, for when a transition fails.
+func (mcr *MCR) cutUnderCard(ctx context.Context, mixerID, cardID, inputID int) error {
+	err := mcr.brave.CutMixerToInput(ctx, mixerID, inputID)
+	if err != nil {
+		return err
+	}
+	return mcr.overlayUnderCard(ctx, mixerID, cardID, inputID)
+}
+
+// checkTransition fills in a transition's duration, and makes it a cut when
+// there is nothing in the program to transition from.
+func (mcr *MCR) checkTransition(ctx context.Context, mixerID, fromID, toID int, t transition) (transition, error) {
+	switch {
+	case fromID == 0 || fromID == toID:
+		t.Type = TransitionCut
+	case t.Type != TransitionCut:
+		m, err := mcr.brave.GetMixer(ctx, mixerID)
+		if err != nil {
+			return transition{}, fmt.Errorf("failed to get mixer: %w", err)
+		}
+		if !inProgram(m, fromID) {
+			t.Type = TransitionCut
+		}
+	}
+	if t.Duration == 0 {
+		t.Duration = defaultTransitionDuration
+	}
+	return t, nil
+}
+
+// inputVolumes gets the volume of each input in Brave, which is its audio level
+// outside of a transition.
+func (mcr *MCR) inputVolumes(ctx context.Context) (map[int]float64, error) {
+	inputs, err := mcr.brave.ListInputs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inputs: %w", err)
+	}
+	vols := make(map[int]float64, len(inputs))
+	for _, i := range inputs {
+		vols[i.ID] = i.Volume
+	}
+	return vols, nil
+}
+
+// restoreVolumes puts inputs back to their volumes after a transition. The
+// outgoing input could be cut back to, such as continuity, and a failed
+// transition mustn't leave the incoming one quiet.
+func (mcr *MCR) restoreVolumes(ctx context.Context, vols map[int]float64, inputIDs ...int) {
+	for _, id := range inputIDs {
+		v, ok := vols[id]
+		if !ok {
+			continue
+		}
+		err := mcr.brave.SetInputVolume(ctx, id, v)
+		if err != nil {
+			log.Printf("failed to restore input %d volume: %v", id, err)
+		}
+	}
+}
+
+// crossfade fades one input's sound out whilst another's fades in, each
+// between silence and its volume.
+func (mcr *MCR) crossfade(ctx context.Context, d time.Duration, fromID, toID int, vols map[int]float64) error {
+	err := fadeSound(ctx, d, func(f float64) error {
+		err := mcr.brave.SetInputVolume(ctx, toID, f*vols[toID])
+		if err != nil {
+			return err
+		}
+		return mcr.brave.SetInputVolume(ctx, fromID, (1-f)*vols[fromID])
+	})
+	if err != nil {
+		return fmt.Errorf("failed to crossfade sound: %w", err)
+	}
+	return nil
+}
+
+// fadeIn fades an input's sound up from silence to its volume.
+func (mcr *MCR) fadeIn(ctx context.Context, d time.Duration, inputID int, volume float64) error {
+	err := fadeSound(ctx, d, func(f float64) error {
+		return mcr.brave.SetInputVolume(ctx, inputID, f*volume)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fade sound in: %w", err)
+	}
+	return nil
+}
+
+// fadeOut fades an input's sound down from its volume to silence.
+func (mcr *MCR) fadeOut(ctx context.Context, d time.Duration, inputID int, volume float64) error {
+	err := fadeSound(ctx, d, func(f float64) error {
+		return mcr.brave.SetInputVolume(ctx, inputID, (1-f)*volume)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fade sound out: %w", err)
+	}
+	return nil
+}
+
+// holdTransition waits part way through a transition.
+func holdTransition(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// fadeSound calls set with how far through a fade of duration d it is, from 0
+// to 1, every transitionStep until it is done.
+func fadeSound(ctx context.Context, d time.Duration, set func(f float64) error) error {
+	start := time.Now()
+	t := time.NewTicker(transitionStep)
+	defer t.Stop()
+	for {
+		f := 1.0
+		if elapsed := time.Since(start); d > 0 && elapsed < d {
+			f = float64(elapsed) / float64(d)
+		}
+		err := set(f)
+		if err != nil || f == 1 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package mcr
+
+import (
+	"context"
+
+	"github.com/ystv/showtime/brave"
+)
+
+// ProgramLostFunc is called when a channel's live playout is lost, reason says
+// why and what the channel was cut to.
+type ProgramLostFunc func(ctx context.Context, ch Channel, po Playout, reason string)
+
+// OnProgramLost sets a function to be called after a channel has failed over
+// because its program was lost.
+func (mcr *MCR) OnProgramLost(f ProgramLostFunc) {
+	mcr.programLost = f
+}
+
+// WatchBrave reacts to updates from Brave until the channel is closed. When an
+// input ends, fails or is deleted the scheduler is woken, so an on-air channel
+// showing it is taken off it or failed over straight away rather than on its
+// next tick.
+func (mcr *MCR) WatchBrave(updates <-chan brave.Update) {
+	for u := range updates {
+		if u.Object != "input" {
+			continue
+		}
+		if u.Deleted || u.Input.Ended() || u.Input.Failed() {
+			mcr.wakeScheduler()
+		}
+	}
+}
+
+func (mcr *MCR) wakeScheduler() {
+	select {
+	case mcr.scheduleWake <- struct{}{}:
+	default:
+	}
+}
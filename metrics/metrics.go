@@ -0,0 +1,183 @@
+// Package metrics defines the Prometheus metrics ShowTime! exposes.
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "showtime"
+
+var (
+	// HTTPRequests counts requests served by route and status.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served by method, route and status code.",
+	}, []string{"method", "route", "code"})
+	// HTTPRequestDuration observes how long requests take to serve by route.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// ForwardersActive is the number of running ffmpeg forwarders by link type.
+	ForwardersActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "forwarders_active",
+		Help:      "Running ffmpeg forwarders by integration type.",
+	}, []string{"integration_type"})
+	// FFmpegRestarts counts forwarders started again for a link that has
+	// already been forwarded, usually when the encoder reconnects.
+	FFmpegRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ffmpeg_restarts_total",
+		Help:      "ffmpeg forwarders restarted for a link by integration type.",
+	}, []string{"integration_type"})
+	// FFmpegExits counts forwarders exiting by whether they failed.
+	FFmpegExits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ffmpeg_exits_total",
+		Help:      "ffmpeg forwarders that exited by integration type and result.",
+	}, []string{"integration_type", "result"})
+
+	// APIRequestDuration observes the latency of requests to external APIs.
+	APIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests to external APIs by API and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"api", "method"})
+	// APIRequestErrors counts failed requests to external APIs, either the
+	// request failed or the response had an error status code.
+	APIRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_request_errors_total",
+		Help:      "Failed requests to external APIs by API and status code, \"error\" when no response was received.",
+	}, []string{"api", "code"})
+
+	// NginxHooks counts nginx-rtmp hook calls by hook and result.
+	NginxHooks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nginx_hooks_total",
+		Help:      "nginx-rtmp hook calls by hook and result.",
+	}, []string{"hook", "result"})
+
+	// ReconcileRuns counts Brave reconciliations by whether they had errors.
+	ReconcileRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_runs_total",
+		Help:      "Brave reconciliations by result.",
+	}, []string{"result"})
+	// ReconcileChanges counts changes made by reconciling Brave.
+	ReconcileChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_changes_total",
+		Help:      "Changes made reconciling Brave by object and action.",
+	}, []string{"object", "action"})
+	// SchedulerActions counts what the playout scheduler has done.
+	SchedulerActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_actions_total",
+		Help:      "Playouts pre-rolled, started and ended by the scheduler.",
+	}, []string{"action"})
+
+	// LivestreamEvents counts livestream events created by type.
+	LivestreamEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "livestream_events_total",
+		Help:      "Livestream events created by type.",
+	}, []string{"type"})
+	// ChannelEvents counts channel events created by type.
+	ChannelEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channel_events_total",
+		Help:      "Channel events created by type.",
+	}, []string{"type"})
+)
+
+// Nginx hook results.
+const (
+	HookOK       = "ok"
+	HookRejected = "rejected"
+	HookError    = "error"
+)
+
+// External APIs.
+const (
+	APIBrave   = "brave"
+	APIYouTube = "youtube"
+)
+
+// StatusCounter counts rows by their status.
+type StatusCounter func(ctx context.Context) (map[string]int, error)
+
+// statusCollector is a gauge of rows by status, counted when scraped so it
+// can't drift from the database.
+type statusCollector struct {
+	desc  *prometheus.Desc
+	count StatusCounter
+}
+
+// RegisterStatusGauge registers a gauge labelled by status that is counted on
+// each scrape.
+func RegisterStatusGauge(name, help string, count StatusCounter) {
+	prometheus.MustRegister(&statusCollector{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{"status"}, nil),
+		count: count,
+	})
+}
+
+func (s *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.desc
+}
+
+func (s *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	counts, err := s.count(ctx)
+	if err != nil {
+		log.Printf("failed to collect %s: %v", s.desc, err)
+		ch <- prometheus.NewInvalidMetric(s.desc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(n), status)
+	}
+}
+
+// transport instruments requests to an external API.
+type transport struct {
+	api  string
+	next http.RoundTripper
+}
+
+// NewTransport wraps a round tripper recording the latency and errors of
+// requests to an external API, http.DefaultTransport is used when next is nil.
+func NewTransport(api string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{api: api, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	APIRequestDuration.WithLabelValues(t.api, req.Method).Observe(time.Since(start).Seconds())
+	switch {
+	case err != nil:
+		APIRequestErrors.WithLabelValues(t.api, "error").Inc()
+	case res.StatusCode >= 400:
+		APIRequestErrors.WithLabelValues(t.api, strconv.Itoa(res.StatusCode)).Inc()
+	}
+	return res, err
+}
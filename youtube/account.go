@@ -6,6 +6,8 @@ import (
 
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
+
+	"github.com/ystv/showtime/metrics"
 )
 
 type (
@@ -22,6 +24,7 @@ func (y *YouTube) NewAccount(ctx context.Context, tokenID int) error {
 	if err != nil {
 		return fmt.Errorf("failed to get youtube http client: %w", err)
 	}
+	httpClient.Transport = metrics.NewTransport(metrics.APIYouTube, httpClient.Transport)
 	ytClient, err := youtube.NewService(ctx, option.WithHTTPClient(httpClient))
 	if err != nil {
 		return fmt.Errorf("failed to create youtube service: %w", err)
@@ -66,3 +69,17 @@ func (y *YouTube) listAccounts(ctx context.Context) ([]Account, error) {
 	}
 	return accounts, nil
 }
+
+// CheckAccounts checks the token of every integrated account is valid,
+// returning the error for each account ID, nil when it is valid.
+func (y *YouTube) CheckAccounts(ctx context.Context) (map[int]error, error) {
+	accounts, err := y.listAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	errs := make(map[int]error, len(accounts))
+	for _, account := range accounts {
+		errs[account.ID] = y.auth.CheckToken(ctx, account.TokenID)
+	}
+	return errs, nil
+}
@@ -359,6 +359,11 @@ func (y *YouTuber) ListShowTimedBroadcasts(ctx context.Context) ([]Broadcast, er
 	return broadcasts, err
 }
 
+// WatchURL returns the public URL of the broadcast on YouTube.
+func (b Broadcast) WatchURL() string {
+	return "https://www.youtube.com/watch?v=" + b.ID
+}
+
 // PrettyDateTime formats dates to a more readable string.
 func (b *Broadcast) PrettyDateTime() string {
 	ts, err := time.Parse(time.RFC3339, b.ScheduledStart)
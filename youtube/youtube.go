@@ -11,6 +11,7 @@ import (
 	"google.golang.org/api/youtube/v3"
 
 	"github.com/ystv/showtime/auth"
+	"github.com/ystv/showtime/metrics"
 )
 
 type (
@@ -53,6 +54,7 @@ func New(ctx context.Context, db *sqlx.DB, auth *auth.Auther) (*YouTube, error)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get youtube http client: %w", err)
 		}
+		httpClient.Transport = metrics.NewTransport(metrics.APIYouTube, httpClient.Transport)
 		ytClient, err := youtube.NewService(ctx, option.WithHTTPClient(httpClient))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create youtube service: %w", err)